@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/docs"
+)
+
+// runDocs implements `tfschema docs`, which renders a reference of the input
+// variables to stdout, or into a document between the docs markers.
+func runDocs(args []string) {
+	flags := flag.NewFlagSet("docs", flag.ExitOnError)
+	htmlFlag := flags.Bool("html", false, "Render HTML instead of Markdown")
+	injectFlag := flags.String("inject", "", "Replace the text between the docs markers of this file instead of printing")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: tfschema docs [-html] [-inject README.md] <file.tf>")
+		os.Exit(1)
+	}
+
+	c := converter.New()
+	if _, err := c.ConvertFile(flags.Arg(0)); err != nil {
+		fmt.Printf("Error converting file: %v\n", err)
+		os.Exit(1)
+	}
+
+	inputs := docs.Inputs(c.Variables(), c.Validations(), c.Untranslatable())
+	output := docs.Markdown(inputs)
+	if *htmlFlag {
+		output = docs.HTML(inputs)
+	}
+
+	if *injectFlag == "" {
+		fmt.Print(output)
+		return
+	}
+	document, err := os.ReadFile(*injectFlag)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", *injectFlag, err)
+		os.Exit(1)
+	}
+	updated, err := docs.Inject(string(document), output)
+	if err != nil {
+		fmt.Printf("Error injecting into %s: %v\n", *injectFlag, err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*injectFlag, []byte(updated), 0o644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", *injectFlag, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/example"
+)
+
+// runExample implements `tfschema example`, which prints example inputs
+// satisfying the schema as terraform.tfvars.json, or terraform.tfvars with
+// -hcl.
+func runExample(args []string) {
+	flags := flag.NewFlagSet("example", flag.ExitOnError)
+	hclFlag := flags.Bool("hcl", false, "Write terraform.tfvars syntax with the descriptions as comments instead of JSON")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: tfschema example [-hcl] <file.tf>")
+		os.Exit(1)
+	}
+
+	c := converter.New()
+	if _, err := c.ConvertFile(flags.Arg(0)); err != nil {
+		fmt.Printf("Error converting file: %v\n", err)
+		os.Exit(1)
+	}
+
+	assignments := example.Generate(c.Variables())
+	failed, err := example.Check(assignments, c.Validations())
+	if err != nil {
+		fmt.Printf("Error checking the example: %v\n", err)
+		os.Exit(1)
+	}
+	for _, v := range failed {
+		fmt.Fprintf(os.Stderr, "Warning: variable %q: the example does not satisfy %s\n", v.Variable, v.Source)
+	}
+	for _, skipped := range c.Untranslatable() {
+		fmt.Fprintf(os.Stderr, "Warning: variable %q: the example may not satisfy %s\n", skipped.Variable, skipped.Source)
+	}
+
+	if *hclFlag {
+		fmt.Print(example.HCL(assignments))
+		return
+	}
+	output, err := example.JSON(assignments)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(output)
+}
@@ -5,14 +5,44 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/crd"
+	"github.com/alex-tw-lam/tfschema/internal/cue"
+	"github.com/alex-tw-lam/tfschema/internal/gostruct"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/pydantic"
+	"github.com/alex-tw-lam/tfschema/internal/typescript"
+	"github.com/alex-tw-lam/tfschema/internal/uischema"
 )
 
 var version = "dev"
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "docs":
+			runDocs(os.Args[2:])
+			return
+		case "example":
+			runExample(os.Args[2:])
+			return
+		case "variables":
+			runVariables(os.Args[2:])
+			return
+		case "rego":
+			runRego(os.Args[2:])
+			return
+		}
+	}
+
 	versionFlag := flag.Bool("version", false, "Print the version and exit")
+	draftFlag := flag.String("draft", string(jsonschema.Draft07), "JSON Schema draft to generate (draft-07, 2019-09 or 2020-12)")
+	formatFlag := flag.String("format", "json", "Output format (json, typescript, go, pydantic, crd, cue or uischema)")
+	moduleFlag := flag.String("module", "", "Module name for generated types (defaults to the directory of the file)")
+	packageFlag := flag.String("package", "", "Package name of generated Go or CUE code (defaults to the module name)")
+	groupFlag := flag.String("group", crd.DefaultGroup, "API group of the generated CRD")
 	flag.Parse()
 
 	if *versionFlag {
@@ -21,18 +51,85 @@ func main() {
 	}
 
 	if len(flag.Args()) != 1 {
-		fmt.Println("Usage: tfschema <file.tf>")
+		fmt.Println("Usage: tfschema [-draft draft-07|2019-09|2020-12] [-format json|typescript|go|pydantic|crd|cue|uischema] [-module name] [-package name] [-group name] <file.tf>")
+		fmt.Println("       tfschema docs [-html] [-inject README.md] <file.tf>")
+		fmt.Println("       tfschema example [-hcl] <file.tf>")
+		fmt.Println("       tfschema variables <schema.json>")
+		fmt.Println("       tfschema rego [-package name] [-module name] <file.tf>")
+		os.Exit(1)
+	}
+
+	draft, err := jsonschema.ParseDraft(*draftFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	filepath := flag.Arg(0)
-	c := converter.New()
-	schema, err := c.ConvertFile(filepath)
+	file := flag.Arg(0)
+	c := converter.New(converter.WithDraft(draft))
+	schema, err := c.ConvertFile(file)
 	if err != nil {
 		fmt.Printf("Error converting file: %v\n", err)
 		os.Exit(1)
 	}
 
+	for _, skipped := range c.Untranslatable() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", skipped)
+	}
+
+	switch *formatFlag {
+	case "json":
+	case "typescript":
+		fmt.Print(typescript.Generate(moduleName(*moduleFlag, file), c.Variables()))
+		return
+	case "go":
+		module := moduleName(*moduleFlag, file)
+		pkg := *packageFlag
+		if pkg == "" {
+			pkg = gostruct.PackageName(module)
+		}
+		source, err := gostruct.Generate(pkg, module, c.Variables(), c.Validations())
+		if err != nil {
+			fmt.Printf("Error generating Go code: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(source)
+		return
+	case "pydantic":
+		fmt.Print(pydantic.Generate(moduleName(*moduleFlag, file), c.Variables()))
+		return
+	case "cue":
+		module := moduleName(*moduleFlag, file)
+		pkg := *packageFlag
+		if pkg == "" {
+			pkg = gostruct.PackageName(module)
+		}
+		fmt.Print(cue.Generate(pkg, module, c.Variables(), c.Validations()))
+		return
+	case "uischema":
+		ui, err := json.MarshalIndent(uischema.Generate(c.Variables()), "", "  ")
+		if err != nil {
+			fmt.Printf("Error marshalling to JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(ui))
+		return
+	case "crd":
+		manifest, unchecked, err := crd.Generate(*groupFlag, moduleName(*moduleFlag, file), c.Variables(), c.Validations(), c.Untranslatable())
+		if err != nil {
+			fmt.Printf("Error generating CRD: %v\n", err)
+			os.Exit(1)
+		}
+		for _, u := range unchecked {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", u)
+		}
+		fmt.Print(manifest)
+		return
+	default:
+		fmt.Printf("Error: unknown format %q\n", *formatFlag)
+		os.Exit(1)
+	}
+
 	jsonOutput, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
 		fmt.Printf("Error marshalling to JSON: %v\n", err)
@@ -41,3 +138,16 @@ func main() {
 
 	fmt.Println(string(jsonOutput))
 }
+
+// moduleName returns the name of the module declaring the variables: the
+// -module flag if set, or else the name of the directory holding the file.
+func moduleName(flagValue, file string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	dir, err := filepath.Abs(filepath.Dir(file))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(dir)
+}
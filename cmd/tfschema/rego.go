@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/gostruct"
+	"github.com/alex-tw-lam/tfschema/internal/rego"
+)
+
+// runRego implements `tfschema rego`, which prints an Open Policy Agent
+// policy denying inputs that break the type constraints or the validation
+// blocks of the variables.
+func runRego(args []string) {
+	flags := flag.NewFlagSet("rego", flag.ExitOnError)
+	packageFlag := flags.String("package", "", "Package of the policy (defaults to terraform.<module name>)")
+	moduleFlag := flags.String("module", "", "Module name (defaults to the directory of the file)")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: tfschema rego [-package name] [-module name] <file.tf>")
+		os.Exit(1)
+	}
+
+	file := flags.Arg(0)
+	c := converter.New()
+	if _, err := c.ConvertFile(file); err != nil {
+		fmt.Printf("Error converting file: %v\n", err)
+		os.Exit(1)
+	}
+
+	pkg := *packageFlag
+	if pkg == "" {
+		pkg = "terraform." + gostruct.PackageName(moduleName(*moduleFlag, file))
+	}
+	fmt.Print(rego.Generate(pkg, c.Variables(), c.Validations(), c.Untranslatable()))
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/variables"
+)
+
+// runVariables implements `tfschema variables`, which prints the variable
+// blocks declaring the properties of a JSON Schema.
+func runVariables(args []string) {
+	flags := flag.NewFlagSet("variables", flag.ExitOnError)
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: tfschema variables <schema.json>")
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		fmt.Printf("Error parsing schema: %v\n", err)
+		os.Exit(1)
+	}
+
+	source, skipped, err := variables.Generate(&schema)
+	if err != nil {
+		fmt.Printf("Error generating variables: %v\n", err)
+		os.Exit(1)
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", s)
+	}
+	fmt.Print(string(source))
+}
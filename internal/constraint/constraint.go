@@ -0,0 +1,283 @@
+// Package constraint defines an intermediate representation of the conditions
+// of Terraform validation blocks. Rule parsers lower each condition into a
+// tree of predicates on paths, which output backends then render without
+// having to understand HCL expressions.
+package constraint
+
+import "github.com/hashicorp/hcl/v2"
+
+// Constraint is a node of a constraint tree: an Atom, a logical combination
+// of constraints, or Never.
+type Constraint interface {
+	constraint()
+}
+
+// Atom applies a predicate to the value at Path. A value that is absent or
+// null satisfies the predicate, as in JSON Schema, except in the If of an
+// Implies.
+type Atom struct {
+	Path      Path
+	Predicate Predicate
+}
+
+// And holds when all of its terms hold.
+type And struct {
+	Terms []Constraint
+}
+
+// Or holds when at least one of its terms holds.
+type Or struct {
+	Terms []Constraint
+}
+
+// Not holds when its term does not.
+type Not struct {
+	Term Constraint
+}
+
+// Implies requires Then when If holds and Else, if set, when it does not.
+// Then may also be nil.
+type Implies struct {
+	If   Constraint
+	Then Constraint
+	Else Constraint
+}
+
+// Never is not satisfied by any value.
+type Never struct{}
+
+func (Atom) constraint()    {}
+func (And) constraint()     {}
+func (Or) constraint()      {}
+func (Not) constraint()     {}
+func (Implies) constraint() {}
+func (Never) constraint()   {}
+
+// Predicate is a check on a single value.
+type Predicate interface {
+	predicate()
+}
+
+// Length bounds the number of characters of a string or the number of
+// elements of a collection.
+type Length struct {
+	Min *int
+	Max *int
+}
+
+// Range bounds a number.
+type Range struct {
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum *float64
+	ExclusiveMaximum *float64
+}
+
+// Pattern requires a string to match an ECMA-262 regular expression. RE2
+// holds the same expression as Terraform's regex() takes it, for engines such
+// as Go's regexp; it is empty when Pattern already is valid RE2.
+type Pattern struct {
+	Pattern string
+	RE2     string
+}
+
+// Regexp returns the expression in RE2 syntax.
+func (p Pattern) Regexp() string {
+	if p.RE2 != "" {
+		return p.RE2
+	}
+	return p.Pattern
+}
+
+// Format requires a string to be in a JSON Schema format, such as date-time.
+type Format struct {
+	Format string
+}
+
+// Enum requires a value to equal one of Values, which hold strings, float64
+// numbers and booleans.
+type Enum struct {
+	Values []interface{}
+}
+
+// Integer requires a number to be whole, or a string to hold a base-10
+// integer.
+type Integer struct{}
+
+// MultipleOf requires a number to be a multiple of Value.
+type MultipleOf struct {
+	Value float64
+}
+
+// UniqueItems requires the elements of a collection to be distinct.
+type UniqueItems struct{}
+
+// Required requires an object or map to have non-null Properties.
+type Required struct {
+	Properties []string
+}
+
+// Contains requires at least one element of a collection, or one value of a
+// map, to satisfy Element. The paths in Element are relative to the element.
+type Contains struct {
+	Element Constraint
+}
+
+// Satisfies requires a value to satisfy Constraint, whose paths are relative
+// to the value. Applied at a path with a wildcard, it checks a condition such
+// as an Implies on each element separately.
+type Satisfies struct {
+	Constraint Constraint
+}
+
+func (Length) predicate()      {}
+func (Range) predicate()       {}
+func (Pattern) predicate()     {}
+func (Format) predicate()      {}
+func (Enum) predicate()        {}
+func (Integer) predicate()     {}
+func (MultipleOf) predicate()  {}
+func (UniqueItems) predicate() {}
+func (Required) predicate()    {}
+func (Contains) predicate()    {}
+func (Satisfies) predicate()   {}
+
+// Validation is the lowered form of a translated validation block.
+type Validation struct {
+	Variable     string
+	Condition    hcl.Expression
+	ErrorMessage string
+	Source       string // Original HCL of the condition, filled in by the converter
+	// Constraint's paths start with the name of a variable, so that one tree
+	// can span several variables.
+	Constraint Constraint
+}
+
+// Prefix returns c with prefix prepended to the path of every atom. The
+// element constraints of Contains keep their relative paths. Below a wildcard
+// or keys(...), a Not, Or, Implies or Never is wrapped in Satisfies instead,
+// as it holds for each value on its own rather than for all of them at once.
+func Prefix(c Constraint, prefix Path) Constraint {
+	if len(prefix) == 0 {
+		return c
+	}
+	switch n := c.(type) {
+	case Atom:
+		return Atom{Path: prefix.Append(n.Path...), Predicate: n.Predicate}
+	case And:
+		return And{Terms: prefixAll(n.Terms, prefix)}
+	}
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if kind := prefix[i].Kind; kind == WildcardSegment || kind == KeysSegment {
+			return Atom{Path: prefix[:i+1].Append(), Predicate: Satisfies{Constraint: Prefix(c, prefix[i+1:])}}
+		}
+	}
+	switch n := c.(type) {
+	case Or:
+		return Or{Terms: prefixAll(n.Terms, prefix)}
+	case Not:
+		return Not{Term: Prefix(n.Term, prefix)}
+	case Implies:
+		return Implies{If: Prefix(n.If, prefix), Then: prefixOptional(n.Then, prefix), Else: prefixOptional(n.Else, prefix)}
+	}
+	return c
+}
+
+func prefixOptional(c Constraint, prefix Path) Constraint {
+	if c == nil {
+		return nil
+	}
+	return Prefix(c, prefix)
+}
+
+func prefixAll(terms []Constraint, prefix Path) []Constraint {
+	prefixed := make([]Constraint, len(terms))
+	for i, term := range terms {
+		prefixed[i] = Prefix(term, prefix)
+	}
+	return prefixed
+}
+
+// Atoms calls fn for every atom of c, in order. The element constraints of
+// Contains and Satisfies are left out, as their paths are relative.
+func Atoms(c Constraint, fn func(Atom)) {
+	leaves(c, func(leaf Constraint) {
+		if atom, ok := leaf.(Atom); ok {
+			fn(atom)
+		}
+	})
+}
+
+// CommonPath returns the longest path shared by the atoms of c. Never, which
+// has no atom, is anchored at the value itself.
+func CommonPath(c Constraint) Path {
+	var paths []Path
+	leaves(c, func(leaf Constraint) {
+		if atom, ok := leaf.(Atom); ok {
+			paths = append(paths, atom.Path)
+		} else {
+			paths = append(paths, nil)
+		}
+	})
+	return CommonPrefix(paths...)
+}
+
+// Trim removes the first n segments, such as those CommonPath returned, from
+// the path of every atom of c. It is the inverse of Prefix.
+func Trim(c Constraint, n int) Constraint {
+	if n == 0 {
+		return c
+	}
+	switch node := c.(type) {
+	case Atom:
+		return Atom{Path: node.Path[n:], Predicate: node.Predicate}
+	case And:
+		return And{Terms: trimAll(node.Terms, n)}
+	case Or:
+		return Or{Terms: trimAll(node.Terms, n)}
+	case Not:
+		return Not{Term: Trim(node.Term, n)}
+	case Implies:
+		implies := Implies{If: Trim(node.If, n)}
+		if node.Then != nil {
+			implies.Then = Trim(node.Then, n)
+		}
+		if node.Else != nil {
+			implies.Else = Trim(node.Else, n)
+		}
+		return implies
+	}
+	return c
+}
+
+func trimAll(terms []Constraint, n int) []Constraint {
+	trimmed := make([]Constraint, len(terms))
+	for i, term := range terms {
+		trimmed[i] = Trim(term, n)
+	}
+	return trimmed
+}
+
+// leaves calls fn for every atom and Never of c.
+func leaves(c Constraint, fn func(Constraint)) {
+	switch n := c.(type) {
+	case And:
+		for _, term := range n.Terms {
+			leaves(term, fn)
+		}
+	case Or:
+		for _, term := range n.Terms {
+			leaves(term, fn)
+		}
+	case Not:
+		leaves(n.Term, fn)
+	case Implies:
+		for _, term := range []Constraint{n.If, n.Then, n.Else} {
+			if term != nil {
+				leaves(term, fn)
+			}
+		}
+	default:
+		fn(c)
+	}
+}
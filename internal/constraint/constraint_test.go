@@ -0,0 +1,186 @@
+package constraint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func intPtr(v int) *int { return &v }
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestEvaluate(t *testing.T) {
+	inputs := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.StringVal("web"),
+		"port": cty.NumberIntVal(8080),
+		"tags": cty.MapVal(map[string]cty.Value{
+			"env":  cty.StringVal("prod"),
+			"team": cty.StringVal("platform"),
+		}),
+		"subnets": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{"public": cty.True, "cidr": cty.StringVal("10.0.0.0/24")}),
+			cty.ObjectVal(map[string]cty.Value{"public": cty.False, "cidr": cty.NullVal(cty.String)}),
+		}),
+		"cert": cty.NullVal(cty.String),
+	})
+
+	tests := []struct {
+		name       string
+		constraint Constraint
+		want       bool
+	}{
+		{"length", Atom{Path: Path{Attr("name")}, Predicate: Length{Min: intPtr(3), Max: intPtr(3)}}, true},
+		{"range", Atom{Path: Path{Attr("port")}, Predicate: Range{ExclusiveMaximum: floatPtr(8080)}}, false},
+		{"pattern", Atom{Path: Path{Attr("name")}, Predicate: Pattern{Pattern: "^[a-z]+$"}}, true},
+		{"pattern in RE2 syntax", Atom{Path: Path{Attr("name")}, Predicate: Pattern{Pattern: `^\u0077[a-z]+$`, RE2: `^\x{77}[a-z]+$`}}, true},
+		{"enum on map values", Atom{Path: Path{Attr("tags"), Wildcard()}, Predicate: Enum{Values: []interface{}{"prod", "platform"}}}, true},
+		{"map entry", Atom{Path: Path{Attr("tags"), Key("env")}, Predicate: Enum{Values: []interface{}{"prod"}}}, true},
+		{"missing map entry", Atom{Path: Path{Attr("tags"), Key("owner")}, Predicate: Length{Min: intPtr(1)}}, true},
+		{"map keys", Atom{Path: Path{Attr("tags"), Keys()}, Predicate: Length{Max: intPtr(3)}}, false},
+		{"null value satisfies", Atom{Path: Path{Attr("cert")}, Predicate: Pattern{Pattern: "^arn:"}}, true},
+		{"required", Atom{Path: Path{Attr("subnets"), Wildcard()}, Predicate: Required{Properties: []string{"cidr"}}}, false},
+		{"not", Not{Term: Atom{Path: Path{Attr("port")}, Predicate: MultipleOf{Value: 3}}}, true},
+		{"or", Or{Terms: []Constraint{Never{}, Atom{Path: Path{Attr("port")}, Predicate: Integer{}}}}, true},
+		{
+			"contains",
+			Atom{Path: Path{Attr("subnets")}, Predicate: Contains{Element: Atom{Path: Path{Attr("public")}, Predicate: Enum{Values: []interface{}{true}}}}},
+			true,
+		},
+		{
+			"implies with a missing condition takes else",
+			Implies{
+				If:   Atom{Path: Path{Attr("cert")}, Predicate: Length{Min: intPtr(1)}},
+				Then: Never{},
+				Else: Atom{Path: Path{Attr("port")}, Predicate: Range{Minimum: floatPtr(9000)}},
+			},
+			false,
+		},
+		{
+			"implies per element",
+			Implies{
+				If:   Atom{Path: Path{Attr("name")}, Predicate: Enum{Values: []interface{}{"web"}}},
+				Then: Atom{Path: Path{Attr("subnets"), Index(0), Attr("cidr")}, Predicate: Pattern{Pattern: "/24$"}},
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Evaluate(tt.constraint, inputs)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvaluateUnknownValues(t *testing.T) {
+	inputs := cty.ObjectVal(map[string]cty.Value{"name": cty.UnknownVal(cty.String)})
+	got, err := Evaluate(Atom{Path: Path{Attr("name")}, Predicate: Length{Min: intPtr(3)}}, inputs)
+	require.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		constraint Constraint
+		want       string
+	}{
+		{Atom{Path: Path{Attr("name")}, Predicate: Length{Min: intPtr(3), Max: intPtr(24)}}, "length of `name` is between 3 and 24"},
+		{Atom{Path: Path{Attr("port")}, Predicate: Range{ExclusiveMinimum: floatPtr(0), Maximum: floatPtr(65535)}}, "`port` is greater than 0 and at most 65535"},
+		{Not{Term: Atom{Path: Path{Attr("env")}, Predicate: Enum{Values: []interface{}{"dev", "test"}}}}, "`env` is not one of \"dev\", \"test\""},
+		{Atom{Path: Path{Attr("tags"), Keys()}, Predicate: Pattern{Pattern: "^[a-z]+$"}}, "`keys(tags)[*]` matches `^[a-z]+$`"},
+		{Atom{Path: Path{Attr("tags"), Key("env")}, Predicate: Length{Min: intPtr(1)}}, "length of `tags[\"env\"]` is at least 1"},
+		{Atom{Path: Path{Attr("db")}, Predicate: Required{Properties: []string{"user", "password"}}}, "`db.user` and `db.password` are set"},
+		{
+			Implies{
+				If:   Atom{Path: Path{Attr("tls")}, Predicate: Enum{Values: []interface{}{true}}},
+				Then: Atom{Predicate: Required{Properties: []string{"cert"}}},
+			},
+			"if `tls` is true, then `cert` is set",
+		},
+		{
+			Atom{Path: Path{Attr("subnets")}, Predicate: Contains{Element: Atom{Path: Path{Attr("public")}, Predicate: Enum{Values: []interface{}{true}}}}},
+			"at least one element of `subnets` satisfies: `public` is true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, Describe(tt.constraint))
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	element := Atom{Path: Path{Attr("public")}, Predicate: Enum{Values: []interface{}{true}}}
+	c := And{Terms: []Constraint{
+		Atom{Path: Path{Attr("name")}, Predicate: Length{Min: intPtr(1)}},
+		Atom{Predicate: Contains{Element: element}},
+	}}
+
+	prefixed := Prefix(c, Path{Attr("config")}).(And)
+	assert.Equal(t, Path{Attr("config"), Attr("name")}, prefixed.Terms[0].(Atom).Path)
+	contains := prefixed.Terms[1].(Atom)
+	assert.Equal(t, Path{Attr("config")}, contains.Path)
+	assert.Equal(t, element, contains.Predicate.(Contains).Element, "element paths stay relative")
+}
+
+func TestPrefixBelowWildcard(t *testing.T) {
+	public := Atom{Path: Path{Attr("public")}, Predicate: Enum{Values: []interface{}{true}}}
+	c := Prefix(Not{Term: public}, Path{Attr("subnets"), Wildcard()})
+
+	satisfies := c.(Atom)
+	assert.Equal(t, Path{Attr("subnets"), Wildcard()}, satisfies.Path)
+	assert.Equal(t, Not{Term: public}, satisfies.Predicate.(Satisfies).Constraint)
+
+	inputs := cty.ObjectVal(map[string]cty.Value{"subnets": cty.ListVal([]cty.Value{
+		cty.ObjectVal(map[string]cty.Value{"public": cty.False}),
+		cty.ObjectVal(map[string]cty.Value{"public": cty.True}),
+	})})
+	got, err := Evaluate(c, inputs)
+	require.NoError(t, err)
+	assert.False(t, got, "every element must be non-public, not just one of them")
+}
+
+func TestCommonPathAndTrim(t *testing.T) {
+	port := Atom{Path: Path{Attr("service"), Attr("port")}, Predicate: Range{Minimum: floatPtr(1)}}
+	host := Atom{Path: Path{Attr("service"), Attr("host")}, Predicate: Length{Min: intPtr(1)}}
+	c := Implies{If: port, Then: Not{Term: host}}
+
+	assert.Equal(t, Path{Attr("service")}, CommonPath(c))
+	assert.Equal(t, Implies{
+		If:   Atom{Path: Path{Attr("port")}, Predicate: port.Predicate},
+		Then: Not{Term: Atom{Path: Path{Attr("host")}, Predicate: host.Predicate}},
+	}, Trim(c, 1))
+	assert.Equal(t, c, Prefix(Trim(c, 1), CommonPath(c)))
+
+	assert.Empty(t, CommonPath(Or{Terms: []Constraint{port, Never{}}}), "Never is anchored at the value itself")
+
+	var paths []string
+	Atoms(And{Terms: []Constraint{c, Atom{Path: Path{Attr("zones")}, Predicate: Contains{Element: host}}}}, func(a Atom) {
+		paths = append(paths, a.Path.String())
+	})
+	assert.Equal(t, []string{"service.port", "service.host", "zones"}, paths)
+}
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		path Path
+		want string
+	}{
+		{Path{Attr("subnets"), Wildcard(), Attr("cidr")}, "subnets[*].cidr"},
+		{Path{Attr("rules"), Index(0), Attr("port")}, "rules[0].port"},
+		{Path{Attr("tags"), Key("env")}, `tags["env"]`},
+		{Path{Attr("tags"), Keys()}, "keys(tags)[*]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.path.String())
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package constraint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Describe renders a constraint as an English sentence fragment, such as
+// "length of `name` is at most 60", for documentation.
+func Describe(c Constraint) string {
+	switch n := c.(type) {
+	case Atom:
+		return describeAtom(n, false)
+	case And:
+		return joinDescriptions(n.Terms, " and ")
+	case Or:
+		return joinDescriptions(n.Terms, " or ")
+	case Not:
+		if atom, ok := n.Term.(Atom); ok {
+			return describeAtom(atom, true)
+		}
+		return "not (" + Describe(n.Term) + ")"
+	case Implies:
+		if n.Then == nil {
+			return "unless " + Describe(n.If) + ", " + describeOptional(n.Else)
+		}
+		description := "if " + Describe(n.If) + ", then " + Describe(n.Then)
+		if n.Else != nil {
+			description += ", otherwise " + Describe(n.Else)
+		}
+		return description
+	case Never:
+		return "never satisfied"
+	}
+	return fmt.Sprintf("%T", c)
+}
+
+func describeOptional(c Constraint) string {
+	if c == nil {
+		return "no constraint"
+	}
+	return Describe(c)
+}
+
+func joinDescriptions(terms []Constraint, sep string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = Describe(term)
+		if _, nested := term.(Atom); !nested && len(terms) > 1 {
+			if _, negated := term.(Not); !negated {
+				parts[i] = "(" + parts[i] + ")"
+			}
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+func describeAtom(atom Atom, negated bool) string {
+	// An empty path is the element of a Contains.
+	subject := "it"
+	if len(atom.Path) > 0 {
+		subject = "`" + atom.Path.String() + "`"
+	}
+	is, matches := "is", "matches"
+	if negated {
+		is, matches = "is not", "does not match"
+	}
+
+	switch p := atom.Predicate.(type) {
+	case Length:
+		return "length of " + subject + " " + is + " " + describeBounds(intBound(p.Min), intBound(p.Max), "", "")
+	case Range:
+		min, minWord := floatBound(p.Minimum), "at least"
+		if p.ExclusiveMinimum != nil {
+			min, minWord = floatBound(p.ExclusiveMinimum), "greater than"
+		}
+		max, maxWord := floatBound(p.Maximum), "at most"
+		if p.ExclusiveMaximum != nil {
+			max, maxWord = floatBound(p.ExclusiveMaximum), "less than"
+		}
+		return subject + " " + is + " " + describeBounds(min, max, minWord, maxWord)
+	case Pattern:
+		return subject + " " + matches + " `" + p.Pattern + "`"
+	case Format:
+		return subject + " " + is + " a valid " + p.Format
+	case Enum:
+		values := make([]string, len(p.Values))
+		for i, v := range p.Values {
+			values[i] = formatValue(v)
+		}
+		if len(values) == 1 {
+			return subject + " " + is + " " + values[0]
+		}
+		return subject + " " + is + " one of " + strings.Join(values, ", ")
+	case Integer:
+		return subject + " " + is + " a whole number"
+	case MultipleOf:
+		return subject + " " + is + " a multiple of " + strconv.FormatFloat(p.Value, 'f', -1, 64)
+	case UniqueItems:
+		if negated {
+			return subject + " has duplicate elements"
+		}
+		return subject + " has no duplicate elements"
+	case Required:
+		names := make([]string, len(p.Properties))
+		for i, name := range p.Properties {
+			names[i] = "`" + atom.Path.Append(Attr(name)).String() + "`"
+		}
+		verb := "is set"
+		if len(names) > 1 {
+			verb = "are set"
+		}
+		if negated {
+			verb = strings.Replace(verb, " set", " not set", 1)
+		}
+		return strings.Join(names, " and ") + " " + verb
+	case Contains:
+		element := Describe(p.Element)
+		if negated {
+			return "no element of " + subject + " satisfies: " + element
+		}
+		return "at least one element of " + subject + " satisfies: " + element
+	case Satisfies:
+		if negated {
+			return subject + " does not satisfy: " + Describe(p.Constraint)
+		}
+		return subject + " satisfies: " + Describe(p.Constraint)
+	}
+	return subject + " " + is + fmt.Sprintf(" %T", atom.Predicate)
+}
+
+// describeBounds renders a pair of optional bounds, such as "between 1 and
+// 10" or "at most 5".
+func describeBounds(min, max, minWord, maxWord string) string {
+	if minWord == "" {
+		minWord, maxWord = "at least", "at most"
+	}
+	switch {
+	case min != "" && max != "" && min == max && minWord == "at least" && maxWord == "at most":
+		return "exactly " + min
+	case min != "" && max != "" && minWord == "at least" && maxWord == "at most":
+		return "between " + min + " and " + max
+	case min != "" && max != "":
+		return minWord + " " + min + " and " + maxWord + " " + max
+	case min != "":
+		return minWord + " " + min
+	case max != "":
+		return maxWord + " " + max
+	}
+	return "unbounded"
+}
+
+func intBound(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func floatBound(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
+
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strconv.Quote(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,289 @@
+package constraint
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// integerStringPattern matches the strings parseint accepts in base 10.
+var integerStringPattern = regexp.MustCompile(`^[+-]?[0-9]+$`)
+
+// Evaluate reports whether value satisfies c. Paths are resolved from value,
+// so the constraint of a Validation is evaluated against an object holding
+// the input variables. Unknown values satisfy every constraint.
+func Evaluate(c Constraint, value cty.Value) (bool, error) {
+	return evaluate(c, value, true)
+}
+
+// evaluate checks c against value. vacuous is the result of an atom whose
+// path does not lead to a value: true in general, false in the If of an
+// Implies, matching the required properties of a JSON Schema 'if'.
+func evaluate(c Constraint, value cty.Value, vacuous bool) (bool, error) {
+	switch n := c.(type) {
+	case Atom:
+		values, missing := resolve(value, n.Path)
+		if missing && !vacuous {
+			return false, nil
+		}
+		for _, v := range values {
+			ok, err := check(n.Predicate, v)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case And:
+		for _, term := range n.Terms {
+			ok, err := evaluate(term, value, vacuous)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case Or:
+		for _, term := range n.Terms {
+			ok, err := evaluate(term, value, vacuous)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case Not:
+		ok, err := evaluate(n.Term, value, !vacuous)
+		return !ok && err == nil, err
+	case Implies:
+		holds, err := evaluate(n.If, value, false)
+		if err != nil {
+			return false, err
+		}
+		branch := n.Then
+		if !holds {
+			branch = n.Else
+		}
+		if branch == nil {
+			return true, nil
+		}
+		return evaluate(branch, value, vacuous)
+	case Never:
+		return false, nil
+	}
+	return false, fmt.Errorf("unsupported constraint %T", c)
+}
+
+// resolve returns the known, non-null values at path, expanding wildcards,
+// and whether the path is missing for some of them.
+func resolve(value cty.Value, path Path) (values []cty.Value, missing bool) {
+	if !value.IsKnown() {
+		return nil, false
+	}
+	if value.IsNull() {
+		return nil, true
+	}
+	if len(path) == 0 {
+		return []cty.Value{value}, false
+	}
+
+	segment, rest := path[0], path[1:]
+	var next []cty.Value
+	ty := value.Type()
+	switch segment.Kind {
+	case WildcardSegment:
+		next = elements(value)
+	case KeysSegment:
+		if !ty.IsMapType() && !ty.IsObjectType() {
+			return nil, true
+		}
+		for it := value.ElementIterator(); it.Next(); {
+			key, _ := it.Element()
+			next = append(next, key)
+		}
+	case IndexSegment:
+		if !(ty.IsListType() || ty.IsTupleType()) || segment.Index >= value.LengthInt() {
+			return nil, true
+		}
+		next = []cty.Value{value.Index(cty.NumberIntVal(int64(segment.Index)))}
+	case AttributeSegment, KeySegment:
+		// Terraform accepts both tags.env and tags["env"] on maps and objects.
+		switch {
+		case ty.IsObjectType() && ty.HasAttribute(segment.Name):
+			next = []cty.Value{value.GetAttr(segment.Name)}
+		case ty.IsMapType() && value.HasIndex(cty.StringVal(segment.Name)).True():
+			next = []cty.Value{value.Index(cty.StringVal(segment.Name))}
+		default:
+			return nil, true
+		}
+	default:
+		return nil, true
+	}
+
+	for _, v := range next {
+		found, m := resolve(v, rest)
+		values = append(values, found...)
+		missing = missing || m
+	}
+	return values, missing
+}
+
+// elements returns the elements of a collection or the values of a map or
+// object.
+func elements(value cty.Value) []cty.Value {
+	if !value.CanIterateElements() {
+		return nil
+	}
+	var elems []cty.Value
+	for it := value.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		elems = append(elems, v)
+	}
+	return elems
+}
+
+// check applies a predicate to a known, non-null value. As in JSON Schema, a
+// predicate on a value of another type, such as a length on a number, holds.
+func check(p Predicate, v cty.Value) (bool, error) {
+	if !v.IsWhollyKnown() {
+		return true, nil
+	}
+	ty := v.Type()
+
+	switch p := p.(type) {
+	case Length:
+		var length int
+		switch {
+		case ty == cty.String:
+			count, err := stdlib.Strlen(v)
+			if err != nil {
+				return false, err
+			}
+			n, _ := count.AsBigFloat().Int64()
+			length = int(n)
+		case ty.IsCollectionType() || ty.IsTupleType() || ty.IsObjectType():
+			length = v.LengthInt()
+		default:
+			return true, nil
+		}
+		return (p.Min == nil || length >= *p.Min) && (p.Max == nil || length <= *p.Max), nil
+
+	case Range:
+		if ty != cty.Number {
+			return true, nil
+		}
+		n, _ := v.AsBigFloat().Float64()
+		return (p.Minimum == nil || n >= *p.Minimum) &&
+			(p.Maximum == nil || n <= *p.Maximum) &&
+			(p.ExclusiveMinimum == nil || n > *p.ExclusiveMinimum) &&
+			(p.ExclusiveMaximum == nil || n < *p.ExclusiveMaximum), nil
+
+	case Pattern:
+		if ty != cty.String {
+			return true, nil
+		}
+		re, err := regexp.Compile(p.Regexp())
+		if err != nil {
+			return false, fmt.Errorf("cannot evaluate pattern %q: %w", p.Regexp(), err)
+		}
+		return re.MatchString(v.AsString()), nil
+
+	case Format:
+		if ty != cty.String {
+			return true, nil
+		}
+		return checkFormat(p.Format, v.AsString())
+
+	case Enum:
+		for _, allowed := range p.Values {
+			if equalsGo(v, allowed) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case Integer:
+		switch ty {
+		case cty.Number:
+			return v.AsBigFloat().IsInt(), nil
+		case cty.String:
+			return integerStringPattern.MatchString(v.AsString()), nil
+		}
+		return true, nil
+
+	case MultipleOf:
+		if ty != cty.Number || p.Value == 0 {
+			return true, nil
+		}
+		quotient := new(big.Float).Quo(v.AsBigFloat(), big.NewFloat(p.Value))
+		return quotient.IsInt(), nil
+
+	case UniqueItems:
+		if !ty.IsListType() && !ty.IsTupleType() {
+			return true, nil
+		}
+		elems := elements(v)
+		for i := range elems {
+			for j := i + 1; j < len(elems); j++ {
+				if elems[i].Equals(elems[j]).True() {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+
+	case Required:
+		for _, property := range p.Properties {
+			if values, missing := resolve(v, Path{Attr(property)}); missing || len(values) == 0 {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case Contains:
+		for _, elem := range elements(v) {
+			ok, err := evaluate(p.Element, elem, false)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+
+	case Satisfies:
+		return evaluate(p.Constraint, v, true)
+	}
+	return false, fmt.Errorf("unsupported predicate %T", p)
+}
+
+func checkFormat(format, s string) (bool, error) {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil, nil
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && !strings.Contains(s, ":"), nil
+	case "ipv6":
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":"), nil
+	}
+	return false, fmt.Errorf("cannot evaluate format %q", format)
+}
+
+// equalsGo compares a cty value with a string, float64 or bool.
+func equalsGo(v cty.Value, goValue interface{}) bool {
+	switch want := goValue.(type) {
+	case string:
+		return v.Type() == cty.String && v.AsString() == want
+	case float64:
+		return v.Type() == cty.Number && v.AsBigFloat().Cmp(big.NewFloat(want)) == 0
+	case int:
+		return v.Type() == cty.Number && v.AsBigFloat().Cmp(big.NewFloat(float64(want))) == 0
+	case bool:
+		return v.Type() == cty.Bool && v.True() == want
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package constraint
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SegmentKind identifies what a path segment addresses.
+type SegmentKind int
+
+const (
+	// AttributeSegment addresses an attribute of an object, e.g. .name.
+	AttributeSegment SegmentKind = iota
+	// IndexSegment addresses one element of a list or tuple, e.g. [0].
+	IndexSegment
+	// KeySegment addresses one entry of a map, e.g. ["env"].
+	KeySegment
+	// WildcardSegment addresses every element of a collection or every value
+	// of a map, e.g. [*].
+	WildcardSegment
+	// KeysSegment addresses every key of a map, as in keys(x).
+	KeysSegment
+)
+
+// Segment is one step of a Path. Name holds the attribute name or map key
+// and Index the element index, depending on Kind.
+type Segment struct {
+	Kind  SegmentKind
+	Name  string
+	Index int
+}
+
+// Attr returns a segment addressing the attribute name.
+func Attr(name string) Segment {
+	return Segment{Kind: AttributeSegment, Name: name}
+}
+
+// Index returns a segment addressing the element at index i.
+func Index(i int) Segment {
+	return Segment{Kind: IndexSegment, Index: i}
+}
+
+// Key returns a segment addressing the map entry with the given key.
+func Key(key string) Segment {
+	return Segment{Kind: KeySegment, Name: key}
+}
+
+// Wildcard returns a segment addressing every element or map value.
+func Wildcard() Segment {
+	return Segment{Kind: WildcardSegment}
+}
+
+// Keys returns a segment addressing every key of a map.
+func Keys() Segment {
+	return Segment{Kind: KeysSegment}
+}
+
+// Path addresses values within an input, as a list of segments from its root.
+type Path []Segment
+
+// Append returns a new path made of p followed by segments, leaving p
+// unchanged.
+func (p Path) Append(segments ...Segment) Path {
+	path := make(Path, 0, len(p)+len(segments))
+	return append(append(path, p...), segments...)
+}
+
+// Equal reports whether p and other address the same values.
+func (p Path) Equal(other Path) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CommonPrefix returns the longest path all of the given paths start with.
+func CommonPrefix(paths ...Path) Path {
+	if len(paths) == 0 {
+		return nil
+	}
+	prefix := paths[0]
+	for _, path := range paths[1:] {
+		n := 0
+		for n < len(prefix) && n < len(path) && prefix[n] == path[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return Path{}.Append(prefix...)
+}
+
+// String renders the path in Terraform's reference syntax, e.g.
+// subnets[*].cidr, tags["env"] or keys(tags)[*].
+func (p Path) String() string {
+	var b strings.Builder
+	for _, segment := range p {
+		switch segment.Kind {
+		case AttributeSegment:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(segment.Name)
+		case IndexSegment:
+			b.WriteString("[" + strconv.Itoa(segment.Index) + "]")
+		case KeySegment:
+			b.WriteString("[" + strconv.Quote(segment.Name) + "]")
+		case WildcardSegment:
+			b.WriteString("[*]")
+		case KeysSegment:
+			return "keys(" + b.String() + ")[*]"
+		}
+	}
+	return b.String()
+}
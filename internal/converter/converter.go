@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/alex-tw-lam/tfschema/internal/converter/types"
 	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/validation"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // Converter converts Terraform variable definitions to JSON Schema
@@ -19,15 +22,45 @@ type Converter struct {
 	validationProcessor   *ValidationProcessor
 	typeInferenceHandler  *TypeInferenceHandler
 	typeConverterRegistry *types.TypeConverterRegistry
+	draft                 jsonschema.Draft
+	variables             []Variable
+}
+
+// Variable is a converted variable block.
+type Variable struct {
+	Name string
+	// Type is the HCL of the variable's type constraint, or "any" when it
+	// has none.
+	Type string
+	// Schema is the variable's schema, as found in the root schema's
+	// properties.
+	Schema *jsonschema.Schema
+	// Required is set when the variable has no default.
+	Required bool
+}
+
+// Option configures a Converter.
+type Option func(*Converter)
+
+// WithDraft selects the JSON Schema draft of the generated schemas. The
+// default is draft-07.
+func WithDraft(draft jsonschema.Draft) Option {
+	return func(c *Converter) {
+		c.draft = draft
+	}
 }
 
 // New creates a new Converter instance
-func New() *Converter {
+func New(opts ...Option) *Converter {
 	defaultParser := NewDefaultParser()
 	c := &Converter{
 		parser:              hclparse.NewParser(),
 		defaultParser:       defaultParser,
 		validationProcessor: NewValidationProcessor(),
+		draft:               jsonschema.Draft07,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 	c.typeInferenceHandler = NewTypeInferenceHandler(c.defaultParser, c)
 
@@ -107,6 +140,7 @@ func (c *Converter) convertBody(body hcl.Body) (*jsonschema.Schema, error) {
 	content, diags := body.Content(&hcl.BodySchema{
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "locals"},
 		},
 	})
 	if diags.HasErrors() {
@@ -121,13 +155,51 @@ func (c *Converter) convertBody(body hcl.Body) (*jsonschema.Schema, error) {
 		AdditionalProperties: &[]bool{true}[0], // Follow terraschema's permissive approach
 	}
 
+	c.variables = nil
+	var declared []string
+	for _, block := range content.Blocks {
+		if block.Type == "variable" {
+			declared = append(declared, block.Labels[0])
+		}
+	}
+	c.validationProcessor.Reset(declared, constantLocals(content.Blocks))
+
 	if err := c.processVariableBlocks(content.Blocks, rootSchema); err != nil {
 		return nil, err
 	}
 
+	// Cross-variable validations can only be placed once all variables exist
+	if err := c.validationProcessor.ApplyRootRules(rootSchema); err != nil {
+		return nil, err
+	}
+
+	jsonschema.Adapt(rootSchema, c.draft)
 	return rootSchema, nil
 }
 
+// constantLocals returns the local values whose expressions are literal
+// strings, numbers or booleans, such as the patterns conditions share.
+func constantLocals(blocks hcl.Blocks) map[string]cty.Value {
+	locals := make(map[string]cty.Value)
+	for _, block := range blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			continue
+		}
+		for name, attr := range attrs {
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() || !val.Type().IsPrimitiveType() {
+				continue
+			}
+			locals[name] = val
+		}
+	}
+	return locals
+}
+
 // processVariableBlocks processes all variable blocks and adds them to the root schema.
 func (c *Converter) processVariableBlocks(blocks hcl.Blocks, rootSchema *jsonschema.Schema) error {
 	for _, block := range blocks {
@@ -152,9 +224,15 @@ func (c *Converter) processVariableBlocks(blocks hcl.Blocks, rootSchema *jsonsch
 			rootSchema.Properties[varName] = schema
 
 			// Add to required array only if variable has no default value
-			if !c.hasDefaultValue(content) {
+			required := !c.hasDefaultValue(content)
+			if required {
 				*rootSchema.Required = append(*rootSchema.Required, varName)
 			}
+			typeExpr := "any"
+			if attr, ok := content.Attributes["type"]; ok {
+				typeExpr = c.sourceOf(attr.Expr.Range())
+			}
+			c.variables = append(c.variables, Variable{Name: varName, Type: typeExpr, Schema: schema, Required: required})
 		}
 	}
 
@@ -164,6 +242,44 @@ func (c *Converter) processVariableBlocks(blocks hcl.Blocks, rootSchema *jsonsch
 	return nil
 }
 
+// Variables returns the variables of the last conversion in declaration
+// order, for output formats other than JSON Schema.
+func (c *Converter) Variables() []Variable {
+	return append([]Variable{}, c.variables...)
+}
+
+// Untranslatable returns the validation conditions of the last conversion that
+// could not be expressed in JSON Schema.
+func (c *Converter) Untranslatable() []validation.Untranslatable {
+	skipped := c.validationProcessor.Untranslatable()
+	for i := range skipped {
+		skipped[i].Source = c.sourceOf(skipped[i].Condition.Range())
+	}
+	return skipped
+}
+
+// Validations returns the translated validation conditions of the last
+// conversion, lowered into the constraint IR for output formats other than
+// JSON Schema.
+func (c *Converter) Validations() []constraint.Validation {
+	validations := c.validationProcessor.Validations()
+	for i := range validations {
+		if validations[i].Condition != nil {
+			validations[i].Source = c.sourceOf(validations[i].Condition.Range())
+		}
+	}
+	return validations
+}
+
+// sourceOf returns the original text covered by a range of a parsed file.
+func (c *Converter) sourceOf(rng hcl.Range) string {
+	file, ok := c.parser.Files()[rng.Filename]
+	if !ok {
+		return ""
+	}
+	return string(rng.SliceBytes(file.Bytes))
+}
+
 // hasDefaultValue checks if a variable block has a default value
 func (c *Converter) hasDefaultValue(content *hcl.BodyContent) bool {
 	_, exists := content.Attributes["default"]
@@ -263,6 +379,12 @@ func (c *Converter) IsOptionalType(expr hcl.Expression) bool {
 	return false
 }
 
+// ParseDefaultValue evaluates a default value, such as the second argument
+// of optional(), into a native Go value.
+func (c *Converter) ParseDefaultValue(expr hcl.Expression) (interface{}, error) {
+	return c.defaultParser.ParseDefaultValue(expr)
+}
+
 func (c *Converter) parseDefault(attrs map[string]*hcl.Attribute) (interface{}, bool) {
 	if attr, exists := attrs["default"]; exists {
 		val, err := c.defaultParser.ParseDefaultValue(attr.Expr)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -260,3 +261,225 @@ variable "test_object_validated" {
 
 	assertSchemasEqual(t, expectedSchema, schema)
 }
+
+func TestConvertStringReportsUntranslatableCrossVariableCondition(t *testing.T) {
+	input := `
+variable "min_size" {
+  type = number
+}
+
+variable "max_size" {
+  type = number
+  validation {
+    condition     = var.max_size >= var.min_size
+    error_message = "max_size must not be smaller than min_size."
+  }
+}`
+
+	converter := New()
+	schema, err := converter.ConvertString(input)
+	require.NoError(t, err)
+	assert.Nil(t, schema.Properties["max_size"].Minimum)
+
+	skipped := converter.Untranslatable()
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "max_size", skipped[0].Variable)
+	assert.Equal(t, "var.max_size >= var.min_size", skipped[0].Source)
+	assert.Equal(t, "max_size must not be smaller than min_size.", skipped[0].ErrorMessage)
+}
+
+func TestConvertStringReportsUnsupportedCrossVariableCondition(t *testing.T) {
+	input := `
+variable "enabled" {
+  type = bool
+}
+
+variable "items" {
+  type = any
+  validation {
+    condition     = var.enabled ? length(var.items) != 3 : true
+    error_message = "Three items are not allowed when enabled."
+  }
+}`
+
+	converter := New()
+	schema, err := converter.ConvertString(input)
+	require.NoError(t, err)
+	assert.Nil(t, schema.If)
+	assert.Empty(t, converter.Validations())
+
+	skipped := converter.Untranslatable()
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "items", skipped[0].Variable)
+	assert.Contains(t, skipped[0].Reason, "JSON Schema cannot negate")
+}
+
+func TestConvertStringWithDraft(t *testing.T) {
+	input := `
+variable "ports" {
+  type = list(number)
+  validation {
+    condition     = anytrue([for p in var.ports : p == 443])
+    error_message = "Port 443 must be open."
+  }
+}
+
+variable "pair" {
+  type = tuple([string, number])
+}`
+
+	schema, err := New().ConvertString(input)
+	require.NoError(t, err)
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", schema.Schema)
+	assert.NotNil(t, schema.Properties["ports"].Contains)
+	assert.Nil(t, schema.Properties["ports"].MinContains)
+
+	schema, err = New(WithDraft(jsonschema.Draft201909)).ConvertString(input)
+	require.NoError(t, err)
+	assert.Equal(t, "https://json-schema.org/draft/2019-09/schema", schema.Schema)
+	assert.Equal(t, 1, *schema.Properties["ports"].MinContains)
+	assert.IsType(t, []*jsonschema.Schema{}, schema.Properties["pair"].Items)
+
+	schema, err = New(WithDraft(jsonschema.Draft202012)).ConvertString(input)
+	require.NoError(t, err)
+	assert.Nil(t, schema.Properties["pair"].Items)
+	assert.Len(t, schema.Properties["pair"].PrefixItems, 2)
+}
+
+func TestConvertStringReportsUnflattenedNestedFor(t *testing.T) {
+	input := `
+variable "rules" {
+  type = list(object({
+    ports = list(number)
+  }))
+  validation {
+    condition     = alltrue([for r in var.rules : [for p in r.ports : p > 0]])
+    error_message = "Every port must be positive."
+  }
+}`
+
+	converter := New()
+	_, err := converter.ConvertString(input)
+	require.NoError(t, err)
+
+	skipped := converter.Untranslatable()
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "nested for expressions must be flattened to be checked with alltrue", skipped[0].Reason)
+}
+
+func TestConvertStringReportsNestedForReferencingOuterElement(t *testing.T) {
+	input := `
+variable "rules" {
+  type = list(object({
+    max   = number
+    ports = list(number)
+  }))
+  validation {
+    condition     = alltrue(flatten([for r in var.rules : [for p in r.ports : p < r.max]]))
+    error_message = "Every port must be below the maximum of its rule."
+  }
+}`
+
+	converter := New()
+	_, err := converter.ConvertString(input)
+	require.NoError(t, err)
+	assert.Empty(t, converter.Validations())
+
+	skipped := converter.Untranslatable()
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "JSON Schema cannot relate the elements of a nested collection to the outer element", skipped[0].Reason)
+}
+
+func TestConvertStringReportsListIndexCondition(t *testing.T) {
+	input := `
+variable "items" {
+  type = list(string)
+  validation {
+    condition     = alltrue([for i, v in var.items : i < 5])
+    error_message = "At most five items are allowed."
+  }
+}`
+
+	converter := New()
+	schema, err := converter.ConvertString(input)
+	require.NoError(t, err)
+	assert.Nil(t, schema.Properties["items"].PropertyNames)
+	assert.Empty(t, converter.Validations())
+
+	skipped := converter.Untranslatable()
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "JSON Schema cannot constrain the indices of a list", skipped[0].Reason)
+}
+
+func TestConvertStringRecordsValidations(t *testing.T) {
+	input := `
+variable "name" {
+  type = string
+  validation {
+    condition     = length(var.name) <= 20
+    error_message = "The name is too long."
+  }
+}
+
+variable "cert_arn" {
+  type    = string
+  default = null
+}
+
+variable "enable_tls" {
+  type = bool
+  validation {
+    condition     = var.enable_tls ? var.cert_arn != null : true
+    error_message = "TLS needs a certificate."
+  }
+}`
+
+	converter := New()
+	_, err := converter.ConvertString(input)
+	require.NoError(t, err)
+
+	validations := converter.Validations()
+	require.Len(t, validations, 2)
+
+	assert.Equal(t, "name", validations[0].Variable)
+	assert.Equal(t, "The name is too long.", validations[0].ErrorMessage)
+	assert.Equal(t, "length(var.name) <= 20", validations[0].Source)
+	assert.Equal(t, "length of `name` is at most 20", constraint.Describe(validations[0].Constraint))
+
+	assert.Equal(t, "enable_tls", validations[1].Variable)
+	assert.Equal(t, "if `enable_tls` is true, then `cert_arn` is set", constraint.Describe(validations[1].Constraint))
+}
+
+func TestConvertStringRecordsVariables(t *testing.T) {
+	input := `
+variable "zone" {
+  type = string
+}
+
+variable "count" {
+  type    = number
+  default = 1
+}
+
+variable "app" {
+  type = list(object({
+    name = string
+  }))
+}
+
+variable "extra" {}`
+
+	converter := New()
+	schema, err := converter.ConvertString(input)
+	require.NoError(t, err)
+
+	variables := converter.Variables()
+	require.Len(t, variables, 4)
+	assert.Equal(t, []string{"zone", "count", "app", "extra"}, []string{variables[0].Name, variables[1].Name, variables[2].Name, variables[3].Name})
+	assert.True(t, variables[0].Required)
+	assert.False(t, variables[1].Required)
+	assert.Same(t, schema.Properties["count"], variables[1].Schema)
+	assert.Equal(t, "number", variables[1].Type)
+	assert.Equal(t, "list(object({\n    name = string\n  }))", variables[2].Type)
+	assert.Equal(t, "any", variables[3].Type)
+}
@@ -37,12 +37,15 @@ func (o *OptionalTypeConverter) Convert(expr hcl.Expression) (*jsonschema.Schema
 		return nil, fmt.Errorf("failed to convert optional base type: %w", err)
 	}
 
-	// For optional types, we don't include default values in the JSON Schema
-	// The default is handled at the Terraform level, not the JSON Schema level
-	// This keeps the schema clean and focuses on structure rather than values
-
-	// For JSON Schema, optional types are just the base type
-	// The "optional" nature is handled by not including the field in the required array
-	// This is managed at the object level, not the individual property level
+	// The default, which Terraform substitutes for an absent or null
+	// attribute, is kept as an annotation. The "optional" nature itself is
+	// handled by not including the field in the required array of the object.
+	if len(funcExpr.Args) == 2 {
+		def, err := o.mainConverter.ParseDefaultValue(funcExpr.Args[1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse optional default: %w", err)
+		}
+		baseSchema.Default = def
+	}
 	return baseSchema, nil
 }
@@ -15,6 +15,7 @@ type TypeConverter interface {
 type TypeConverterWithIsOptional interface {
 	ConvertType(expr hcl.Expression) (*jsonschema.Schema, error)
 	IsOptionalType(expr hcl.Expression) bool
+	ParseDefaultValue(expr hcl.Expression) (interface{}, error)
 }
 
 // TypeConverterRegistry holds a map of type converters.
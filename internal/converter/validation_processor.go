@@ -1,137 +1,128 @@
 package converter
 
 import (
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
 	"github.com/alex-tw-lam/tfschema/internal/validation"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // ValidationProcessor handles the extraction and application of validation rules.
-type ValidationProcessor struct{}
+type ValidationProcessor struct {
+	declared       []string
+	locals         map[string]cty.Value
+	rootRules      []rootRule
+	untranslatable []validation.Untranslatable
+	validations    []constraint.Validation
+}
+
+// rootRule is a deferred cross-variable rule and the variable declaring it.
+type rootRule struct {
+	variable string
+	rule     validation.ScopedRule
+}
 
 // NewValidationProcessor creates a new ValidationProcessor.
 func NewValidationProcessor() *ValidationProcessor {
 	return &ValidationProcessor{}
 }
 
-// Process extracts and applies validation rules from the variable's blocks to the schema.
+// Reset clears the state of a previous conversion and records the names of
+// the variables declared in the module about to be processed, and the values
+// of its constant local values.
+func (p *ValidationProcessor) Reset(declared []string, locals map[string]cty.Value) {
+	p.declared = declared
+	p.locals = locals
+	p.rootRules = nil
+	p.untranslatable = nil
+	p.validations = nil
+}
+
+// Process extracts the validation rules from the variable's blocks and adds
+// their constraints to the schema. Rules spanning several variables are
+// deferred until ApplyRootRules is called.
 func (p *ValidationProcessor) Process(schema *jsonschema.Schema, blocks hcl.Blocks, varName string) error {
-	rules, err := validation.ExtractValidationRules(blocks, varName)
+	rules, untranslatable, err := validation.ExtractValidationRules(blocks, varName, p.declared, p.locals)
 	if err != nil {
 		return fmt.Errorf("failed to extract validation rules: %w", err)
 	}
+	p.untranslatable = append(p.untranslatable, untranslatable...)
 
 	for _, scopedRule := range rules {
-		targetSchema, err := p.findTargetSchema(varName, schema, scopedRule.Path)
-		if err != nil {
-			return fmt.Errorf("failed to find target schema for validation: %w", err)
+		if scopedRule.Root {
+			p.rootRules = append(p.rootRules, rootRule{variable: varName, rule: scopedRule})
+			continue
 		}
-
-		if err := scopedRule.Rule.Apply(targetSchema); err != nil {
-			return fmt.Errorf("failed to apply validation rule: %w", err)
+		if err := p.apply(schema, varName, scopedRule); err != nil {
+			return fmt.Errorf("failed to apply validation rule to '%s': %w", varName, err)
 		}
 	}
 	return nil
 }
 
-// findTargetSchema navigates the schema to find the target for a validation rule.
-func (p *ValidationProcessor) findTargetSchema(
-	varName string,
-	schema *jsonschema.Schema,
-	path []string,
-) (*jsonschema.Schema, error) {
-	currentSchema := schema
-	if len(path) > 0 && path[0] == varName {
-		path = path[1:] // Skip the root variable name
-	}
-
-	for _, segment := range path {
-		if currentSchema == nil {
-			return nil, fmt.Errorf("cannot apply validation to a nil schema")
-		}
-
-		// The "*" segment is a wildcard.
-		baseType := getBaseType(currentSchema.Type)
-		if segment == "*" {
-			switch baseType {
-			case "object":
-				if ap, ok := currentSchema.AdditionalProperties.(*jsonschema.Schema); ok {
-					currentSchema = ap
-					continue
-				}
-				return nil, fmt.Errorf("cannot apply wildcard validation to object without schema for additional properties in '%s'", varName)
-			case "array", "set":
-				if currentSchema.Items != nil {
-					if itemsSchema, ok := currentSchema.Items.(*jsonschema.Schema); ok {
-						currentSchema = itemsSchema
-						continue
-					}
-					// For arrays of schemas (tuples), we can't apply wildcard validation
-					return nil, fmt.Errorf("cannot apply wildcard validation to tuple types in '%s'", varName)
-				}
-				return nil, fmt.Errorf("cannot apply wildcard validation to array with no item schema in '%s'", varName)
-			default:
-				return nil, fmt.Errorf("wildcard validation can only be applied to object or array/set types, not '%s' in '%s'", baseType, varName)
-			}
+// ApplyRootRules adds the constraints of the deferred cross-variable rules to
+// the root schema, once every variable has been converted.
+func (p *ValidationProcessor) ApplyRootRules(rootSchema *jsonschema.Schema) error {
+	for _, root := range p.rootRules {
+		if err := p.apply(rootSchema, root.variable, root.rule); err != nil {
+			return fmt.Errorf("failed to apply cross-variable validation rule: %w", err)
 		}
+	}
+	return nil
+}
 
-		// Handle array/tuple indexing
-		if strings.HasPrefix(segment, "[") && strings.HasSuffix(segment, "]") {
-			indexStr := strings.Trim(segment, "[]")
-			index, err := strconv.Atoi(indexStr)
-			if err != nil {
-				return nil, fmt.Errorf("invalid index in path: %s", segment)
-			}
-
-			if currentSchema.PrefixItems != nil && index < len(currentSchema.PrefixItems) {
-				currentSchema = currentSchema.PrefixItems[index]
-				continue
-			}
-
-			if currentSchema.Items != nil {
-				if itemsSchema, ok := currentSchema.Items.(*jsonschema.Schema); ok {
-					currentSchema = itemsSchema
-					continue
-				}
-				// For tuples with array of schemas, use the indexed schema if available
-				if itemsArray, ok := currentSchema.Items.([]*jsonschema.Schema); ok && index < len(itemsArray) {
-					currentSchema = itemsArray[index]
-					continue
-				}
-			}
+// apply adds the constraint of a rule of the given variable to schema. The
+// constraint is added to a copy, so that one JSON Schema cannot express leaves
+// no partial keywords behind; such a rule is reported as untranslatable.
+func (p *ValidationProcessor) apply(schema *jsonschema.Schema, varName string, scopedRule validation.ScopedRule) error {
+	c, _ := scopedRule.Constraint()
+	constrained := schema.Clone()
+	err := jsonschema.Constrain(constrained, c)
+	var unsupported *jsonschema.UnsupportedError
+	if errors.As(err, &unsupported) {
+		p.untranslatable = append(p.untranslatable, validation.Untranslatable{
+			Variable:     varName,
+			Condition:    scopedRule.Condition,
+			ErrorMessage: scopedRule.ErrorMessage,
+			Reason:       unsupported.Reason,
+		})
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	*schema = *constrained
+	p.recordValidation(varName, scopedRule, c)
+	return nil
+}
 
-			return nil, fmt.Errorf("cannot apply indexed validation to a schema without Items or PrefixItems")
-		}
+// Untranslatable returns the conditions that could not be expressed in JSON
+// Schema since the last Reset.
+func (p *ValidationProcessor) Untranslatable() []validation.Untranslatable {
+	return append([]validation.Untranslatable{}, p.untranslatable...)
+}
 
-		// Handle object property access
-		if baseType == "object" && currentSchema.Properties != nil {
-			if propSchema, ok := currentSchema.Properties[segment]; ok {
-				currentSchema = propSchema
-			} else {
-				return nil, fmt.Errorf("property '%s' not found in schema for '%s'", segment, varName)
-			}
-		} else if baseType != "object" {
-			return nil, fmt.Errorf("cannot apply validation to path segment '%s' on non-object type in '%s'", segment, varName)
-		}
-	}
-	return currentSchema, nil
+// Validations returns the translated conditions since the last Reset, lowered
+// into the constraint IR.
+func (p *ValidationProcessor) Validations() []constraint.Validation {
+	return append([]constraint.Validation{}, p.validations...)
 }
 
-// getBaseType extracts the primary non-null type from a schema's Type field.
-func getBaseType(t interface{}) string {
-	if typeStr, ok := t.(string); ok {
-		return typeStr
-	}
-	if typeSlice, ok := t.([]interface{}); ok {
-		for _, v := range typeSlice {
-			if typeStr, ok := v.(string); ok && typeStr != "null" {
-				return typeStr
-			}
-		}
+// recordValidation records the constraint of a rule of the given variable,
+// rooting its paths at the variable unless it already spans several
+// variables.
+func (p *ValidationProcessor) recordValidation(varName string, scopedRule validation.ScopedRule, c constraint.Constraint) {
+	if !scopedRule.Root {
+		c = constraint.Prefix(c, constraint.Path{constraint.Attr(varName)})
 	}
-	return ""
+	p.validations = append(p.validations, constraint.Validation{
+		Variable:     varName,
+		Condition:    scopedRule.Condition,
+		ErrorMessage: scopedRule.ErrorMessage,
+		Constraint:   c,
+	})
 }
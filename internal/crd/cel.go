@@ -0,0 +1,444 @@
+package crd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+)
+
+// Regexes of the string formats, for the formats checked in CEL rules.
+const (
+	dateTimePattern = `^[0-9]{4}-[0-9]{2}-[0-9]{2}[Tt][0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?([Zz]|[+-][0-9]{2}:[0-9]{2})$`
+	ipv4Pattern     = `^((25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)$`
+	ipv6Pattern     = `^(([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4})$`
+	integerPattern  = `^[+-]?[0-9]+$`
+)
+
+// celReserved are the words CEL reserves, which field names are escaped from.
+var celReserved = map[string]bool{
+	"true": true, "false": true, "null": true, "in": true, "as": true, "break": true,
+	"const": true, "continue": true, "else": true, "for": true, "function": true, "if": true,
+	"import": true, "let": true, "loop": true, "package": true, "namespace": true,
+	"return": true, "var": true, "void": true, "while": true,
+}
+
+// celGen renders constraints as CEL expressions over values of the
+// structural schema.
+type celGen struct {
+	depth int // nesting of macros, for the names of their variables
+}
+
+// condition renders a constraint as a CEL boolean expression over e, a value
+// of the schema node. As in constraint.Evaluate, vacuous is the result of an
+// atom whose path leads to an absent or null value.
+func (g *celGen) condition(c constraint.Constraint, e string, node *jsonschema.Schema, vacuous bool) (string, error) {
+	switch n := c.(type) {
+	case constraint.Atom:
+		return g.atom(n.Path, n.Predicate, e, node, vacuous)
+	case constraint.And, constraint.Or:
+		terms, sep := []constraint.Constraint(nil), " && "
+		if and, ok := n.(constraint.And); ok {
+			terms = and.Terms
+		} else {
+			terms, sep = n.(constraint.Or).Terms, " || "
+		}
+		// Terms that always hold drop out of an And and decide an Or.
+		var parts []string
+		for _, term := range terms {
+			part, err := g.condition(term, e, node, vacuous)
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case part == "true" && sep == " || ":
+				return "true", nil
+			case part != "true":
+				parts = append(parts, part)
+			}
+		}
+		switch len(parts) {
+		case 0:
+			return "true", nil
+		case 1:
+			return parts[0], nil
+		}
+		for i := range parts {
+			parts[i] = paren(parts[i])
+		}
+		return strings.Join(parts, sep), nil
+	case constraint.Not:
+		term, err := g.condition(n.Term, e, node, !vacuous)
+		if err != nil {
+			return "", err
+		}
+		return "!(" + term + ")", nil
+	case constraint.Implies:
+		cond, err := g.condition(n.If, e, node, false)
+		if err != nil {
+			return "", err
+		}
+		then, err := g.optionalCondition(n.Then, e, node, vacuous)
+		if err != nil {
+			return "", err
+		}
+		otherwise, err := g.optionalCondition(n.Else, e, node, vacuous)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case otherwise == "true":
+			return "!" + wrap(cond) + " || " + paren(then), nil
+		case then == "true":
+			return paren(cond) + " || " + paren(otherwise), nil
+		}
+		return fmt.Sprintf("%s ? %s : %s", paren(cond), paren(then), paren(otherwise)), nil
+	case constraint.Never:
+		return "false", nil
+	}
+	return "", fmt.Errorf("unsupported constraint %T", c)
+}
+
+func (g *celGen) optionalCondition(c constraint.Constraint, e string, node *jsonschema.Schema, vacuous bool) (string, error) {
+	if c == nil {
+		return "true", nil
+	}
+	return g.condition(c, e, node, vacuous)
+}
+
+// atom renders the check of a predicate on the values at path from e. Absent
+// and null values along the path are tested first, and wildcards become
+// all() macros.
+func (g *celGen) atom(path constraint.Path, p constraint.Predicate, e string, node *jsonschema.Schema, vacuous bool) (string, error) {
+	body, err := g.step(path, p, e, node, vacuous)
+	if err != nil {
+		return "", err
+	}
+	if node.AcceptsNull() {
+		return guard(e+" != null", e+" == null", body, vacuous), nil
+	}
+	return body, nil
+}
+
+func (g *celGen) step(path constraint.Path, p constraint.Predicate, e string, node *jsonschema.Schema, vacuous bool) (string, error) {
+	if len(path) == 0 {
+		return g.predicate(p, e, node)
+	}
+	segment, rest := path[0], path[1:]
+
+	switch segment.Kind {
+	case constraint.AttributeSegment, constraint.KeySegment:
+		if values := node.MapValues(); values != nil {
+			key := strconv.Quote(segment.Name)
+			inner, err := g.atom(rest, p, e+"["+key+"]", values, vacuous)
+			if err != nil {
+				return "", err
+			}
+			return guard(key+" in "+e, "!("+key+" in "+e+")", inner, vacuous), nil
+		}
+		if prop := node.Properties[segment.Name]; prop != nil {
+			field := e + "." + fieldName(segment.Name)
+			inner, err := g.atom(rest, p, field, prop, vacuous)
+			if err != nil {
+				return "", err
+			}
+			return guard("has("+field+")", "!has("+field+")", inner, vacuous), nil
+		}
+	case constraint.IndexSegment:
+		item := node.ElementSchema()
+		if tuple := node.TupleItems(); segment.Index < len(tuple) {
+			item = tuple[segment.Index]
+		}
+		if item != nil {
+			inner, err := g.atom(rest, p, fmt.Sprintf("%s[%d]", e, segment.Index), item, vacuous)
+			if err != nil {
+				return "", err
+			}
+			return guard(fmt.Sprintf("%s.size() > %d", e, segment.Index), fmt.Sprintf("%s.size() <= %d", e, segment.Index), inner, vacuous), nil
+		}
+	case constraint.WildcardSegment:
+		x := g.enter()
+		defer g.leave()
+		if items := node.ElementSchema(); items != nil {
+			inner, err := g.atom(rest, p, x, items, vacuous)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s.all(%s, %s)", e, x, inner), nil
+		}
+		if values := node.MapValues(); values != nil {
+			inner, err := g.atom(rest, p, e+"["+x+"]", values, vacuous)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s.all(%s, %s)", e, x, inner), nil
+		}
+	case constraint.KeysSegment:
+		if node.MapValues() != nil {
+			x := g.enter()
+			defer g.leave()
+			inner, err := g.atom(rest, p, x, &jsonschema.Schema{Type: "string"}, vacuous)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s.all(%s, %s)", e, x, inner), nil
+		}
+	}
+	return "", fmt.Errorf("cannot address %s on a value of type %s", constraint.Path{segment}, typeName(node))
+}
+
+// enter returns the variable of a new macro.
+func (g *celGen) enter() string {
+	g.depth++
+	if g.depth == 1 {
+		return "x"
+	}
+	return fmt.Sprintf("x%d", g.depth)
+}
+
+func (g *celGen) leave() {
+	g.depth--
+}
+
+// predicate renders a check of p on e. As in JSON Schema, a predicate on a
+// value of another type, such as a pattern on a number, holds.
+func (g *celGen) predicate(p constraint.Predicate, e string, node *jsonschema.Schema) (string, error) {
+	t := node.BaseType()
+	if t == "" || t == "object" && node.MapValues() == nil && len(node.Properties) == 0 {
+		return "", fmt.Errorf("values of type any have no CEL type to check")
+	}
+	tuple := t == "array" && node.TupleItems() != nil
+
+	switch p := p.(type) {
+	case constraint.Length:
+		var checks []string
+		if t == "object" && node.MapValues() == nil || tuple {
+			// The attributes of an object and the elements of a tuple are fixed.
+			n := len(node.Properties) + len(node.TupleItems())
+			if p.Min != nil && n < *p.Min || p.Max != nil && n > *p.Max {
+				return "false", nil
+			}
+			return "true", nil
+		}
+		if t != "string" && t != "array" && t != "object" {
+			return "true", nil
+		}
+		if p.Min != nil && p.Max != nil && *p.Min == *p.Max {
+			return fmt.Sprintf("%s.size() == %d", e, *p.Min), nil
+		}
+		if p.Min != nil {
+			checks = append(checks, fmt.Sprintf("%s.size() >= %d", e, *p.Min))
+		}
+		if p.Max != nil {
+			checks = append(checks, fmt.Sprintf("%s.size() <= %d", e, *p.Max))
+		}
+		return render.JoinChecks(checks), nil
+
+	case constraint.Range:
+		if t != "number" && t != "integer" {
+			return "true", nil
+		}
+		if p.Minimum != nil && p.Maximum != nil && *p.Minimum == *p.Maximum {
+			return fmt.Sprintf("%s == %s", e, number(*p.Minimum, t)), nil
+		}
+		var checks []string
+		for _, bound := range []struct {
+			value *float64
+			op    string
+		}{{p.Minimum, ">="}, {p.Maximum, "<="}, {p.ExclusiveMinimum, ">"}, {p.ExclusiveMaximum, "<"}} {
+			if bound.value != nil {
+				checks = append(checks, fmt.Sprintf("%s %s %s", e, bound.op, number(*bound.value, t)))
+			}
+		}
+		return render.JoinChecks(checks), nil
+
+	case constraint.Pattern:
+		if t != "string" {
+			return "true", nil
+		}
+		return e + ".matches(" + strconv.Quote(p.Regexp()) + ")", nil
+
+	case constraint.Format:
+		if t != "string" {
+			return "true", nil
+		}
+		pattern, ok := map[string]string{"date-time": dateTimePattern, "ipv4": ipv4Pattern, "ipv6": ipv6Pattern}[p.Format]
+		if !ok {
+			return "", fmt.Errorf("format %q is not supported", p.Format)
+		}
+		return e + ".matches(" + strconv.Quote(pattern) + ")", nil
+
+	case constraint.Enum:
+		var values []string
+		for _, allowed := range p.Values {
+			switch a := allowed.(type) {
+			case string:
+				if t == "string" {
+					values = append(values, strconv.Quote(a))
+				}
+			case float64:
+				if t == "number" || t == "integer" && a == float64(int64(a)) {
+					values = append(values, number(a, t))
+				}
+			case bool:
+				if t == "boolean" {
+					values = append(values, strconv.FormatBool(a))
+				}
+			}
+		}
+		switch len(values) {
+		case 0:
+			return "false", nil
+		case 1:
+			return e + " == " + values[0], nil
+		}
+		return e + " in [" + strings.Join(values, ", ") + "]", nil
+
+	case constraint.Integer:
+		switch t {
+		case "number":
+			return e + " == double(int(" + e + "))", nil
+		case "string":
+			return e + ".matches(" + strconv.Quote(integerPattern) + ")", nil
+		}
+		return "true", nil
+
+	case constraint.MultipleOf:
+		if t != "number" && t != "integer" || p.Value == 0 {
+			return "true", nil
+		}
+		if t == "integer" && p.Value == float64(int64(p.Value)) {
+			return fmt.Sprintf("%s %% %s == 0", e, number(p.Value, t)), nil
+		}
+		quotient := fmt.Sprintf("(%s / %s)", e, number(p.Value, "number"))
+		if t == "integer" {
+			quotient = fmt.Sprintf("(double(%s) / %s)", e, number(p.Value, "number"))
+		}
+		return fmt.Sprintf("%s == double(int%s)", quotient, quotient), nil
+
+	case constraint.UniqueItems:
+		if t != "array" || tuple {
+			return "true", nil
+		}
+		x := g.enter()
+		defer g.leave()
+		y := g.enter()
+		defer g.leave()
+		return fmt.Sprintf("%s.all(%s, %s.exists_one(%s, %s == %s))", e, x, e, y, y, x), nil
+
+	case constraint.Required:
+		var checks []string
+		for _, property := range p.Properties {
+			if values := node.MapValues(); values != nil {
+				key := strconv.Quote(property)
+				check := key + " in " + e
+				if values.AcceptsNull() {
+					check += " && " + e + "[" + key + "] != null"
+				}
+				checks = append(checks, check)
+				continue
+			}
+			prop := node.Properties[property]
+			if prop == nil {
+				return "", fmt.Errorf("the object has no attribute %q", property)
+			}
+			field := e + "." + fieldName(property)
+			check := "has(" + field + ")"
+			if prop.AcceptsNull() {
+				check += " && " + field + " != null"
+			}
+			checks = append(checks, check)
+		}
+		return render.JoinChecks(checks), nil
+
+	case constraint.Contains:
+		x := g.enter()
+		defer g.leave()
+		if items := node.ElementSchema(); items != nil && !tuple {
+			element, err := g.condition(p.Element, x, items, false)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s.exists(%s, %s)", e, x, element), nil
+		}
+		if values := node.MapValues(); values != nil {
+			element, err := g.condition(p.Element, e+"["+x+"]", values, false)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s.exists(%s, %s)", e, x, element), nil
+		}
+		return "true", nil
+
+	case constraint.Satisfies:
+		return g.condition(p.Constraint, e, node, true)
+	}
+	return "", fmt.Errorf("unsupported predicate %T", p)
+}
+
+// guard renders body for a value that present tests the presence of. An
+// absent value yields vacuous.
+func guard(present, absent, body string, vacuous bool) string {
+	switch {
+	case vacuous && body == "true":
+		return "true"
+	case vacuous:
+		return absent + " || " + paren(body)
+	case body == "true":
+		return present
+	}
+	return present + " && " + paren(body)
+}
+
+// paren wraps an expression in parentheses unless it is a single term.
+func paren(e string) string {
+	if strings.ContainsAny(e, "|&?") {
+		return "(" + e + ")"
+	}
+	return e
+}
+
+// wrap wraps an expression in parentheses unless it is a call, for the
+// operand of !.
+func wrap(e string) string {
+	if strings.ContainsAny(e, " |&?") {
+		return "(" + e + ")"
+	}
+	return e
+}
+
+// number renders a number literal for a value of schema type t: an int for
+// integers, and otherwise a double, as CEL does not compare int and double
+// in every version.
+func number(v float64, t string) string {
+	s := render.Number(v)
+	if t == "integer" && v == float64(int64(v)) {
+		return s
+	}
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// fieldName escapes an attribute name the way the API server exposes it to
+// CEL rules.
+func fieldName(name string) string {
+	if celReserved[name] {
+		return "__" + name + "__"
+	}
+	name = strings.ReplaceAll(name, "__", "__underscores__")
+	name = strings.ReplaceAll(name, ".", "__dot__")
+	name = strings.ReplaceAll(name, "-", "__dash__")
+	return strings.ReplaceAll(name, "/", "__slash__")
+}
+
+func typeName(node *jsonschema.Schema) string {
+	if t := node.BaseType(); t != "" {
+		return t
+	}
+	return "any"
+}
@@ -0,0 +1,190 @@
+// Package crd generates a Kubernetes CustomResourceDefinition whose spec
+// holds the inputs of a Terraform module, for operators that run modules as
+// custom resources. The schema is structural, as apiextensions.k8s.io/v1
+// requires, and the validations that its keywords cannot express become
+// x-kubernetes-validations CEL rules.
+package crd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/naming"
+	"github.com/alex-tw-lam/tfschema/internal/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the served and stored version of the generated resource.
+const Version = "v1alpha1"
+
+// DefaultGroup is the API group of the generated resource when none is given.
+const DefaultGroup = "modules.example.com"
+
+// Unchecked is a validation with no CEL rule, which the resource therefore
+// does not check.
+type Unchecked struct {
+	Validation constraint.Validation
+	Err        error
+}
+
+func (u Unchecked) String() string {
+	return fmt.Sprintf("variable %q: %q is not checked by the resource: %v", u.Validation.Variable, u.Validation.ErrorMessage, u.Err)
+}
+
+type definition struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   metadata `yaml:"metadata"`
+	Spec       spec     `yaml:"spec"`
+}
+
+type metadata struct {
+	Name string `yaml:"name"`
+}
+
+type spec struct {
+	Group    string    `yaml:"group"`
+	Names    names     `yaml:"names"`
+	Scope    string    `yaml:"scope"`
+	Versions []version `yaml:"versions"`
+}
+
+type names struct {
+	Kind     string `yaml:"kind"`
+	ListKind string `yaml:"listKind"`
+	Plural   string `yaml:"plural"`
+	Singular string `yaml:"singular"`
+}
+
+type version struct {
+	Name         string       `yaml:"name"`
+	Served       bool         `yaml:"served"`
+	Storage      bool         `yaml:"storage"`
+	Schema       schema       `yaml:"schema"`
+	Subresources subresources `yaml:"subresources"`
+}
+
+type schema struct {
+	OpenAPIV3Schema *props `yaml:"openAPIV3Schema"`
+}
+
+type subresources struct {
+	Status struct{} `yaml:"status"`
+}
+
+// Generate renders a CRD in the given API group for a resource named after
+// the module. Its spec has one property per variable and requires the
+// variables without a default; its status is left to the operator. The
+// conditions the converter could not translate are translated from their HCL
+// where CEL has the same operators and functions. The validations that have
+// no CEL rule, because they address values of type any or use HCL that CEL
+// lacks, are returned.
+func Generate(group, module string, variables []converter.Variable, validations []constraint.Validation, untranslatable []validation.Untranslatable) (string, []Unchecked, error) {
+	if group == "" {
+		group = DefaultGroup
+	}
+	kind := naming.Pascal(module, "Module")
+	singular := strings.ToLower(kind)
+	plural := singular + "s"
+
+	var required []string
+	inputs := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{}, Required: &required}
+	specProps := &props{
+		Type:        "object",
+		Description: fmt.Sprintf("Inputs of the %s module.", module),
+		Properties:  map[string]*props{},
+	}
+	for _, v := range variables {
+		inputs.Properties[v.Name] = v.Schema
+		specProps.Properties[v.Name] = structural(v.Schema)
+		if v.Required {
+			specProps.Required = append(specProps.Required, v.Name)
+			required = append(required, v.Name)
+		}
+	}
+
+	var unchecked []Unchecked
+	for _, v := range validations {
+		if expressed(v.Constraint, inputs) {
+			continue
+		}
+		target, self, root := specProps, inputs, v.Constraint
+		if name, ok := variableOf(v.Constraint); ok && specProps.Properties[name] != nil {
+			target, self = specProps.Properties[name], inputs.Properties[name]
+			root = constraint.Trim(v.Constraint, 1)
+		}
+		rule, err := (&celGen{}).condition(root, "self", self, true)
+		if err != nil {
+			unchecked = append(unchecked, Unchecked{Validation: v, Err: err})
+			continue
+		}
+		target.Validations = append(target.Validations, celRule{Rule: rule, Message: v.ErrorMessage})
+	}
+	for _, u := range untranslatable {
+		g := &hclGen{inputs: inputs, guarded: map[string]bool{}}
+		target := specProps
+		if names := referencedVariables(u.Condition); len(names) == 1 && specProps.Properties[names[0]] != nil {
+			g.variable, target = names[0], specProps.Properties[names[0]]
+		}
+		rule, err := g.rule(u.Condition)
+		if err != nil {
+			v := constraint.Validation{Variable: u.Variable, Condition: u.Condition, ErrorMessage: u.ErrorMessage, Source: u.Source}
+			unchecked = append(unchecked, Unchecked{Validation: v, Err: err})
+			continue
+		}
+		target.Validations = append(target.Validations, celRule{Rule: rule, Message: u.ErrorMessage})
+	}
+
+	crd := definition{
+		APIVersion: "apiextensions.k8s.io/v1",
+		Kind:       "CustomResourceDefinition",
+		Metadata:   metadata{Name: plural + "." + group},
+		Spec: spec{
+			Group: group,
+			Names: names{Kind: kind, ListKind: kind + "List", Plural: plural, Singular: singular},
+			Scope: "Namespaced",
+			Versions: []version{{
+				Name:    Version,
+				Served:  true,
+				Storage: true,
+				Schema: schema{OpenAPIV3Schema: &props{
+					Type: "object",
+					Properties: map[string]*props{
+						"spec":   specProps,
+						"status": {Type: "object", PreserveUnknownFields: true},
+					},
+				}},
+			}},
+		},
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(crd); err != nil {
+		return "", nil, fmt.Errorf("failed to encode the CRD: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return "", nil, err
+	}
+	return b.String(), unchecked, nil
+}
+
+// variableOf returns the variable that all the atoms of c address, if there
+// is exactly one.
+func variableOf(c constraint.Constraint) (string, bool) {
+	var name string
+	ok := true
+	constraint.Atoms(c, func(a constraint.Atom) {
+		if len(a.Path) == 0 || a.Path[0].Kind != constraint.AttributeSegment || name != "" && a.Path[0].Name != name {
+			ok = false
+			return
+		}
+		name = a.Path[0].Name
+	})
+	return name, ok && name != ""
+}
@@ -0,0 +1,308 @@
+package crd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const module = `
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]*$", var.name))
+    error_message = "The name must be lower-case."
+  }
+}
+
+variable "replicas" {
+  type    = number
+  default = 0
+
+  validation {
+    condition     = var.replicas != 1
+    error_message = "A single replica is not allowed."
+  }
+}
+
+variable "labels" {
+  type    = map(string)
+  default = {}
+
+  validation {
+    condition     = alltrue([for k in keys(var.labels) : can(regex("^[a-z]+$", k))])
+    error_message = "Label keys must be lower-case letters."
+  }
+}
+
+variable "owner" {
+  type     = string
+  nullable = true
+  default  = null
+
+  validation {
+    condition     = var.owner != "root"
+    error_message = "The owner cannot be root."
+  }
+}
+
+variable "zones" {
+  type = set(string)
+}
+
+variable "peer" {
+  type    = tuple([string, number])
+  default = ["a", 1]
+}
+
+variable "extra" {
+  type    = any
+  default = {}
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    private = optional(bool)
+  })
+
+  validation {
+    condition     = var.network.private ? can(regex("^10\\.", var.network.cidr)) : true
+    error_message = "Private networks must use 10.0.0.0/8."
+  }
+}
+
+variable "tls" {
+  type = object({
+    cert = optional(string)
+  })
+  default = {}
+}
+
+variable "public" {
+  type    = bool
+  default = false
+
+  validation {
+    condition     = var.public ? var.tls.cert != null : true
+    error_message = "Public endpoints need a certificate."
+  }
+}
+`
+
+func generate(t *testing.T) (string, []Unchecked) {
+	t.Helper()
+	c := converter.New()
+	_, err := c.ConvertString(module)
+	require.NoError(t, err)
+
+	manifest, unchecked, err := Generate("", "terraform-aws-vpc", c.Variables(), c.Validations(), c.Untranslatable())
+	require.NoError(t, err)
+	return manifest, unchecked
+}
+
+func TestGenerate(t *testing.T) {
+	manifest, unchecked := generate(t)
+	assert.Empty(t, unchecked)
+
+	for _, want := range []string{
+		"apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: terraformawsvpcs.modules.example.com\n",
+		"    kind: TerraformAwsVpc\n    listKind: TerraformAwsVpcList\n    plural: terraformawsvpcs\n    singular: terraformawsvpc\n",
+		"    - name: v1alpha1\n      served: true\n      storage: true\n",
+		"              required:\n                - name\n                - zones\n                - network\n",
+		// any preserves unknown fields, and keeps its default.
+		"                extra:\n                  default: {}\n                  x-kubernetes-preserve-unknown-fields: true\n",
+		// A map, whose keys are checked by a CEL rule.
+		"                labels:\n                  type: object\n                  default: {}\n" +
+			"                  additionalProperties:\n                    type: string\n" +
+			"                  x-kubernetes-map-type: granular\n" +
+			"                  x-kubernetes-validations:\n" +
+			"                    - rule: self.all(x, x.matches(\"^[a-z]+$\"))\n" +
+			"                      message: Label keys must be lower-case letters.\n",
+		"                owner:\n                  type: string\n                  nullable: true\n" +
+			"                  x-kubernetes-validations:\n" +
+			"                    - rule: '!(self != null && self == \"root\")'\n",
+		// A pattern is a structural keyword and needs no rule.
+		"                name:\n                  type: string\n                  description: Resource name\n                  pattern: ^[a-z][a-z0-9-]*$\n                network:\n",
+		"                    - rule: '!(has(self.private) && self.private == true) || (!has(self.cidr) || self.cidr.matches(\"^10\\\\.\"))'\n" +
+			"                      message: Private networks must use 10.0.0.0/8.\n",
+		"                  minItems: 2\n                  maxItems: 2\n                  items:\n                    x-kubernetes-preserve-unknown-fields: true\n",
+		"                    - rule: type(self[1]) == int || type(self[1]) == double\n                      message: Element 1 must be a number.\n",
+		"                  default: 0\n                  x-kubernetes-validations:\n                    - rule: '!(self == 1.0)'\n",
+		"                zones:\n                  type: array\n                  items:\n                    type: string\n                  x-kubernetes-list-type: set\n",
+		// A rule on two variables is checked on the spec.
+		"              x-kubernetes-validations:\n" +
+			"                - rule: '!(has(self.public) && self.public == true) || (!has(self.tls) || has(self.tls.cert))'\n" +
+			"                  message: Public endpoints need a certificate.\n",
+		"            status:\n              type: object\n              x-kubernetes-preserve-unknown-fields: true\n",
+	} {
+		assert.Contains(t, manifest, want)
+	}
+	assert.NotContains(t, manifest, "anyOf")
+}
+
+// TestGenerateStructural checks the rules of structural schemas on every
+// node of the generated schema.
+func TestGenerateStructural(t *testing.T) {
+	manifest, _ := generate(t)
+	var crd map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &crd))
+	versions := crd["spec"].(map[string]interface{})["versions"].([]interface{})
+	root := versions[0].(map[string]interface{})["schema"].(map[string]interface{})["openAPIV3Schema"]
+
+	var check func(path string, node map[string]interface{})
+	check = func(path string, node map[string]interface{}) {
+		for _, keyword := range []string{"anyOf", "oneOf", "not", "if", "then", "else", "uniqueItems", "contains", "propertyNames"} {
+			assert.NotContains(t, node, keyword, path)
+		}
+		_, typed := node["type"]
+		assert.True(t, typed || node["x-kubernetes-preserve-unknown-fields"] == true, "%s has no type", path)
+		if properties, ok := node["properties"].(map[string]interface{}); ok {
+			for name, prop := range properties {
+				check(path+"."+name, prop.(map[string]interface{}))
+			}
+		}
+		for _, keyword := range []string{"items", "additionalProperties"} {
+			if sub, ok := node[keyword].(map[string]interface{}); ok {
+				check(path+"."+keyword, sub)
+			}
+		}
+	}
+	check("(root)", root.(map[string]interface{}))
+}
+
+func TestGenerateUnchecked(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "settings" {
+  type = any
+
+  validation {
+    condition     = can(regex("^[a-z]+$", var.settings))
+    error_message = "Settings must be a lower-case name."
+  }
+}
+`)
+	require.NoError(t, err)
+
+	_, unchecked, err := Generate("infra.example.org", "app", c.Variables(), c.Validations(), c.Untranslatable())
+	require.NoError(t, err)
+	if assert.Len(t, unchecked, 1) {
+		assert.True(t, strings.HasPrefix(unchecked[0].String(), `variable "settings": "Settings must be a lower-case name." is not checked by the resource`), unchecked[0].String())
+	}
+}
+
+func TestGenerateUntranslatable(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "min_size" {
+  type    = number
+  default = 1
+}
+
+variable "max_size" {
+  type = number
+
+  validation {
+    condition     = var.max_size >= var.min_size
+    error_message = "max_size must not be smaller than min_size."
+  }
+}
+
+variable "banner" {
+  type = string
+
+  validation {
+    condition     = can(regex("(?m)^[A-Z]", var.banner))
+    error_message = "Every line must start with a capital letter."
+  }
+}
+`)
+	require.NoError(t, err)
+	require.Len(t, c.Untranslatable(), 2)
+
+	manifest, unchecked, err := Generate("", "app", c.Variables(), c.Validations(), c.Untranslatable())
+	require.NoError(t, err)
+	assert.Empty(t, unchecked)
+	// A condition on two variables is checked on the spec, and one on a single
+	// variable on its property.
+	assert.Contains(t, manifest, "              x-kubernetes-validations:\n"+
+		"                - rule: self.max_size >= self.min_size\n"+
+		"                  message: max_size must not be smaller than min_size.\n")
+	assert.Contains(t, manifest, "                  x-kubernetes-validations:\n"+
+		"                    - rule: self.matches(\"(?m)^[A-Z]\")\n")
+}
+
+func TestHCLRule(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "size" {
+  type = number
+}
+
+variable "limit" {
+  type    = number
+  default = null
+}
+
+variable "network" {
+  type = object({
+    name  = string
+    cidrs = optional(list(string))
+  })
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "settings" {
+  type    = any
+  default = {}
+}
+`)
+	require.NoError(t, err)
+	var required []string
+	inputs := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{}, Required: &required}
+	for _, v := range c.Variables() {
+		inputs.Properties[v.Name] = v.Schema
+		if v.Required {
+			required = append(required, v.Name)
+		}
+	}
+
+	for _, tc := range []struct {
+		condition, rule, err string
+	}{
+		{condition: `var.size >= var.limit`, rule: `!has(self.limit) || self.size >= self.limit`},
+		{condition: `var.limit == null || var.size <= var.limit * 2`, rule: `!has(self.limit) || (!has(self.limit) || self.size <= self.limit * 2.0)`},
+		{condition: `var.size % 2 == 0`, err: "the remainder of numbers that are not whole is not supported"},
+		{condition: `length(var.network.cidrs) > 0 && var.network.cidrs[0] != "0.0.0.0/0"`, rule: `!has(self.network.cidrs) || self.network.cidrs.size() <= 0 || (self.network.cidrs.size() > 0 && self.network.cidrs[0] != "0.0.0.0/0")`},
+		{condition: `contains(["dev", "prod"], var.tags["env"]) ? var.size > 1 : !startswith(var.network.name, "tmp-")`, rule: `!("env" in self.tags) || (self.tags["env"] in ["dev", "prod"] ? self.size > 1.0 : !self.network.name.startsWith("tmp-"))`},
+		{condition: `var.settings.enabled`, err: "cannot address \"enabled\" on a value of type any"},
+		{condition: `length(setintersection(var.network.cidrs, ["0.0.0.0/0"])) == 0`, err: "function setintersection has no CEL counterpart"},
+		{condition: `var.size`, err: "the condition is not a bool"},
+	} {
+		t.Run(tc.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tc.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+			rule, err := (&hclGen{inputs: inputs, guarded: map[string]bool{}}).rule(expr)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.rule, rule)
+		})
+	}
+}
@@ -0,0 +1,491 @@
+package crd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Precedences of CEL operators, for parenthesizing operands.
+const (
+	precConditional = iota + 1
+	precOr
+	precAnd
+	precCompare
+	precAdd
+	precMultiply
+	precUnary
+	precPrimary
+)
+
+// stringMethods are the CEL methods of the HCL string functions that test a
+// substring.
+var stringMethods = map[string]string{"startswith": "startsWith", "endswith": "endsWith", "strcontains": "contains"}
+
+// celTerm is a translated HCL expression.
+type celTerm struct {
+	text string
+	typ  string   // schema type of the value, or "null" for the null literal
+	num  *float64 // the value of a number literal, rendered once its type is known
+	prec int
+	node *jsonschema.Schema // the schema of a referenced value
+	// present and absent test whether a reference leads to a value.
+	present, absent []string
+}
+
+// hclGen translates the HCL conditions that the converter could not lower
+// into the constraint IR into CEL expressions over the spec. References to
+// variables become fields of self, or self itself for a rule on the one
+// variable the condition references.
+type hclGen struct {
+	inputs   *jsonschema.Schema
+	variable string // the variable self is, or "" for the spec
+	// guards are the tests of the values the condition references, in order.
+	// As for the IR, a condition on absent or null values holds.
+	guards  []string
+	guarded map[string]bool
+}
+
+// rule translates a condition into a CEL rule.
+func (g *hclGen) rule(expr hcl.Expression) (string, error) {
+	e, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return "", fmt.Errorf("the condition is not native HCL syntax")
+	}
+	t, err := g.expr(e)
+	if err != nil {
+		return "", err
+	}
+	if t.typ != "boolean" {
+		return "", fmt.Errorf("the condition is not a bool")
+	}
+	if len(g.guards) == 0 {
+		return t.text, nil
+	}
+	return strings.Join(g.guards, " || ") + " || " + paren(t.text), nil
+}
+
+// referencedVariables returns the variables an expression references, in
+// order of appearance.
+func referencedVariables(expr hcl.Expression) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, trav := range expr.Variables() {
+		if trav.RootName() != "var" || len(trav) < 2 {
+			continue
+		}
+		if attr, ok := trav[1].(hcl.TraverseAttr); ok && !seen[attr.Name] {
+			seen[attr.Name] = true
+			names = append(names, attr.Name)
+		}
+	}
+	return names
+}
+
+func (g *hclGen) expr(expr hclsyntax.Expression) (celTerm, error) {
+	switch e := expr.(type) {
+	case *hclsyntax.ParenthesesExpr:
+		return g.expr(e.Expression)
+	case *hclsyntax.TemplateWrapExpr:
+		return g.expr(e.Wrapped)
+	case *hclsyntax.TemplateExpr:
+		if len(e.Parts) == 1 {
+			if lit, ok := e.Parts[0].(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
+				return g.expr(lit)
+			}
+		}
+		return celTerm{}, fmt.Errorf("string templates are not supported")
+	case *hclsyntax.LiteralValueExpr:
+		return literal(e.Val)
+	case *hclsyntax.ScopeTraversalExpr:
+		return g.reference(e.Traversal)
+	case *hclsyntax.TupleConsExpr:
+		var elems []string
+		for _, elem := range e.Exprs {
+			t, err := g.operand(elem)
+			if err != nil {
+				return celTerm{}, err
+			}
+			elems = append(elems, t.render(""))
+		}
+		return celTerm{text: "[" + strings.Join(elems, ", ") + "]", typ: "array", prec: precPrimary}, nil
+	case *hclsyntax.UnaryOpExpr:
+		t, err := g.operand(e.Val)
+		if err != nil {
+			return celTerm{}, err
+		}
+		if e.Op == hclsyntax.OpLogicalNot {
+			return celTerm{text: "!" + t.wrap(precUnary), typ: "boolean", prec: precUnary}, nil
+		}
+		if t.num != nil {
+			v := -*t.num
+			return celTerm{num: &v, typ: "number", prec: precPrimary}, nil
+		}
+		return celTerm{text: "-" + t.wrap(precUnary), typ: t.typ, prec: precUnary}, nil
+	case *hclsyntax.BinaryOpExpr:
+		return g.binary(e)
+	case *hclsyntax.ConditionalExpr:
+		cond, err := g.operand(e.Condition)
+		if err != nil {
+			return celTerm{}, err
+		}
+		then, err := g.operand(e.TrueResult)
+		if err != nil {
+			return celTerm{}, err
+		}
+		otherwise, err := g.operand(e.FalseResult)
+		if err != nil {
+			return celTerm{}, err
+		}
+		if then.typ != otherwise.typ {
+			return celTerm{}, fmt.Errorf("the results of a conditional have different types")
+		}
+		text := fmt.Sprintf("%s ? %s : %s", cond.wrap(precOr), then.render(then.typ), otherwise.render(otherwise.typ))
+		return celTerm{text: text, typ: then.typ, prec: precConditional}, nil
+	case *hclsyntax.FunctionCallExpr:
+		return g.call(e)
+	}
+	return celTerm{}, fmt.Errorf("%T expressions are not supported", expr)
+}
+
+// operand translates a subexpression whose value is used, so that the values
+// it references must be present.
+func (g *hclGen) operand(expr hclsyntax.Expression) (celTerm, error) {
+	t, err := g.expr(expr)
+	if err != nil {
+		return celTerm{}, err
+	}
+	if t.typ == "null" {
+		return celTerm{}, fmt.Errorf("null is only supported in comparisons")
+	}
+	for _, absent := range t.absent {
+		if !g.guarded[absent] {
+			g.guarded[absent] = true
+			g.guards = append(g.guards, absent)
+		}
+	}
+	return t, nil
+}
+
+func (g *hclGen) binary(e *hclsyntax.BinaryOpExpr) (celTerm, error) {
+	if e.Op == hclsyntax.OpEqual || e.Op == hclsyntax.OpNotEqual {
+		if t, ok, err := g.nullCheck(e); ok || err != nil {
+			return t, err
+		}
+	}
+	lhs, err := g.operand(e.LHS)
+	if err != nil {
+		return celTerm{}, err
+	}
+	rhs, err := g.operand(e.RHS)
+	if err != nil {
+		return celTerm{}, err
+	}
+
+	op, prec, typ := "", 0, "boolean"
+	switch e.Op {
+	case hclsyntax.OpLogicalOr:
+		op, prec = "||", precOr
+	case hclsyntax.OpLogicalAnd:
+		op, prec = "&&", precAnd
+	case hclsyntax.OpEqual:
+		op, prec = "==", precCompare
+	case hclsyntax.OpNotEqual:
+		op, prec = "!=", precCompare
+	case hclsyntax.OpGreaterThan:
+		op, prec = ">", precCompare
+	case hclsyntax.OpGreaterThanOrEqual:
+		op, prec = ">=", precCompare
+	case hclsyntax.OpLessThan:
+		op, prec = "<", precCompare
+	case hclsyntax.OpLessThanOrEqual:
+		op, prec = "<=", precCompare
+	case hclsyntax.OpAdd:
+		op, prec, typ = "+", precAdd, ""
+	case hclsyntax.OpSubtract:
+		op, prec, typ = "-", precAdd, ""
+	case hclsyntax.OpMultiply:
+		op, prec, typ = "*", precMultiply, ""
+	case hclsyntax.OpDivide:
+		op, prec, typ = "/", precMultiply, "number"
+	case hclsyntax.OpModulo:
+		op, prec, typ = "%", precMultiply, "integer"
+	default:
+		return celTerm{}, fmt.Errorf("the operator is not supported")
+	}
+
+	// Numbers are compared and computed as ints only when both operands are,
+	// as CEL does not mix int and double in every version.
+	numeric := isNumber(lhs.typ) || isNumber(rhs.typ)
+	operandType := ""
+	if numeric {
+		operandType = "number"
+		if (lhs.typ == "integer" || lhs.num != nil) && (rhs.typ == "integer" || rhs.num != nil) && typ != "number" {
+			operandType = "integer"
+		}
+		if typ == "" {
+			typ = operandType
+		}
+		if typ == "integer" && operandType != "integer" {
+			return celTerm{}, fmt.Errorf("the remainder of numbers that are not whole is not supported")
+		}
+	}
+	left, right := lhs.render(operandType), rhs.render(operandType)
+	if numeric && operandType == "number" {
+		left, right = lhs.double(), rhs.double()
+	}
+	if lhs.prec < prec {
+		left = "(" + left + ")"
+	}
+	if rhs.prec <= prec {
+		right = "(" + right + ")"
+	}
+	return celTerm{text: left + " " + op + " " + right, typ: typ, prec: prec}, nil
+}
+
+// nullCheck translates a comparison of a reference with null into a test of
+// its presence.
+func (g *hclGen) nullCheck(e *hclsyntax.BinaryOpExpr) (celTerm, bool, error) {
+	lhs, err := g.expr(e.LHS)
+	if err != nil {
+		return celTerm{}, false, err
+	}
+	rhs, err := g.expr(e.RHS)
+	if err != nil {
+		return celTerm{}, false, err
+	}
+	if lhs.typ == "null" {
+		lhs, rhs = rhs, lhs
+	}
+	if rhs.typ != "null" {
+		return celTerm{}, false, nil
+	}
+	if lhs.typ == "null" {
+		return celTerm{text: strconv.FormatBool(e.Op == hclsyntax.OpEqual), typ: "boolean", prec: precPrimary}, true, nil
+	}
+	if e.Op == hclsyntax.OpNotEqual {
+		if len(lhs.present) == 0 {
+			return celTerm{text: "true", typ: "boolean", prec: precPrimary}, true, nil
+		}
+		prec := precAnd
+		if len(lhs.present) == 1 {
+			prec = precCompare
+		}
+		return celTerm{text: strings.Join(lhs.present, " && "), typ: "boolean", prec: prec}, true, nil
+	}
+	if len(lhs.absent) == 0 {
+		return celTerm{text: "false", typ: "boolean", prec: precPrimary}, true, nil
+	}
+	prec := precOr
+	if len(lhs.absent) == 1 {
+		prec = precCompare
+	}
+	return celTerm{text: strings.Join(lhs.absent, " || "), typ: "boolean", prec: prec}, true, nil
+}
+
+// reference translates a reference to a variable or to part of it, walking
+// its schema to type the value and to test that it is present.
+func (g *hclGen) reference(trav hcl.Traversal) (celTerm, error) {
+	if trav.RootName() != "var" || len(trav) < 2 {
+		return celTerm{}, fmt.Errorf("only references to variables are supported")
+	}
+	var t celTerm
+	e, node := "self", g.inputs
+	for i, step := range trav[1:] {
+		var name string
+		switch s := step.(type) {
+		case hcl.TraverseAttr:
+			name = s.Name
+		case hcl.TraverseIndex:
+			if s.Key.Type() == cty.String {
+				name = s.Key.AsString()
+				break
+			}
+			index, ok := wholeNumber(s.Key)
+			items := node.ElementSchema()
+			if tuple := node.TupleItems(); ok && index < len(tuple) {
+				items = tuple[index]
+			}
+			if !ok || items == nil {
+				return celTerm{}, fmt.Errorf("cannot index a value of type %s", typeName(node))
+			}
+			t.present = append(t.present, fmt.Sprintf("%s.size() > %d", e, index))
+			t.absent = append(t.absent, fmt.Sprintf("%s.size() <= %d", e, index))
+			e, node = fmt.Sprintf("%s[%d]", e, index), items
+			g.nullable(&t, e, node)
+			continue
+		default:
+			return celTerm{}, fmt.Errorf("cannot address %s", hcl.Traversal{step})
+		}
+
+		if i == 0 && name == g.variable {
+			node = node.Properties[name]
+		} else if i == 0 && node.Properties[name] == nil {
+			return celTerm{}, fmt.Errorf("there is no variable %q", name)
+		} else if values := node.MapValues(); values != nil {
+			key := strconv.Quote(name)
+			t.present = append(t.present, key+" in "+e)
+			t.absent = append(t.absent, "!("+key+" in "+e+")")
+			e, node = e+"["+key+"]", values
+		} else if prop := node.Properties[name]; prop != nil {
+			field := e + "." + fieldName(name)
+			if !isRequired(node, name) && prop.Default == nil {
+				t.present = append(t.present, "has("+field+")")
+				t.absent = append(t.absent, "!has("+field+")")
+			}
+			e, node = field, prop
+		} else {
+			return celTerm{}, fmt.Errorf("cannot address %q on a value of type %s", name, typeName(node))
+		}
+		g.nullable(&t, e, node)
+	}
+	t.typ = node.BaseType()
+	if t.typ == "" {
+		return celTerm{}, fmt.Errorf("values of type any have no CEL type to check")
+	}
+	t.text, t.prec, t.node = e, precPrimary, node
+	return t, nil
+}
+
+// nullable adds the test that a value of the node at e is not null.
+func (g *hclGen) nullable(t *celTerm, e string, node *jsonschema.Schema) {
+	if node.AcceptsNull() {
+		t.present = append(t.present, e+" != null")
+		t.absent = append(t.absent, e+" == null")
+	}
+}
+
+// call translates the functions with a CEL counterpart.
+func (g *hclGen) call(e *hclsyntax.FunctionCallExpr) (celTerm, error) {
+	if e.ExpandFinal {
+		return celTerm{}, fmt.Errorf("expanding function arguments is not supported")
+	}
+	if call, ok := calledRegex(e); ok {
+		pattern, ok := call.Args[0].(*hclsyntax.TemplateExpr)
+		if !ok {
+			return celTerm{}, fmt.Errorf("the pattern of regex is not a string literal")
+		}
+		p, err := g.expr(pattern)
+		if err != nil || p.typ != "string" {
+			return celTerm{}, fmt.Errorf("the pattern of regex is not a string literal")
+		}
+		s, err := g.operand(call.Args[1])
+		if err != nil {
+			return celTerm{}, err
+		}
+		return celTerm{text: s.wrap(precPrimary) + ".matches(" + p.text + ")", typ: "boolean", prec: precPrimary}, nil
+	}
+
+	var args []celTerm
+	for _, arg := range e.Args {
+		t, err := g.operand(arg)
+		if err != nil {
+			return celTerm{}, err
+		}
+		args = append(args, t)
+	}
+	switch {
+	case e.Name == "length" && len(args) == 1:
+		return celTerm{text: args[0].wrap(precPrimary) + ".size()", typ: "integer", prec: precPrimary}, nil
+	case e.Name == "contains" && len(args) == 2:
+		elem := "number"
+		if args[0].node != nil && args[0].node.ElementSchema() != nil {
+			elem = args[0].node.ElementSchema().BaseType()
+		}
+		return celTerm{text: args[1].render(elem) + " in " + args[0].wrap(precPrimary), typ: "boolean", prec: precCompare}, nil
+	case stringMethods[e.Name] != "" && len(args) == 2:
+		return celTerm{text: args[0].wrap(precPrimary) + "." + stringMethods[e.Name] + "(" + args[1].render("") + ")", typ: "boolean", prec: precPrimary}, nil
+	}
+	return celTerm{}, fmt.Errorf("function %s has no CEL counterpart", e.Name)
+}
+
+// calledRegex returns the regex call of can(regex(pattern, string)), which
+// CEL's matches checks with the same RE2 syntax.
+func calledRegex(e *hclsyntax.FunctionCallExpr) (*hclsyntax.FunctionCallExpr, bool) {
+	if e.Name != "can" || len(e.Args) != 1 {
+		return nil, false
+	}
+	inner, ok := e.Args[0].(*hclsyntax.FunctionCallExpr)
+	if !ok || inner.Name != "regex" || len(inner.Args) != 2 || inner.ExpandFinal {
+		return nil, false
+	}
+	return inner, true
+}
+
+// literal translates a literal, leaving numbers to be rendered once the type
+// of the value they are used with is known.
+func literal(v cty.Value) (celTerm, error) {
+	switch {
+	case v.IsNull():
+		return celTerm{text: "null", typ: "null", prec: precPrimary}, nil
+	case v.Type() == cty.String:
+		return celTerm{text: strconv.Quote(v.AsString()), typ: "string", prec: precPrimary}, nil
+	case v.Type() == cty.Bool:
+		return celTerm{text: strconv.FormatBool(v.True()), typ: "boolean", prec: precPrimary}, nil
+	case v.Type() == cty.Number:
+		f, _ := v.AsBigFloat().Float64()
+		return celTerm{num: &f, typ: "number", prec: precPrimary}, nil
+	}
+	return celTerm{}, fmt.Errorf("literals of type %s are not supported", v.Type().FriendlyName())
+}
+
+// render returns the text of a term, rendering a number literal for values
+// of schema type t.
+func (t celTerm) render(typ string) string {
+	if t.num == nil {
+		return t.text
+	}
+	if typ == "" {
+		typ = "number"
+	}
+	return number(*t.num, typ)
+}
+
+// double renders a number term as a double.
+func (t celTerm) double() string {
+	if t.typ == "integer" && t.num == nil {
+		return "double(" + t.text + ")"
+	}
+	return t.render("number")
+}
+
+// wrap renders a term in parentheses when its operator binds more loosely
+// than prec.
+func (t celTerm) wrap(prec int) string {
+	if t.prec < prec {
+		return "(" + t.render("") + ")"
+	}
+	return t.render("")
+}
+
+func isNumber(t string) bool {
+	return t == "number" || t == "integer"
+}
+
+func isRequired(node *jsonschema.Schema, name string) bool {
+	if node.Required == nil {
+		return false
+	}
+	for _, required := range *node.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+func wholeNumber(v cty.Value) (int, bool) {
+	if v.Type() != cty.Number || v.IsNull() {
+		return 0, false
+	}
+	f, _ := v.AsBigFloat().Float64()
+	if f < 0 || f != float64(int(f)) {
+		return 0, false
+	}
+	return int(f), true
+}
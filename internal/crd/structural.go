@@ -0,0 +1,262 @@
+package crd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+)
+
+// props is a node of a structural schema: every node has a type, except
+// those preserving unknown fields, and there is no anyOf, not or if.
+// Exclusive bounds are flags on minimum and maximum, as in OpenAPI 3.0.
+type props struct {
+	Type                  string            `yaml:"type,omitempty"`
+	Description           string            `yaml:"description,omitempty"`
+	Default               *value            `yaml:"default,omitempty"`
+	Nullable              bool              `yaml:"nullable,omitempty"`
+	Format                string            `yaml:"format,omitempty"`
+	Pattern               string            `yaml:"pattern,omitempty"`
+	Enum                  []interface{}     `yaml:"enum,omitempty"`
+	MinLength             *int              `yaml:"minLength,omitempty"`
+	MaxLength             *int              `yaml:"maxLength,omitempty"`
+	Minimum               *float64          `yaml:"minimum,omitempty"`
+	ExclusiveMinimum      bool              `yaml:"exclusiveMinimum,omitempty"`
+	Maximum               *float64          `yaml:"maximum,omitempty"`
+	ExclusiveMaximum      bool              `yaml:"exclusiveMaximum,omitempty"`
+	MultipleOf            *float64          `yaml:"multipleOf,omitempty"`
+	MinItems              *int              `yaml:"minItems,omitempty"`
+	MaxItems              *int              `yaml:"maxItems,omitempty"`
+	Items                 *props            `yaml:"items,omitempty"`
+	MinProperties         *int              `yaml:"minProperties,omitempty"`
+	MaxProperties         *int              `yaml:"maxProperties,omitempty"`
+	Required              []string          `yaml:"required,omitempty"`
+	Properties            map[string]*props `yaml:"properties,omitempty"`
+	AdditionalProperties  *props            `yaml:"additionalProperties,omitempty"`
+	AllOf                 []*props          `yaml:"allOf,omitempty"`
+	PreserveUnknownFields bool              `yaml:"x-kubernetes-preserve-unknown-fields,omitempty"`
+	ListType              string            `yaml:"x-kubernetes-list-type,omitempty"`
+	MapType               string            `yaml:"x-kubernetes-map-type,omitempty"`
+	Validations           []celRule         `yaml:"x-kubernetes-validations,omitempty"`
+}
+
+// value wraps a default so that false, 0 and "" are not left out as empty.
+type value struct {
+	v interface{}
+}
+
+func (v value) MarshalYAML() (interface{}, error) {
+	return v.v, nil
+}
+
+// celRule is an entry of x-kubernetes-validations.
+type celRule struct {
+	Rule    string `yaml:"rule"`
+	Message string `yaml:"message,omitempty"`
+}
+
+// formats are the string formats the API server validates.
+var formats = map[string]bool{
+	"date": true, "date-time": true, "duration": true, "email": true, "hostname": true,
+	"ipv4": true, "ipv6": true, "uri": true, "uuid": true,
+}
+
+// structural converts a schema of the converter into a structural one.
+// Nullable anyOf become nullable, any becomes
+// x-kubernetes-preserve-unknown-fields, sets become lists of type set and
+// maps are granular. Tuples are lists of unknown fields whose element types
+// are checked by CEL rules. Keywords with no structural equivalent are left
+// out; the validations they came from get CEL rules instead.
+func structural(s *jsonschema.Schema) *props {
+	p := &props{Description: s.Description, Nullable: s.AcceptsNull() || s.Nullable != nil && *s.Nullable}
+	if s.Default != nil {
+		p.Default = &value{s.Default}
+	}
+
+	switch t := s.BaseType(); t {
+	case "string":
+		p.Type = t
+		if formats[s.Format] {
+			p.Format = s.Format
+		}
+		p.Pattern, p.MinLength, p.MaxLength = s.Pattern, s.MinLength, s.MaxLength
+	case "number", "integer":
+		p.Type = t
+		p.Minimum, p.Maximum, p.MultipleOf = s.Minimum, s.Maximum, s.MultipleOf
+		if s.ExclusiveMinimum != nil && (p.Minimum == nil || *s.ExclusiveMinimum >= *p.Minimum) {
+			p.Minimum, p.ExclusiveMinimum = s.ExclusiveMinimum, true
+		}
+		if s.ExclusiveMaximum != nil && (p.Maximum == nil || *s.ExclusiveMaximum <= *p.Maximum) {
+			p.Maximum, p.ExclusiveMaximum = s.ExclusiveMaximum, true
+		}
+	case "boolean":
+		p.Type = t
+	case "array":
+		p.Type = t
+		if tuple := s.TupleItems(); tuple != nil {
+			n := len(tuple)
+			p.Items = &props{PreserveUnknownFields: true}
+			p.MinItems, p.MaxItems = &n, &n
+			p.ListType = "atomic"
+			for i, item := range tuple {
+				if rule, message := elementType(i, item); rule != "" {
+					p.Validations = append(p.Validations, celRule{Rule: rule, Message: message})
+				}
+			}
+			break
+		}
+		p.MinItems, p.MaxItems = s.MinItems, s.MaxItems
+		p.Items = &props{PreserveUnknownFields: true}
+		if items := s.ElementSchema(); items != nil {
+			p.Items = structural(items)
+		}
+		p.ListType = "atomic"
+		if s.UniqueItems != nil && *s.UniqueItems && p.Items.Type != "" {
+			// The elements of a set must be scalars or atomic.
+			p.ListType = "set"
+			switch p.Items.Type {
+			case "object":
+				p.Items.MapType = "atomic"
+			case "array":
+				p.Items.ListType = "atomic"
+			}
+		}
+	case "object":
+		p.Type = t
+		p.MinProperties, p.MaxProperties = s.MinProperties, s.MaxProperties
+		switch values := s.MapValues(); {
+		case values != nil:
+			p.AdditionalProperties = structural(values)
+			p.MapType = "granular"
+		case len(s.Properties) > 0:
+			p.Properties = map[string]*props{}
+			for name, prop := range s.Properties {
+				p.Properties[name] = structural(prop)
+			}
+			if s.Required != nil && len(*s.Required) > 0 {
+				p.Required = append([]string{}, *s.Required...)
+				sort.Strings(p.Required)
+			}
+		default:
+			p.PreserveUnknownFields = true
+		}
+	default:
+		return &props{Description: p.Description, Default: p.Default, Nullable: p.Nullable, PreserveUnknownFields: true}
+	}
+	p.Enum = s.Enum
+	for _, sub := range s.AllOf {
+		if keywords := valueKeywords(sub, p.Type); keywords != nil {
+			p.AllOf = append(p.AllOf, keywords)
+		}
+	}
+	return p
+}
+
+// valueKeywords returns the structural form of an allOf entry made only of
+// value validations, such as a second pattern, or nil. The entry is read as
+// a schema of type t.
+func valueKeywords(s *jsonschema.Schema, t string) *props {
+	rest := *s
+	rest.Pattern, rest.Format, rest.Enum = "", "", nil
+	rest.MinLength, rest.MaxLength, rest.MinItems, rest.MaxItems, rest.MinProperties, rest.MaxProperties = nil, nil, nil, nil, nil, nil
+	rest.Minimum, rest.Maximum, rest.ExclusiveMinimum, rest.ExclusiveMaximum, rest.MultipleOf = nil, nil, nil, nil, nil
+	if !isEmpty(&rest) {
+		return nil
+	}
+	typed := *s
+	typed.Type = t
+	p := structural(&typed)
+	p.Type, p.Description, p.Default, p.Nullable = "", "", nil, false
+	p.PreserveUnknownFields, p.ListType, p.MapType, p.Items = false, "", "", nil
+	return p
+}
+
+func isEmpty(s *jsonschema.Schema) bool {
+	return s.Type == nil && s.Properties == nil && s.Required == nil && s.Items == nil &&
+		s.AdditionalProperties == nil && s.PropertyNames == nil && s.Dependencies == nil &&
+		s.DependentRequired == nil && s.PrefixItems == nil && s.UniqueItems == nil &&
+		s.Contains == nil && s.MinContains == nil && s.AnyOf == nil && s.AllOf == nil &&
+		s.Not == nil && s.If == nil && s.Then == nil && s.Else == nil
+}
+
+// elementType returns the CEL rule checking the type of the element of a
+// tuple at index i, with its message.
+func elementType(i int, item *jsonschema.Schema) (string, string) {
+	e := fmt.Sprintf("self[%d]", i)
+	var check, noun string
+	switch item.BaseType() {
+	case "string":
+		check, noun = "type("+e+") == string", "a string"
+	case "number", "integer":
+		check, noun = "type("+e+") == int || type("+e+") == double", "a number"
+	case "boolean":
+		check, noun = "type("+e+") == bool", "a bool"
+	case "array":
+		check, noun = "type("+e+") == list", "a list"
+	case "object":
+		check, noun = "type("+e+") == map", "an object"
+	default:
+		return "", ""
+	}
+	if item.AcceptsNull() {
+		check = e + " == null || " + check
+	}
+	return check, fmt.Sprintf("Element %d must be %s.", i, noun)
+}
+
+// expressed reports whether the structural schema of inputs already holds
+// the keywords the converter set for c, so that c needs no CEL rule: its
+// atoms are all joined by And and address attributes and wildcards only.
+func expressed(c constraint.Constraint, inputs *jsonschema.Schema) bool {
+	switch n := c.(type) {
+	case constraint.And:
+		for _, term := range n.Terms {
+			if !expressed(term, inputs) {
+				return false
+			}
+		}
+		return true
+	case constraint.Atom:
+		if len(n.Path) == 0 {
+			return false
+		}
+		node := inputs
+		for _, segment := range n.Path {
+			switch segment.Kind {
+			case constraint.AttributeSegment:
+				if node.MapValues() != nil || node.Properties[segment.Name] == nil {
+					return false
+				}
+				node = node.Properties[segment.Name]
+			case constraint.WildcardSegment:
+				next := node.ElementSchema()
+				if next == nil {
+					next = node.MapValues()
+				}
+				if next == nil {
+					return false
+				}
+				node = next
+			default:
+				return false
+			}
+		}
+		if node.BaseType() == "" {
+			return false // Unknown fields keep no keywords.
+		}
+		switch p := n.Predicate.(type) {
+		case constraint.Length, constraint.Range, constraint.Pattern, constraint.Enum, constraint.MultipleOf:
+			return true
+		case constraint.Format:
+			return formats[p.Format]
+		case constraint.Integer:
+			return node.BaseType() == "integer"
+		case constraint.UniqueItems:
+			return node.BaseType() == "array" && node.TupleItems() == nil && node.ElementSchema() != nil && node.ElementSchema().BaseType() != ""
+		case constraint.Required:
+			return node.MapValues() == nil && len(node.Properties) > 0
+		}
+	}
+	return false
+}
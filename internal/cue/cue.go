@@ -0,0 +1,646 @@
+// Package cue generates a CUE definition for the inputs of a Terraform module
+// from its converted variables and the constraint IR of its validations.
+package cue
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+)
+
+// integerPattern matches strings holding a base-10 integer.
+const integerPattern = `^[+-]?[0-9]+$`
+
+// identifierPattern matches labels that need no quotes.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z$][A-Za-z0-9_$]*$`)
+
+// keywords are the CUE keywords, which are quoted as labels.
+var keywords = map[string]bool{
+	"package": true, "import": true, "for": true, "in": true, "if": true, "let": true,
+	"true": true, "false": true, "null": true, "div": true, "mod": true, "quo": true, "rem": true,
+}
+
+// formats are the string formats with a validator in the CUE standard
+// library.
+var formats = map[string]struct{ pkg, validator string }{
+	"date-time": {"time", "time.Time"},
+	"ipv4":      {"net", "net.IPv4"},
+	"ipv6":      {"net", "net.IPv6"},
+}
+
+// syntax renders CUE literals, with labels as map keys.
+var syntax = render.Syntax{Null: "null", True: "true", False: "false", String: quote, Key: label}
+
+// generator accumulates the imports of a generated file and the constraints
+// taken from validations, by the path of the value they constrain.
+type generator struct {
+	imports     map[string]bool
+	constraints map[string][]string
+	entries     map[string]map[string]bool // map entries by key, and whether they are required
+}
+
+// Generate renders a CUE file in package pkg declaring an `#Inputs`
+// definition with one field per variable, in declaration order. Variables
+// with a default are `*default | type`, optional attributes and variables
+// defaulting to null are `field?:`, and enums are disjunctions. The length,
+// range, pattern and format keywords become `strings.MinRunes`, `>=`, `=~`
+// and validators of the standard library. Validations whose conditions the
+// keywords do not capture, such as if/then, are listed as TODO comments.
+func Generate(pkg, module string, variables []converter.Variable, validations []constraint.Validation) string {
+	g := &generator{
+		imports:     map[string]bool{},
+		constraints: map[string][]string{},
+		entries:     map[string]map[string]bool{},
+	}
+
+	inputs := &jsonschema.Schema{Type: "object", Properties: map[string]*jsonschema.Schema{}}
+	for _, v := range variables {
+		inputs.Properties[v.Name] = v.Schema
+	}
+	var todo []constraint.Validation
+	for _, v := range validations {
+		if !g.capture(v.Constraint, inputs) {
+			todo = append(todo, v)
+		}
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "// #Inputs are the input variables of the %s module.\n", module)
+	body.WriteString("#Inputs: {\n")
+	for i, v := range variables {
+		if i > 0 {
+			body.WriteString("\n")
+		}
+		var def interface{}
+		optional := !v.Required
+		if optional && v.Schema.Default != nil {
+			def, optional = v.Schema.Default, false
+		}
+		g.writeField(&body, v.Name, v.Schema, constraint.Path{constraint.Attr(v.Name)}, optional, def, 1)
+	}
+	for _, v := range todo {
+		body.WriteString("\n")
+		fmt.Fprintf(&body, "\t// TODO: %s\n", render.CommentLine(fmt.Sprintf("%q has no CUE constraint.", v.Variable+": "+v.ErrorMessage)))
+		for _, line := range strings.Split(strings.TrimSpace(v.Source), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				fmt.Fprintf(&body, "\t// %s\n", line)
+			}
+		}
+	}
+	body.WriteString("\n\t...\n}\n")
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by tfschema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	switch imports := render.SortedKeys(g.imports); len(imports) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&b, "import %q\n\n", imports[0])
+	default:
+		b.WriteString("import (\n")
+		for _, path := range imports {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		b.WriteString(")\n\n")
+	}
+	b.WriteString(body.String())
+	return b.String()
+}
+
+// writeField writes a field of a struct at the given depth, with the
+// description as a comment.
+func (g *generator) writeField(b *strings.Builder, name string, s *jsonschema.Schema, path constraint.Path, optional bool, def interface{}, depth int) {
+	indent := strings.Repeat("\t", depth)
+	if s.Description != "" {
+		for _, line := range strings.Split(strings.TrimSpace(s.Description), "\n") {
+			fmt.Fprintf(b, "%s// %s\n", indent, strings.TrimRight(line, " \t"))
+		}
+	}
+	marker := ""
+	if optional {
+		marker = "?"
+	}
+	fmt.Fprintf(b, "%s%s%s: %s\n", indent, label(name), marker, g.value(s, path, def, depth))
+}
+
+// value renders the disjunction of the values a schema accepts, marking the
+// default, if any.
+func (g *generator) value(s *jsonschema.Schema, path constraint.Path, def interface{}, depth int) string {
+	var disjuncts []string
+	if s.AcceptsNull() || s.Nullable != nil && *s.Nullable {
+		disjuncts = append(disjuncts, "null")
+	}
+	if len(s.Enum) > 0 {
+		for _, v := range s.Enum {
+			if v != nil {
+				disjuncts = append(disjuncts, syntax.Literal(v))
+			}
+		}
+	} else {
+		disjuncts = append(disjuncts, g.typeExpr(s, path, depth))
+	}
+	if def != nil {
+		marked := false
+		d := syntax.Literal(def)
+		for i, disjunct := range disjuncts {
+			if disjunct == d {
+				disjuncts[i], marked = "*"+d, true
+			}
+		}
+		if !marked {
+			disjuncts = append([]string{"*" + d}, disjuncts...)
+		}
+	}
+	return strings.Join(disjuncts, " | ")
+}
+
+// typeExpr renders the non-null type of a schema with the constraints of its
+// keywords and of the validations on path.
+func (g *generator) typeExpr(s *jsonschema.Schema, path constraint.Path, depth int) string {
+	var parts []string
+	switch t := s.BaseType(); t {
+	case "string":
+		parts = append(parts, "string")
+		for _, k := range append([]*jsonschema.Schema{s}, s.AllOf...) {
+			if k.MinLength != nil {
+				g.imports["strings"] = true
+				parts = append(parts, fmt.Sprintf("strings.MinRunes(%d)", *k.MinLength))
+			}
+			if k.MaxLength != nil {
+				g.imports["strings"] = true
+				parts = append(parts, fmt.Sprintf("strings.MaxRunes(%d)", *k.MaxLength))
+			}
+			if k.Pattern != "" {
+				parts = append(parts, "=~"+quote(k.Pattern))
+			}
+			if f, ok := formats[k.Format]; ok {
+				g.imports[f.pkg] = true
+				parts = append(parts, f.validator)
+			}
+		}
+	case "number", "integer":
+		if t == "integer" {
+			parts = append(parts, "int")
+		} else {
+			parts = append(parts, "number")
+		}
+		for _, k := range append([]*jsonschema.Schema{s}, s.AllOf...) {
+			for _, bound := range []struct {
+				value *float64
+				op    string
+			}{{k.Minimum, ">="}, {k.Maximum, "<="}, {k.ExclusiveMinimum, ">"}, {k.ExclusiveMaximum, "<"}} {
+				if bound.value != nil {
+					parts = append(parts, bound.op+render.Number(*bound.value))
+				}
+			}
+		}
+	case "boolean":
+		parts = append(parts, "bool")
+	case "array":
+		if tuple := s.TupleItems(); tuple != nil {
+			items := make([]string, len(tuple))
+			for i, item := range tuple {
+				items[i] = g.value(item, path.Append(constraint.Index(i)), nil, depth)
+			}
+			parts = append(parts, "["+strings.Join(items, ", ")+"]")
+			break
+		}
+		elem := "_"
+		if items := s.ElementSchema(); items != nil {
+			elem = g.value(items, path.Append(constraint.Wildcard()), nil, depth)
+			if strings.Contains(elem, " | ") {
+				elem = "(" + elem + ")"
+			}
+		}
+		parts = append(parts, "[..."+elem+"]")
+		for _, k := range append([]*jsonschema.Schema{s}, s.AllOf...) {
+			if k.MinItems != nil {
+				g.imports["list"] = true
+				parts = append(parts, fmt.Sprintf("list.MinItems(%d)", *k.MinItems))
+			}
+			if k.MaxItems != nil {
+				g.imports["list"] = true
+				parts = append(parts, fmt.Sprintf("list.MaxItems(%d)", *k.MaxItems))
+			}
+		}
+		if s.UniqueItems != nil && *s.UniqueItems {
+			g.imports["list"] = true
+			parts = append(parts, "list.UniqueItems()")
+		}
+	case "object":
+		switch values := s.MapValues(); {
+		case values != nil:
+			key := strings.Join(unique(append([]string{"string"}, g.constraints[path.Append(constraint.Keys()).String()]...)), " & ")
+			fields := []string{"[" + key + "]: " + g.value(values, path.Append(constraint.Wildcard()), nil, depth)}
+			entries := g.entries[path.String()]
+			for _, name := range render.SortedKeys(entries) {
+				marker := "?"
+				if entries[name] {
+					marker = ""
+				}
+				fields = append(fields, label(name)+marker+": "+g.value(values, path.Append(constraint.Key(name)), nil, depth))
+			}
+			parts = append(parts, "{"+strings.Join(fields, ", ")+"}")
+		case len(s.Properties) > 0:
+			parts = append(parts, g.structExpr(s, path, depth))
+		default:
+			parts = append(parts, "{...}")
+		}
+		for _, k := range append([]*jsonschema.Schema{s}, s.AllOf...) {
+			if k.MinProperties != nil {
+				g.imports["struct"] = true
+				parts = append(parts, fmt.Sprintf("struct.MinFields(%d)", *k.MinProperties))
+			}
+			if k.MaxProperties != nil {
+				g.imports["struct"] = true
+				parts = append(parts, fmt.Sprintf("struct.MaxFields(%d)", *k.MaxProperties))
+			}
+		}
+	default:
+		return "_"
+	}
+	parts = append(parts, g.constraints[path.String()]...)
+	return strings.Join(unique(parts), " & ")
+}
+
+// structExpr renders an object type as a struct with its attributes sorted
+// by name. Like the schema, the struct is open to other attributes.
+func (g *generator) structExpr(s *jsonschema.Schema, path constraint.Path, depth int) string {
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range render.SortedKeys(s.Properties) {
+		g.writeField(&b, name, s.Properties[name], path.Append(constraint.Attr(name)), !requires(s, name), nil, depth+1)
+	}
+	fmt.Fprintf(&b, "%s...\n%s}", strings.Repeat("\t", depth+1), strings.Repeat("\t", depth))
+	return b.String()
+}
+
+// capture reports whether the generated definition checks c, a conjunction
+// of atoms and negated atoms. Atoms whose keyword the schema already holds
+// need nothing more; the others add a constraint at their path, which may
+// address map entries, tuple elements and map keys.
+func (g *generator) capture(c constraint.Constraint, inputs *jsonschema.Schema) bool {
+	type addition struct {
+		path, expr string
+		entry      *entry
+	}
+	var additions []addition
+	for _, term := range terms(c) {
+		negated := false
+		if not, ok := term.(constraint.Not); ok {
+			term, negated = not.Term, true
+		}
+		atom, ok := term.(constraint.Atom)
+		if !ok {
+			return false
+		}
+		node, path, entries, ok := resolve(inputs, atom.Path)
+		if !ok {
+			return false
+		}
+		for _, e := range entries {
+			additions = append(additions, addition{entry: e})
+		}
+
+		var expr string
+		switch p := atom.Predicate.(type) {
+		case constraint.Required:
+			if negated {
+				return false
+			}
+			if node.MapValues() == nil {
+				if !rendered(p, node) {
+					return false
+				}
+				continue
+			}
+			for _, key := range p.Properties {
+				additions = append(additions, addition{entry: &entry{path: path.String(), key: key, required: true}})
+			}
+			continue
+		default:
+			if negated {
+				expr = negation(p, node.BaseType())
+			} else if plain(path) && rendered(p, node) {
+				continue
+			} else {
+				expr = g.predicateExpr(p, node)
+			}
+		}
+		if expr == "" {
+			return false
+		}
+		additions = append(additions, addition{path: path.String(), expr: expr})
+	}
+	for _, a := range additions {
+		if a.entry != nil {
+			g.addEntry(*a.entry)
+		} else {
+			g.constraints[a.path] = append(g.constraints[a.path], strings.Split(a.expr, " & ")...)
+		}
+	}
+	return true
+}
+
+// terms returns the terms of the conjunction c. A constraint every element
+// of a collection satisfies contributes its own terms at the element path,
+// since CUE constrains each element separately.
+func terms(c constraint.Constraint) []constraint.Constraint {
+	switch n := c.(type) {
+	case constraint.And:
+		var all []constraint.Constraint
+		for _, term := range n.Terms {
+			all = append(all, terms(term)...)
+		}
+		return all
+	case constraint.Atom:
+		satisfies, ok := n.Predicate.(constraint.Satisfies)
+		if !ok {
+			break
+		}
+		var all []constraint.Constraint
+		for _, term := range terms(satisfies.Constraint) {
+			switch t := term.(type) {
+			case constraint.Atom:
+				term = constraint.Atom{Path: n.Path.Append(t.Path...), Predicate: t.Predicate}
+			case constraint.Not:
+				if atom, ok := t.Term.(constraint.Atom); ok {
+					term = constraint.Not{Term: constraint.Atom{Path: n.Path.Append(atom.Path...), Predicate: atom.Predicate}}
+					break
+				}
+				return []constraint.Constraint{c}
+			default:
+				return []constraint.Constraint{c}
+			}
+			all = append(all, term)
+		}
+		return all
+	}
+	return []constraint.Constraint{c}
+}
+
+// entry is an entry of a map addressed by a validation, rendered as a field
+// beside the pattern constraint of the map.
+type entry struct {
+	path     string // of the map
+	key      string
+	required bool
+}
+
+func (g *generator) addEntry(e entry) {
+	if g.entries[e.path] == nil {
+		g.entries[e.path] = map[string]bool{}
+	}
+	g.entries[e.path][e.key] = g.entries[e.path][e.key] || e.required
+}
+
+// plain reports whether a path only has attributes and wildcards, which the
+// converter turns into keywords of nested schemas.
+func plain(path constraint.Path) bool {
+	for _, segment := range path {
+		if segment.Kind != constraint.AttributeSegment && segment.Kind != constraint.WildcardSegment {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve returns the schema of the values at a path, and the path the
+// generator renders them at: map keys of objects become attributes. Indexes
+// address tuple elements only, and keys(...) ends a path. The map entries
+// along the path are returned too.
+func resolve(inputs *jsonschema.Schema, path constraint.Path) (*jsonschema.Schema, constraint.Path, []*entry, bool) {
+	if len(path) == 0 {
+		return nil, nil, nil, false
+	}
+	node, rendered := inputs, constraint.Path{}
+	var entries []*entry
+	for i, segment := range path {
+		switch segment.Kind {
+		case constraint.AttributeSegment, constraint.KeySegment:
+			if values := node.MapValues(); values != nil {
+				entries = append(entries, &entry{path: rendered.String(), key: segment.Name})
+				node, rendered = values, rendered.Append(constraint.Key(segment.Name))
+				continue
+			}
+			if node.Properties[segment.Name] == nil {
+				return nil, nil, nil, false
+			}
+			node, rendered = node.Properties[segment.Name], rendered.Append(constraint.Attr(segment.Name))
+		case constraint.IndexSegment:
+			tuple := node.TupleItems()
+			if segment.Index >= len(tuple) {
+				return nil, nil, nil, false
+			}
+			node, rendered = tuple[segment.Index], rendered.Append(segment)
+		case constraint.WildcardSegment:
+			next := node.ElementSchema()
+			if next == nil {
+				next = node.MapValues()
+			}
+			if next == nil {
+				return nil, nil, nil, false
+			}
+			node, rendered = next, rendered.Append(segment)
+		case constraint.KeysSegment:
+			if node.MapValues() == nil || i != len(path)-1 {
+				return nil, nil, nil, false
+			}
+			node, rendered = &jsonschema.Schema{Type: "string"}, rendered.Append(segment)
+		}
+	}
+	return node, rendered, entries, node.BaseType() != ""
+}
+
+// rendered reports whether the keyword the converter set for a predicate on
+// a node is rendered by typeExpr.
+func rendered(p constraint.Predicate, node *jsonschema.Schema) bool {
+	switch p := p.(type) {
+	case constraint.Length, constraint.Range, constraint.Pattern, constraint.Enum:
+		return true
+	case constraint.Format:
+		_, ok := formats[p.Format]
+		return ok
+	case constraint.Integer:
+		return node.BaseType() == "integer"
+	case constraint.UniqueItems:
+		return node.BaseType() == "array" && node.TupleItems() == nil
+	case constraint.Required:
+		return node.MapValues() == nil && len(node.Properties) > 0
+	}
+	return false
+}
+
+// predicateExpr renders a predicate on a node as a CUE constraint, or ""
+// if CUE has none, as for multiples.
+func (g *generator) predicateExpr(p constraint.Predicate, node *jsonschema.Schema) string {
+	t := node.BaseType()
+	switch p := p.(type) {
+	case constraint.Length:
+		var fn string
+		switch {
+		case t == "string":
+			g.imports["strings"], fn = true, "strings.%sRunes(%d)"
+		case t == "array" && node.TupleItems() == nil:
+			g.imports["list"], fn = true, "list.%sItems(%d)"
+		case t == "object" && node.MapValues() != nil:
+			g.imports["struct"], fn = true, "struct.%sFields(%d)"
+		default:
+			return ""
+		}
+		var parts []string
+		if p.Min != nil {
+			parts = append(parts, fmt.Sprintf(fn, "Min", *p.Min))
+		}
+		if p.Max != nil {
+			parts = append(parts, fmt.Sprintf(fn, "Max", *p.Max))
+		}
+		return strings.Join(parts, " & ")
+	case constraint.Range:
+		if t != "number" && t != "integer" {
+			return ""
+		}
+		var parts []string
+		for _, bound := range []struct {
+			value *float64
+			op    string
+		}{{p.Minimum, ">="}, {p.Maximum, "<="}, {p.ExclusiveMinimum, ">"}, {p.ExclusiveMaximum, "<"}} {
+			if bound.value != nil {
+				parts = append(parts, bound.op+render.Number(*bound.value))
+			}
+		}
+		return strings.Join(parts, " & ")
+	case constraint.Pattern:
+		if t == "string" {
+			return "=~" + quote(p.Regexp())
+		}
+	case constraint.Format:
+		if f, ok := formats[p.Format]; ok && t == "string" {
+			g.imports[f.pkg] = true
+			return f.validator
+		}
+	case constraint.Enum:
+		var values []string
+		for _, v := range p.Values {
+			if typeOf(v) == t || typeOf(v) == "number" && t == "integer" {
+				values = append(values, syntax.Literal(v))
+			}
+		}
+		if len(values) == 0 {
+			return "_|_"
+		}
+		return "(" + strings.Join(values, " | ") + ")"
+	case constraint.Integer:
+		switch t {
+		case "number", "integer":
+			return "int"
+		case "string":
+			return "=~" + quote(integerPattern)
+		}
+	case constraint.UniqueItems:
+		if t == "array" && node.TupleItems() == nil {
+			g.imports["list"] = true
+			return "list.UniqueItems()"
+		}
+	}
+	return ""
+}
+
+// negation renders the constraint of a negated predicate on a value of type
+// t, or "" if it has none.
+func negation(p constraint.Predicate, t string) string {
+	switch p := p.(type) {
+	case constraint.Enum:
+		var parts []string
+		for _, v := range p.Values {
+			if typeOf(v) != t && !(typeOf(v) == "number" && t == "integer") {
+				return ""
+			}
+			parts = append(parts, "!="+syntax.Literal(v))
+		}
+		return strings.Join(parts, " & ")
+	case constraint.Pattern:
+		if t == "string" {
+			return "!~" + quote(p.Regexp())
+		}
+	}
+	return ""
+}
+
+// typeOf returns the schema type of an enum value.
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+	return ""
+}
+
+// quote renders a CUE string literal, escaping control characters with \u
+// as CUE has no \x escapes in strings.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// label renders a field name, quoted unless it is an identifier.
+func label(name string) string {
+	if identifierPattern.MatchString(name) && !keywords[name] {
+		return name
+	}
+	return quote(name)
+}
+
+// requires reports whether an object schema lists an attribute as required.
+func requires(s *jsonschema.Schema, name string) bool {
+	if s.Required == nil {
+		return false
+	}
+	for _, required := range *s.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unique returns the distinct parts of a conjunction, in order, since the
+// converter may have set the keyword of a validation that adds it too.
+func unique(parts []string) []string {
+	seen := map[string]bool{}
+	var distinct []string
+	for _, part := range parts {
+		if !seen[part] {
+			seen[part] = true
+			distinct = append(distinct, part)
+		}
+	}
+	return distinct
+}
@@ -0,0 +1,145 @@
+package cue
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = length(var.name) >= 3 && length(var.name) <= 32
+    error_message = "Between 3 and 32 characters."
+  }
+
+  validation {
+    condition     = can(regex("^[a-z]", var.name))
+    error_message = "Starts with a letter."
+  }
+}
+
+variable "environment" {
+  type    = string
+  default = "dev"
+
+  validation {
+    condition     = contains(["dev", "prod"], var.environment)
+    error_message = "Unknown environment."
+  }
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    subnets = optional(list(string), [])
+    peer    = tuple([string, number])
+    "availability-zones" = set(string)
+  })
+  default = null
+}
+
+variable "replicas" {
+  type    = number
+  default = 2
+
+  validation {
+    condition     = var.replicas >= 1 && var.replicas <= 10
+    error_message = "Between 1 and 10 replicas."
+  }
+
+  validation {
+    condition     = var.replicas != 7
+    error_message = "Not 7 replicas."
+  }
+
+  validation {
+    condition     = var.replicas % 2 == 0
+    error_message = "An even number of replicas."
+  }
+}
+
+variable "tags" {
+  type = map(string)
+
+  validation {
+    condition     = alltrue([for k in keys(var.tags) : can(regex("^[a-z]+$", k))])
+    error_message = "Lower-case tag keys."
+  }
+
+  validation {
+    condition     = contains(keys(var.tags), "owner")
+    error_message = "Tags need an owner."
+  }
+
+  validation {
+    condition     = length(var.tags["owner"]) > 0
+    error_message = "The owner must not be empty."
+  }
+}
+
+variable "endpoint" {
+  type     = string
+  nullable = true
+  default  = null
+}
+`)
+	require.NoError(t, err)
+
+	want := `// Code generated by tfschema. DO NOT EDIT.
+
+package vpc
+
+import (
+	"list"
+	"strings"
+)
+
+// #Inputs are the input variables of the terraform-aws-vpc module.
+#Inputs: {
+	// Resource name
+	name: string & strings.MinRunes(3) & strings.MaxRunes(32) & =~"^[a-z]"
+
+	environment: *"dev" | "prod"
+
+	network?: {
+		"availability-zones": [...string] & list.UniqueItems()
+		cidr: string
+		peer: [string, number]
+		subnets?: [...string]
+		...
+	}
+
+	replicas: *2 | number & >=1 & <=10 & !=7
+
+	tags: {[string & =~"^[a-z]+$"]: string, owner: string & strings.MinRunes(1)}
+
+	endpoint?: null | string
+
+	// TODO: "replicas: An even number of replicas." has no CUE constraint.
+	// var.replicas % 2 == 0
+
+	...
+}
+`
+	assert.Equal(t, want, Generate("vpc", "terraform-aws-vpc", c.Variables(), c.Validations()))
+}
+
+func TestLiteral(t *testing.T) {
+	assert.Equal(t, "null", syntax.Literal(nil))
+	assert.Equal(t, `"a\\d\u0000"`, syntax.Literal("a\\d\x00"))
+	assert.Equal(t, `{"a-b": [1, false], c: {}}`, syntax.Literal(map[string]interface{}{"a-b": []interface{}{1.0, false}, "c": map[string]interface{}{}}))
+}
+
+func TestLabel(t *testing.T) {
+	assert.Equal(t, "subnet_ids", label("subnet_ids"))
+	assert.Equal(t, `"availability-zones"`, label("availability-zones"))
+	assert.Equal(t, `"if"`, label("if"))
+}
@@ -0,0 +1,300 @@
+// Package docs renders a reference of the input variables of a Terraform
+// module, with their validation rules, as Markdown or HTML.
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/validation"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Markers delimit the generated reference in a document it is injected into.
+const (
+	BeginMarker = "<!-- BEGIN_TFSCHEMA_DOCS -->"
+	EndMarker   = "<!-- END_TFSCHEMA_DOCS -->"
+)
+
+// Input is the documentation of a variable.
+type Input struct {
+	Name        string
+	Type        string // HCL of the type constraint
+	Description string
+	Default     string // JSON of the default, empty when required
+	Required    bool
+	Sensitive   bool
+	Rules       []Rule
+}
+
+// Rule is a validation block of a variable.
+type Rule struct {
+	// Description is the translated condition in English, or its HCL when it
+	// was not translated.
+	Description  string
+	ErrorMessage string
+	Translated   bool
+}
+
+// Inputs collects the documentation of the variables, sorted by name. Rules
+// are listed in the order of their validation blocks.
+func Inputs(variables []converter.Variable, validations []constraint.Validation, untranslatable []validation.Untranslatable) []Input {
+	type positioned struct {
+		rule  Rule
+		start hcl.Pos
+	}
+	rules := map[string][]positioned{}
+	for _, v := range validations {
+		p := positioned{rule: Rule{Description: constraint.Describe(v.Constraint), ErrorMessage: v.ErrorMessage, Translated: true}}
+		if v.Condition != nil {
+			p.start = v.Condition.Range().Start
+		}
+		rules[v.Variable] = append(rules[v.Variable], p)
+	}
+	for _, u := range untranslatable {
+		p := positioned{rule: Rule{Description: strings.TrimSpace(u.Source), ErrorMessage: u.ErrorMessage}}
+		if u.Condition != nil {
+			p.start = u.Condition.Range().Start
+		}
+		rules[u.Variable] = append(rules[u.Variable], p)
+	}
+
+	inputs := make([]Input, 0, len(variables))
+	for _, v := range variables {
+		input := Input{
+			Name:        v.Name,
+			Type:        dedent(v.Type),
+			Description: strings.TrimSpace(v.Schema.Description),
+			Required:    v.Required,
+			Sensitive:   v.Schema.Sensitive != nil && *v.Schema.Sensitive,
+		}
+		if !v.Required {
+			input.Default = encode(v.Schema.Default)
+		}
+		byPosition := rules[v.Name]
+		sort.SliceStable(byPosition, func(i, j int) bool { return byPosition[i].start.Byte < byPosition[j].start.Byte })
+		for _, p := range byPosition {
+			input.Rules = append(input.Rules, p.rule)
+		}
+		inputs = append(inputs, input)
+	}
+	sort.SliceStable(inputs, func(i, j int) bool { return inputs[i].Name < inputs[j].Name })
+	return inputs
+}
+
+// Markdown renders the inputs as a summary table followed by a section per
+// variable.
+func Markdown(inputs []Input) string {
+	var b strings.Builder
+	b.WriteString("## Inputs\n\n")
+	if len(inputs) == 0 {
+		b.WriteString("No inputs.\n")
+		return b.String()
+	}
+
+	b.WriteString("| Name | Type | Default | Required |\n")
+	b.WriteString("|------|------|---------|:--------:|\n")
+	for _, in := range inputs {
+		fmt.Fprintf(&b, "| [%s](#input_%s) | %s | %s | %s |\n",
+			in.Name, in.Name, tableCode(oneLine(in.Type)), tableDefault(in), yesNo(in.Required))
+	}
+
+	for _, in := range inputs {
+		fmt.Fprintf(&b, "\n### <a name=\"input_%s\"></a> `%s`\n\n", in.Name, in.Name)
+		if in.Description != "" {
+			b.WriteString(in.Description + "\n\n")
+		}
+		if strings.Contains(in.Type, "\n") {
+			fmt.Fprintf(&b, "- **Type:**\n\n  ```hcl\n  %s\n  ```\n\n", strings.ReplaceAll(in.Type, "\n", "\n  "))
+		} else {
+			fmt.Fprintf(&b, "- **Type:** `%s`\n", in.Type)
+		}
+		if !in.Required {
+			fmt.Fprintf(&b, "- **Default:** `%s`\n", defaultText(in))
+		}
+		fmt.Fprintf(&b, "- **Required:** %s\n", yesNo(in.Required))
+		fmt.Fprintf(&b, "- **Sensitive:** %s\n", yesNo(in.Sensitive))
+
+		if len(in.Rules) > 0 {
+			b.WriteString("\n**Validation:**\n\n")
+			for _, rule := range in.Rules {
+				description := rule.Description
+				if !rule.Translated {
+					description = "`" + oneLine(description) + "` (not translated)"
+				}
+				fmt.Fprintf(&b, "- %s: %s\n", description, quoteMessage(rule.ErrorMessage))
+			}
+		}
+	}
+	return b.String()
+}
+
+// HTML renders the inputs as an HTML fragment with the same structure as the
+// Markdown.
+func HTML(inputs []Input) string {
+	var b strings.Builder
+	b.WriteString("<h2>Inputs</h2>\n")
+	if len(inputs) == 0 {
+		b.WriteString("<p>No inputs.</p>\n")
+		return b.String()
+	}
+
+	b.WriteString("<table>\n<thead>\n<tr><th>Name</th><th>Type</th><th>Default</th><th>Required</th></tr>\n</thead>\n<tbody>\n")
+	for _, in := range inputs {
+		def := html.EscapeString(defaultText(in))
+		if !in.Required {
+			def = "<code>" + def + "</code>"
+		}
+		fmt.Fprintf(&b, "<tr><td><a href=\"#input_%s\">%s</a></td><td><code>%s</code></td><td>%s</td><td>%s</td></tr>\n",
+			html.EscapeString(in.Name), html.EscapeString(in.Name), html.EscapeString(oneLine(in.Type)), def, yesNo(in.Required))
+	}
+	b.WriteString("</tbody>\n</table>\n")
+
+	for _, in := range inputs {
+		fmt.Fprintf(&b, "<h3 id=\"input_%s\"><code>%s</code></h3>\n", html.EscapeString(in.Name), html.EscapeString(in.Name))
+		if in.Description != "" {
+			fmt.Fprintf(&b, "<p>%s</p>\n", html.EscapeString(in.Description))
+		}
+		b.WriteString("<ul>\n")
+		fmt.Fprintf(&b, "<li><strong>Type:</strong> <pre><code>%s</code></pre></li>\n", html.EscapeString(in.Type))
+		if !in.Required {
+			fmt.Fprintf(&b, "<li><strong>Default:</strong> <code>%s</code></li>\n", html.EscapeString(defaultText(in)))
+		}
+		fmt.Fprintf(&b, "<li><strong>Required:</strong> %s</li>\n", yesNo(in.Required))
+		fmt.Fprintf(&b, "<li><strong>Sensitive:</strong> %s</li>\n", yesNo(in.Sensitive))
+		b.WriteString("</ul>\n")
+
+		if len(in.Rules) > 0 {
+			b.WriteString("<p><strong>Validation:</strong></p>\n<ul>\n")
+			for _, rule := range in.Rules {
+				description := markdownCode(html.EscapeString(rule.Description))
+				if !rule.Translated {
+					description = "<code>" + html.EscapeString(oneLine(rule.Description)) + "</code> (not translated)"
+				}
+				fmt.Fprintf(&b, "<li>%s: %s</li>\n", description, html.EscapeString(quoteMessage(rule.ErrorMessage)))
+			}
+			b.WriteString("</ul>\n")
+		}
+	}
+	return b.String()
+}
+
+// Inject replaces the text between BeginMarker and EndMarker in document with
+// content, keeping the markers.
+func Inject(document, content string) (string, error) {
+	begin := strings.Index(document, BeginMarker)
+	if begin < 0 {
+		return "", fmt.Errorf("marker %s not found", BeginMarker)
+	}
+	begin += len(BeginMarker)
+	end := strings.Index(document[begin:], EndMarker)
+	if end < 0 {
+		return "", fmt.Errorf("marker %s not found after %s", EndMarker, BeginMarker)
+	}
+	end += begin
+	return document[:begin] + "\n" + strings.TrimRight(content, "\n") + "\n" + document[end:], nil
+}
+
+// defaultText renders the default of an input, hiding sensitive values.
+func defaultText(in Input) string {
+	switch {
+	case in.Required:
+		return "n/a"
+	case in.Sensitive:
+		return "(sensitive)"
+	}
+	return in.Default
+}
+
+// dedent removes from the continuation lines of a type expression the
+// indentation of the variable block, which the closing line holds.
+func dedent(expr string) string {
+	lines := strings.Split(expr, "\n")
+	if len(lines) == 1 {
+		return expr
+	}
+	last := lines[len(lines)-1]
+	indent := last[:len(last)-len(strings.TrimLeft(last, " \t"))]
+	for i := 1; i < len(lines); i++ {
+		lines[i] = strings.TrimPrefix(lines[i], indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func encode(v interface{}) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(encoded)
+}
+
+// oneLine joins the lines of an expression, for table cells. Attributes of
+// a multi-line object type are separated with commas.
+func oneLine(s string) string {
+	var b strings.Builder
+	for i, line := range strings.Split(s, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			continue
+		}
+		if i > 0 && b.Len() > 0 {
+			prev := b.String()[b.Len()-1]
+			switch {
+			case strings.ContainsRune("{([", rune(prev)) || strings.ContainsRune("})]", rune(line[0])):
+			case prev == ',':
+				b.WriteString(" ")
+			default:
+				b.WriteString(", ")
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+func tableDefault(in Input) string {
+	if in.Required {
+		return defaultText(in)
+	}
+	return tableCode(defaultText(in))
+}
+
+// tableCode renders s as inline code in a Markdown table cell, where a pipe
+// would end the cell.
+func tableCode(s string) string {
+	return "`" + strings.ReplaceAll(s, "|", "\\|") + "`"
+}
+
+// markdownCode turns the `code` spans of a description into HTML.
+func markdownCode(s string) string {
+	parts := strings.Split(s, "`")
+	var b strings.Builder
+	for i, part := range parts {
+		if i%2 == 1 && i < len(parts)-1 {
+			b.WriteString("<code>" + part + "</code>")
+			continue
+		}
+		if i%2 == 1 {
+			b.WriteString("`")
+		}
+		b.WriteString(part)
+	}
+	return b.String()
+}
+
+func quoteMessage(message string) string {
+	return "“" + strings.TrimSpace(message) + "”"
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,125 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func inputs(t *testing.T) []Input {
+	t.Helper()
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "zones" {
+  type = list(object({
+    name = string
+    cidr = optional(string)
+  }))
+  default = [{ name = "a" }]
+
+  validation {
+    condition     = length(var.zones) <= 3
+    error_message = "At most three zones."
+  }
+}
+
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = var.name == "x" ? true : startswith(var.name, "y")
+    error_message = "Odd name."
+  }
+
+  validation {
+    condition     = length(var.name) >= 3
+    error_message = "At least three characters."
+  }
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+  default   = "hunter2"
+}
+`)
+	require.NoError(t, err)
+	return Inputs(c.Variables(), c.Validations(), c.Untranslatable())
+}
+
+func TestMarkdown(t *testing.T) {
+	want := "## Inputs\n" +
+		"\n" +
+		"| Name | Type | Default | Required |\n" +
+		"|------|------|---------|:--------:|\n" +
+		"| [name](#input_name) | `string` | n/a | yes |\n" +
+		"| [password](#input_password) | `string` | `(sensitive)` | no |\n" +
+		"| [zones](#input_zones) | `list(object({name = string, cidr = optional(string)}))` | `[{\"name\":\"a\"}]` | no |\n" +
+		"\n" +
+		"### <a name=\"input_name\"></a> `name`\n" +
+		"\n" +
+		"Resource name\n" +
+		"\n" +
+		"- **Type:** `string`\n" +
+		"- **Required:** yes\n" +
+		"- **Sensitive:** no\n" +
+		"\n" +
+		"**Validation:**\n" +
+		"\n" +
+		"- `var.name == \"x\" ? true : startswith(var.name, \"y\")` (not translated): “Odd name.”\n" +
+		"- length of `name` is at least 3: “At least three characters.”\n" +
+		"\n" +
+		"### <a name=\"input_password\"></a> `password`\n" +
+		"\n" +
+		"- **Type:** `string`\n" +
+		"- **Default:** `(sensitive)`\n" +
+		"- **Required:** no\n" +
+		"- **Sensitive:** yes\n" +
+		"\n" +
+		"### <a name=\"input_zones\"></a> `zones`\n" +
+		"\n" +
+		"- **Type:**\n" +
+		"\n" +
+		"  ```hcl\n" +
+		"  list(object({\n" +
+		"    name = string\n" +
+		"    cidr = optional(string)\n" +
+		"  }))\n" +
+		"  ```\n" +
+		"\n" +
+		"- **Default:** `[{\"name\":\"a\"}]`\n" +
+		"- **Required:** no\n" +
+		"- **Sensitive:** no\n" +
+		"\n" +
+		"**Validation:**\n" +
+		"\n" +
+		"- length of `zones` is at most 3: “At most three zones.”\n"
+	assert.Equal(t, want, Markdown(inputs(t)))
+}
+
+func TestHTML(t *testing.T) {
+	output := HTML(inputs(t))
+	assert.Contains(t, output, `<tr><td><a href="#input_name">name</a></td><td><code>string</code></td><td>n/a</td><td>yes</td></tr>`)
+	assert.Contains(t, output, `<h3 id="input_zones"><code>zones</code></h3>`)
+	assert.Contains(t, output, "<li>length of <code>zones</code> is at most 3: “At most three zones.”</li>")
+	assert.Contains(t, output, "<li><code>var.name == &#34;x&#34; ? true : startswith(var.name, &#34;y&#34;)</code> (not translated): “Odd name.”</li>")
+}
+
+func TestInject(t *testing.T) {
+	document := "# Module\n\n" + BeginMarker + "\nold\n" + EndMarker + "\n\nFooter\n"
+	updated, err := Inject(document, "## Inputs\n\nnew\n")
+	require.NoError(t, err)
+	assert.Equal(t, "# Module\n\n"+BeginMarker+"\n## Inputs\n\nnew\n"+EndMarker+"\n\nFooter\n", updated)
+
+	again, err := Inject(updated, "## Inputs\n\nnew\n")
+	require.NoError(t, err)
+	assert.Equal(t, updated, again)
+
+	_, err = Inject("# Module\n", "x")
+	assert.Error(t, err)
+	_, err = Inject(BeginMarker+"\n", "x")
+	assert.Error(t, err)
+}
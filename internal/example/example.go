@@ -0,0 +1,390 @@
+// Package example generates example inputs for a Terraform module that
+// satisfy the schemas of its converted variables.
+package example
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Placeholder is the value of sensitive strings, to be replaced by the user.
+const Placeholder = "REPLACE_ME"
+
+// Assignment is the example value of a variable.
+type Assignment struct {
+	Name        string
+	Description string
+	Value       interface{}
+}
+
+// identifierPattern matches object keys that need no quotes in HCL.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Generate returns an example value for every variable, in declaration
+// order: the default when the variable has one, or else a value built from
+// its schema.
+func Generate(variables []converter.Variable) []Assignment {
+	assignments := make([]Assignment, 0, len(variables))
+	for _, v := range variables {
+		a := Assignment{Name: v.Name, Description: strings.TrimSpace(v.Schema.Description)}
+		if v.Required {
+			a.Value = Value(v.Schema)
+		} else {
+			a.Value = v.Schema.Default
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments
+}
+
+// Value builds a value satisfying a schema: its first enum value, or else
+// the smallest value meeting its length, range and pattern keywords. Strings
+// of sensitive variables are Placeholder unless a pattern or format requires
+// otherwise.
+func Value(schema *jsonschema.Schema) interface{} {
+	return value(schema, schema.Sensitive != nil && *schema.Sensitive, 0)
+}
+
+// value builds the variant-th value of a schema. Variants differ where the
+// schema allows, so that the items of a set are unique.
+func value(schema *jsonschema.Schema, sensitive bool, variant int) interface{} {
+	schema = resolve(schema)
+	if schema.Not != nil && someValue(schema) == nil {
+		// Try the following variants until one avoids the negated schema.
+		allowed := *schema
+		allowed.Not = nil
+		for i := 0; i < 16; i++ {
+			if v := value(&allowed, sensitive, variant+i); !matches(v, schema.Not) {
+				return v
+			}
+		}
+		return value(&allowed, sensitive, variant)
+	}
+
+	if len(schema.Enum) > 0 {
+		return schema.Enum[variant%len(schema.Enum)]
+	}
+
+	switch schema.BaseType() {
+	case "string":
+		return stringValue(schema, sensitive, variant)
+	case "number", "integer":
+		return numberValue(schema, variant)
+	case "boolean":
+		return variant%2 == 1
+	case "array":
+		return arrayValue(schema, sensitive, variant)
+	case "object":
+		return objectValue(schema, sensitive, variant)
+	}
+	if schema.AcceptsNull() {
+		return nil
+	}
+	return "example"
+}
+
+func stringValue(schema *jsonschema.Schema, sensitive bool, variant int) string {
+	minLength := 0
+	if schema.MinLength != nil {
+		minLength = *schema.MinLength
+	}
+	switch schema.Format {
+	case "date-time":
+		return fmt.Sprintf("2024-01-%02dT00:00:00Z", variant%28+1)
+	case "ipv4":
+		return fmt.Sprintf("192.0.2.%d", variant%254+1)
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", variant+1)
+	}
+	if schema.Pattern != "" {
+		if s, ok := matchingString(schema.Pattern, minLength); ok {
+			return s
+		}
+	}
+
+	s := "example"
+	if sensitive {
+		s = Placeholder
+	}
+	if variant > 0 {
+		s += "-" + strconv.Itoa(variant)
+	}
+	if len(s) < minLength {
+		s += strings.Repeat("x", minLength-len(s))
+	}
+	if schema.MaxLength != nil && len(s) > *schema.MaxLength {
+		s = s[:*schema.MaxLength]
+	}
+	return s
+}
+
+// numberValue picks the lowest allowed number, or zero when it is allowed,
+// stepping by the multipleOf for variants.
+func numberValue(schema *jsonschema.Schema, variant int) float64 {
+	integer := schema.BaseType() == "integer"
+	step := 1.0
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		step = *schema.MultipleOf
+	}
+
+	n := 0.0
+	switch {
+	case schema.Minimum != nil:
+		n = *schema.Minimum
+	case schema.ExclusiveMinimum != nil:
+		n = *schema.ExclusiveMinimum + step
+		if upper := schema.ExclusiveMaximum; upper != nil && n >= *upper && !integer {
+			n = (*schema.ExclusiveMinimum + *upper) / 2
+		}
+	case schema.Maximum != nil && *schema.Maximum < 0:
+		n = *schema.Maximum
+	case schema.ExclusiveMaximum != nil && *schema.ExclusiveMaximum <= 0:
+		n = *schema.ExclusiveMaximum - step
+	}
+	if integer {
+		n = math.Ceil(n)
+	}
+	if schema.MultipleOf != nil && *schema.MultipleOf > 0 {
+		n = math.Ceil(n/step) * step
+		if schema.ExclusiveMinimum != nil && n <= *schema.ExclusiveMinimum {
+			n += step
+		}
+	}
+	return n + float64(variant)*step
+}
+
+func arrayValue(schema *jsonschema.Schema, sensitive bool, variant int) []interface{} {
+	if tuple := schema.TupleItems(); tuple != nil {
+		items := make([]interface{}, len(tuple))
+		for i, item := range tuple {
+			items[i] = value(item, sensitive, variant)
+		}
+		return items
+	}
+
+	count := 1
+	if schema.MinItems != nil && *schema.MinItems > count {
+		count = *schema.MinItems
+	}
+	if schema.MaxItems != nil && *schema.MaxItems < count {
+		count = *schema.MaxItems
+	}
+	elem := schema.ElementSchema()
+	items := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		switch {
+		case i == 0 && schema.Contains != nil && elem != nil:
+			items = append(items, value(merge(elem, schema.Contains), sensitive, variant))
+		case i == 0 && schema.Contains != nil:
+			items = append(items, value(schema.Contains, sensitive, variant))
+		case elem != nil:
+			items = append(items, value(elem, sensitive, variant+i))
+		default:
+			items = append(items, "example")
+		}
+	}
+	return items
+}
+
+// objectValue fills in every attribute of an object. A map gets the entries
+// that validations name or require, or else a single entry, and as many more
+// as its minProperties asks for.
+func objectValue(schema *jsonschema.Schema, sensitive bool, variant int) map[string]interface{} {
+	object := map[string]interface{}{}
+	for name, prop := range schema.Properties {
+		object[name] = value(prop, sensitive, variant)
+	}
+
+	values := schema.MapValues()
+	if values == nil {
+		return object
+	}
+	if schema.Required != nil {
+		for i, name := range *schema.Required {
+			if _, exists := object[name]; !exists {
+				object[name] = value(values, sensitive, variant+i)
+			}
+		}
+	}
+	if some := someValue(schema); some != nil {
+		values = merge(values, some)
+	}
+	count := 1
+	if schema.MinProperties != nil && *schema.MinProperties > count {
+		count = *schema.MinProperties
+	}
+	for i := 0; len(object) < count && i < count+len(object); i++ {
+		key := "key"
+		if schema.PropertyNames != nil {
+			if s, ok := value(schema.PropertyNames, false, i).(string); ok {
+				key = s
+			}
+		} else if i > 0 {
+			key += strconv.Itoa(i)
+		}
+		if _, exists := object[key]; !exists {
+			object[key] = value(values, sensitive, variant+i)
+		}
+	}
+	return object
+}
+
+// Check returns the validations that the example values do not satisfy,
+// such as conditions over several variables.
+func Check(assignments []Assignment, validations []constraint.Validation) ([]constraint.Validation, error) {
+	object := map[string]interface{}{}
+	for _, a := range assignments {
+		object[a.Name] = a.Value
+	}
+	encoded, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	ty, err := ctyjson.ImpliedType(encoded)
+	if err != nil {
+		return nil, err
+	}
+	inputs, err := ctyjson.Unmarshal(encoded, ty)
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []constraint.Validation
+	for _, v := range validations {
+		if ok, err := constraint.Evaluate(v.Constraint, inputs); err == nil && !ok {
+			failed = append(failed, v)
+		}
+	}
+	return failed, nil
+}
+
+// JSON renders the assignments as a terraform.tfvars.json object, keeping
+// the declaration order of the variables.
+func JSON(assignments []Assignment) (string, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, a := range assignments {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		name, _ := json.Marshal(a.Name)
+		encoded, err := json.MarshalIndent(a.Value, "  ", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode the value of %s: %w", a.Name, err)
+		}
+		fmt.Fprintf(&b, "\n  %s: %s", name, encoded)
+	}
+	if len(assignments) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// HCL renders the assignments as a terraform.tfvars file, with the variable
+// descriptions as comments.
+func HCL(assignments []Assignment) string {
+	var b strings.Builder
+	for i, a := range assignments {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, line := range strings.Split(a.Description, "\n") {
+			if line = strings.TrimRight(line, " \t"); line != "" {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&b, "%s = %s\n", a.Name, hclValue(a.Value, ""))
+	}
+	return b.String()
+}
+
+// hclValue renders a JSON value as an HCL expression, indenting the lines of
+// nested objects past indent.
+func hclValue(v interface{}, indent string) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return render.Number(v)
+	case string:
+		return hclString(v)
+	case []interface{}:
+		items := make([]string, len(v))
+		multiline := false
+		for i, item := range v {
+			items[i] = hclValue(item, indent+"  ")
+			multiline = multiline || strings.Contains(items[i], "\n")
+		}
+		if !multiline {
+			return "[" + strings.Join(items, ", ") + "]"
+		}
+		return "[\n" + indent + "  " + strings.Join(items, ",\n"+indent+"  ") + ",\n" + indent + "]"
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return "{}"
+		}
+		keys := render.SortedKeys(v)
+		names := make([]string, len(keys))
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			names[i] = key
+			if !identifierPattern.MatchString(key) {
+				names[i] = hclString(key)
+			}
+			values[i] = hclValue(v[key], indent+"  ")
+		}
+		// As terraform fmt does, align the equals signs of consecutive
+		// attributes, up to and including one with a multi-line value.
+		widths := make([]int, len(keys))
+		for start := 0; start < len(keys); {
+			end, width := start, 0
+			for ; end < len(keys); end++ {
+				if len(names[end]) > width {
+					width = len(names[end])
+				}
+				if strings.Contains(values[end], "\n") {
+					end++
+					break
+				}
+			}
+			for i := start; i < end; i++ {
+				widths[i] = width
+			}
+			start = end
+		}
+		var b strings.Builder
+		b.WriteString("{\n")
+		for i := range keys {
+			fmt.Fprintf(&b, "%s  %-*s = %s\n", indent, widths[i], names[i], values[i])
+		}
+		b.WriteString(indent + "}")
+		return b.String()
+	}
+	encoded, _ := json.Marshal(v)
+	return string(encoded)
+}
+
+// hclString quotes a string for HCL, escaping template sequences.
+func hclString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	quoted := strings.TrimSuffix(b.String(), "\n")
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
@@ -0,0 +1,171 @@
+package example
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchingString(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		minLength int
+		want      string
+	}{
+		{`^[a-z][a-z0-9-]*$`, 0, "a"},
+		{`^[a-z][a-z0-9-]*$`, 5, "aaaaa"},
+		{`^(dev|prod)-[0-9]{2}$`, 0, "dev-00"},
+		{`^sg-[0-9a-f]{8,17}$`, 0, "sg-aaaaaaaa"},
+		{`^arn:aws:iam::\d{12}:role/.+$`, 0, "arn:aws:iam::000000000000:role/x"},
+		{`^[^@\s]+@[^@\s]+\.[a-z]{2,}$`, 0, "a@a.aa"},
+		{`https://`, 12, "https://xxxx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, ok := matchingString(tt.pattern, tt.minLength)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+			assert.Regexp(t, regexp.MustCompile(tt.pattern), got)
+		})
+	}
+
+	_, ok := matchingString(`(?=a)`, 0)
+	assert.False(t, ok)
+}
+
+func TestValue(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	floatPtr := func(v float64) *float64 { return &v }
+	boolPtr := func(v bool) *bool { return &v }
+
+	tests := []struct {
+		name   string
+		schema *jsonschema.Schema
+		want   interface{}
+	}{
+		{"enum", &jsonschema.Schema{Type: "string", Enum: []interface{}{"dev", "prod"}}, "dev"},
+		{"min length", &jsonschema.Schema{Type: "string", MinLength: intPtr(10)}, "examplexxx"},
+		{"max length", &jsonschema.Schema{Type: "string", MaxLength: intPtr(3)}, "exa"},
+		{"format", &jsonschema.Schema{Type: "string", Format: "ipv4"}, "192.0.2.1"},
+		{"sensitive", &jsonschema.Schema{Type: "string", Sensitive: boolPtr(true)}, Placeholder},
+		{"minimum", &jsonschema.Schema{Type: "number", Minimum: floatPtr(3)}, 3.0},
+		{"exclusive range", &jsonschema.Schema{Type: "number", ExclusiveMinimum: floatPtr(0), ExclusiveMaximum: floatPtr(1)}, 0.5},
+		{"integer", &jsonschema.Schema{Type: "integer", ExclusiveMinimum: floatPtr(2.5)}, 4.0},
+		{"multiple", &jsonschema.Schema{Type: "number", Minimum: floatPtr(5), MultipleOf: floatPtr(4)}, 8.0},
+		{"odd", &jsonschema.Schema{Type: "integer", Not: &jsonschema.Schema{MultipleOf: floatPtr(2)}}, 1.0},
+		{
+			"unique items",
+			&jsonschema.Schema{Type: "array", Items: &jsonschema.Schema{Type: "string"}, MinItems: intPtr(2), UniqueItems: boolPtr(true)},
+			[]interface{}{"example", "example-1"},
+		},
+		{
+			"contains",
+			&jsonschema.Schema{Type: "array", Items: &jsonschema.Schema{Type: "number"}, Contains: &jsonschema.Schema{Minimum: floatPtr(443), Maximum: floatPtr(443)}},
+			[]interface{}{443.0},
+		},
+		{
+			"map with required keys",
+			&jsonschema.Schema{Type: "object", AdditionalProperties: &jsonschema.Schema{Type: "string"}, Required: &[]string{"owner"}},
+			map[string]interface{}{"owner": "example"},
+		},
+		{
+			"conditional",
+			&jsonschema.Schema{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"tier":     {Type: "string"},
+					"replicas": {Type: "number"},
+				},
+				If:   &jsonschema.Schema{Properties: map[string]*jsonschema.Schema{"tier": {Enum: []interface{}{"premium"}}}},
+				Then: &jsonschema.Schema{Properties: map[string]*jsonschema.Schema{"replicas": {Minimum: floatPtr(3)}}},
+			},
+			map[string]interface{}{"tier": "premium", "replicas": 3.0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Value(tt.schema))
+		})
+	}
+}
+
+func TestOutputs(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = can(regex("^[a-z]+$", var.name))
+    error_message = "Lower-case letters."
+  }
+}
+
+variable "replicas" {
+  type    = number
+  default = 2
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    subnets = list(string)
+  })
+}
+
+variable "token" {
+  type      = string
+  sensitive = true
+}
+`)
+	require.NoError(t, err)
+	assignments := Generate(c.Variables())
+
+	failed, err := Check(assignments, c.Validations())
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+
+	output, err := JSON(assignments)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "name": "a",
+  "replicas": 2,
+  "network": {
+    "cidr": "example",
+    "subnets": [
+      "example"
+    ]
+  },
+  "token": "REPLACE_ME"
+}
+`, output)
+
+	tfvars := HCL(assignments)
+	assert.Equal(t, `# Resource name
+name = "a"
+
+replicas = 2
+
+network = {
+  cidr    = "example"
+  subnets = ["example"]
+}
+
+token = "REPLACE_ME"
+`, tfvars)
+	_, diags := hclsyntax.ParseConfig([]byte(tfvars), "terraform.tfvars", hcl.InitialPos)
+	assert.False(t, diags.HasErrors(), diags.Error())
+}
+
+func TestHCLValue(t *testing.T) {
+	assert.Equal(t, `"$${var} %%{if}"`, hclValue("${var} %{if}", ""))
+	assert.Equal(t, "{\n  \"a b\" = 1\n  c     = null\n}", hclValue(map[string]interface{}{"a b": 1.0, "c": nil}, ""))
+	assert.Equal(t, "[]", hclValue([]interface{}{}, ""))
+}
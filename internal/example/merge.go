@@ -0,0 +1,168 @@
+package example
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+)
+
+// resolve folds the allOf subschemas of a schema into it, and for an
+// if/then, the condition together with its consequence, so that values built
+// from the result take the "then" branch.
+func resolve(schema *jsonschema.Schema) *jsonschema.Schema {
+	if len(schema.AllOf) == 0 && (schema.If == nil || schema.Then == nil) {
+		return schema
+	}
+	resolved := schema.Clone()
+	resolved.AllOf = nil
+	for _, sub := range schema.AllOf {
+		resolved = merge(resolved, resolve(sub))
+	}
+	if resolved.If != nil && resolved.Then != nil {
+		condition, consequence := resolved.If, resolved.Then
+		resolved.If, resolved.Then, resolved.Else = nil, nil, nil
+		resolved = merge(merge(resolved, condition), consequence)
+	}
+	return resolved
+}
+
+// merge returns a schema whose values satisfy both a and b, as far as the
+// keywords of example values go: properties merge, required attributes add
+// up, bounds tighten and the enum, pattern and format of b win.
+func merge(a, b *jsonschema.Schema) *jsonschema.Schema {
+	if b == nil {
+		return a
+	}
+	m := a.Clone()
+	for name, prop := range b.Properties {
+		if m.Properties == nil {
+			m.Properties = map[string]*jsonschema.Schema{}
+		}
+		if existing, ok := m.Properties[name]; ok {
+			m.Properties[name] = merge(existing, prop)
+		} else {
+			m.Properties[name] = prop
+		}
+	}
+	if b.Required != nil {
+		required := append([]string{}, *b.Required...)
+		if m.Required != nil {
+			required = append(append([]string{}, *m.Required...), required...)
+		}
+		m.Required = &required
+	}
+	if items, ok := b.Items.(*jsonschema.Schema); ok {
+		if existing, ok := m.Items.(*jsonschema.Schema); ok {
+			m.Items = merge(existing, items)
+		} else {
+			m.Items = items
+		}
+	}
+	if len(b.Enum) > 0 {
+		m.Enum = b.Enum
+	}
+	if b.Pattern != "" {
+		m.Pattern = b.Pattern
+	}
+	if b.Format != "" {
+		m.Format = b.Format
+	}
+	if b.MultipleOf != nil {
+		m.MultipleOf = b.MultipleOf
+	}
+	if b.Contains != nil {
+		m.Contains = b.Contains
+	}
+	if b.Not != nil {
+		m.Not = b.Not
+	}
+	m.AllOf = append(m.AllOf, b.AllOf...)
+	if b.If != nil {
+		m.AllOf = append(m.AllOf, &jsonschema.Schema{If: b.If, Then: b.Then, Else: b.Else})
+	}
+	m.MinLength, m.MinItems, m.MinProperties = maxInt(m.MinLength, b.MinLength), maxInt(m.MinItems, b.MinItems), maxInt(m.MinProperties, b.MinProperties)
+	m.MaxLength, m.MaxItems, m.MaxProperties = minInt(m.MaxLength, b.MaxLength), minInt(m.MaxItems, b.MaxItems), minInt(m.MaxProperties, b.MaxProperties)
+	m.Minimum, m.ExclusiveMinimum = maxFloat(m.Minimum, b.Minimum), maxFloat(m.ExclusiveMinimum, b.ExclusiveMinimum)
+	m.Maximum, m.ExclusiveMaximum = minFloat(m.Maximum, b.Maximum), minFloat(m.ExclusiveMaximum, b.ExclusiveMaximum)
+	return m
+}
+
+// someValue returns the schema that one value of a map must satisfy, from
+// the "not every value fails" form of anytrue, or nil.
+func someValue(schema *jsonschema.Schema) *jsonschema.Schema {
+	if schema.Not == nil {
+		return nil
+	}
+	if values, ok := schema.Not.AdditionalProperties.(*jsonschema.Schema); ok && values.Not != nil {
+		return values.Not
+	}
+	return nil
+}
+
+// matches reports whether a scalar value satisfies the scalar keywords of a
+// schema. Keywords of other types hold.
+func matches(v interface{}, schema *jsonschema.Schema) bool {
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			found = found || reflect.DeepEqual(v, allowed)
+		}
+		if !found {
+			return false
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(v)
+		if schema.MinLength != nil && n < *schema.MinLength || schema.MaxLength != nil && n > *schema.MaxLength {
+			return false
+		}
+		if schema.Pattern != "" {
+			re, err := regexp.Compile(schema.Pattern)
+			return err == nil && re.MatchString(v)
+		}
+	case float64:
+		if schema.MultipleOf != nil && *schema.MultipleOf != 0 {
+			q := v / *schema.MultipleOf
+			if q != math.Trunc(q) {
+				return false
+			}
+		}
+		return (schema.Minimum == nil || v >= *schema.Minimum) &&
+			(schema.Maximum == nil || v <= *schema.Maximum) &&
+			(schema.ExclusiveMinimum == nil || v > *schema.ExclusiveMinimum) &&
+			(schema.ExclusiveMaximum == nil || v < *schema.ExclusiveMaximum)
+	}
+	return true
+}
+
+func maxInt(a, b *int) *int {
+	if a == nil || b != nil && *b > *a {
+		return b
+	}
+	return a
+}
+
+func minInt(a, b *int) *int {
+	if a == nil || b != nil && *b < *a {
+		return b
+	}
+	return a
+}
+
+func maxFloat(a, b *float64) *float64 {
+	if a == nil || b != nil && *b > *a {
+		return b
+	}
+	return a
+}
+
+func minFloat(a, b *float64) *float64 {
+	if a == nil || b != nil && *b < *a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,136 @@
+package example
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// preferred are the characters picked from a character class when it has
+// them, so that generated strings read naturally.
+var preferred = []rune("abcxyz0123456789ABCXYZ-_.")
+
+// matchingString returns a short string matching pattern with at least
+// minLength characters, or false when the pattern cannot be parsed or no
+// such string is found. Like Terraform's regex, the pattern matches anywhere
+// in the string unless anchored.
+func matchingString(pattern string, minLength int) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", false
+	}
+
+	g := &regexGenerator{}
+	s := g.generate(re)
+	if n := len([]rune(s)); n < minLength {
+		// Spend the missing characters on the first repetitions.
+		g.extra = minLength - n
+		s = g.generate(re)
+	}
+	if n := len([]rune(s)); n < minLength && !endAnchored(re) {
+		s += strings.Repeat("x", minLength-n)
+	}
+	return s, compiled.MatchString(s)
+}
+
+// regexGenerator builds a string matching a regular expression, taking the
+// first alternative and the fewest repetitions unless extra characters are
+// wanted.
+type regexGenerator struct {
+	extra int
+}
+
+func (g *regexGenerator) generate(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune)
+	case syntax.OpCharClass:
+		return string(pickRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return "x"
+	case syntax.OpCapture:
+		return g.generate(re.Sub[0])
+	case syntax.OpConcat:
+		var b strings.Builder
+		for _, sub := range re.Sub {
+			b.WriteString(g.generate(sub))
+		}
+		return b.String()
+	case syntax.OpAlternate:
+		return g.generate(re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			min, max = 0, -1
+		case syntax.OpPlus:
+			min, max = 1, -1
+		case syntax.OpQuest:
+			min, max = 0, 1
+		}
+		var b strings.Builder
+		for i := 0; i < min; i++ {
+			b.WriteString(g.generate(re.Sub[0]))
+		}
+		for i := min; g.extra > 0 && (max < 0 || i < max); i++ {
+			s := g.generate(re.Sub[0])
+			if s == "" {
+				break
+			}
+			b.WriteString(s)
+			g.extra -= len([]rune(s))
+		}
+		return b.String()
+	}
+	// Empty matches, anchors and word boundaries.
+	return ""
+}
+
+// pickRune picks a printable rune from the ranges of a character class.
+func pickRune(ranges []rune) rune {
+	for _, r := range preferred {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= r && r <= ranges[i+1] {
+				return r
+			}
+		}
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		for r := ranges[i]; r <= ranges[i+1] && r-ranges[i] < 256; r++ {
+			if unicode.IsPrint(r) && !unicode.IsSpace(r) {
+				return r
+			}
+		}
+	}
+	if len(ranges) > 0 {
+		return ranges[0]
+	}
+	return 'x'
+}
+
+// endAnchored reports whether a pattern only matches up to the end of the
+// text, so that characters cannot be appended to a match.
+func endAnchored(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEndText, syntax.OpEndLine:
+		return true
+	case syntax.OpConcat:
+		return len(re.Sub) > 0 && endAnchored(re.Sub[len(re.Sub)-1])
+	case syntax.OpCapture:
+		return endAnchored(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !endAnchored(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
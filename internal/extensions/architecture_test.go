@@ -3,6 +3,7 @@ package extensions
 import (
 	"testing"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/alex-tw-lam/tfschema/internal/converter/types"
 	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
 	"github.com/alex-tw-lam/tfschema/internal/validation"
@@ -31,8 +32,8 @@ func TestExtensionRegistryBasicFunctionality(t *testing.T) {
 	assert.Equal(t, mockConverter, retrievedConverter)
 
 	// Test validation rule registration
-	mockRuleParser := func(expr hcl.Expression, varName string) (validation.Rule, []string, error) {
-		return &mockValidationRule{}, []string{}, nil
+	mockRuleParser := func(expr hcl.Expression, varName string) (validation.Rule, validation.Path, error) {
+		return &mockValidationRule{}, validation.Path{}, nil
 	}
 	registry.RegisterValidationRule(mockRuleParser)
 
@@ -87,8 +88,8 @@ func TestLegacyBridge(t *testing.T) {
 	assert.Equal(t, mockConverter, retrievedConverter)
 
 	// Test legacy validation rule registration
-	mockRuleParser := func(expr hcl.Expression, varName string) (validation.Rule, []string, error) {
-		return &mockValidationRule{}, []string{}, nil
+	mockRuleParser := func(expr hcl.Expression, varName string) (validation.Rule, validation.Path, error) {
+		return &mockValidationRule{}, validation.Path{}, nil
 	}
 	RegisterLegacyValidationRule(mockRuleParser)
 
@@ -134,9 +135,8 @@ func (m *mockTypeConverter) Convert(expr hcl.Expression) (*jsonschema.Schema, er
 
 type mockValidationRule struct{}
 
-func (m *mockValidationRule) Apply(schema *jsonschema.Schema) error {
-	schema.Description = "mock validation applied"
-	return nil
+func (m *mockValidationRule) Constraint() constraint.Constraint {
+	return constraint.Atom{Predicate: constraint.Format{Format: "mock"}}
 }
 
 type mockAttributeApplier struct {
@@ -182,8 +182,8 @@ func (m *mockExtension) Info() ExtensionInfo {
 
 func (m *mockExtension) Register(registry *ExtensionRegistry) error {
 	// Register some components
-	registry.RegisterValidationRule(func(expr hcl.Expression, varName string) (validation.Rule, []string, error) {
-		return &mockValidationRule{}, []string{}, nil
+	registry.RegisterValidationRule(func(expr hcl.Expression, varName string) (validation.Rule, validation.Path, error) {
+		return &mockValidationRule{}, validation.Path{}, nil
 	})
 
 	registry.RegisterAttributeApplier(&mockAttributeApplier{name: "mock_ext"})
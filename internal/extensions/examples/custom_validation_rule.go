@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/alex-tw-lam/tfschema/internal/extensions"
-	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
 	"github.com/alex-tw-lam/tfschema/internal/validation"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -25,18 +25,17 @@ type ContainsSubstringRule struct {
 	Pattern   string // JSON Schema pattern equivalent
 }
 
-// Apply applies the contains substring validation rule to a JSON schema
-func (r *ContainsSubstringRule) Apply(schema *jsonschema.Schema) error {
+// Constraint lowers the contains substring validation rule into the constraint
+// IR, from which the JSON Schema pattern and the other output formats are
+// generated.
+func (r *ContainsSubstringRule) Constraint() constraint.Constraint {
 	// Convert substring requirement to a regex pattern
 	// This is a simple example - in practice, you'd want more robust pattern generation
-	if r.Pattern != "" {
-		schema.Pattern = r.Pattern
-	}
-	return nil
+	return constraint.Atom{Predicate: constraint.Pattern{Pattern: r.Pattern}}
 }
 
 // parseContainsSubstringRule parses expressions like: contains(var.my_string, "required_text")
-func parseContainsSubstringRule(expr hcl.Expression, varName string) (validation.Rule, []string, error) {
+func parseContainsSubstringRule(expr hcl.Expression, varName string) (validation.Rule, validation.Path, error) {
 	call, ok := expr.(*hclsyntax.FunctionCallExpr)
 	if !ok || call.Name != "contains" {
 		return nil, nil, nil // Not our rule
@@ -101,22 +100,22 @@ func parseContainsSubstringRule(expr hcl.Expression, varName string) (validation
 // pathExpressionHandler is a minimal implementation for this example
 type pathExpressionHandler struct{}
 
-func (p *pathExpressionHandler) ExtractPathFromExpression(expr hcl.Expression, varName string) ([]string, error) {
+func (p *pathExpressionHandler) ExtractPathFromExpression(expr hcl.Expression, varName string) (validation.Path, error) {
 	// This is a simplified implementation for the example
 	// In practice, you'd use the full path expression handler from the validation package
 
 	if scopeTraversal, ok := expr.(*hclsyntax.ScopeTraversalExpr); ok {
 		if len(scopeTraversal.Traversal) >= 2 {
 			// Extract path segments after "var.variable_name"
-			var path []string
+			var path validation.Path
 			for i := 2; i < len(scopeTraversal.Traversal); i++ {
 				if attr, ok := scopeTraversal.Traversal[i].(hcl.TraverseAttr); ok {
-					path = append(path, attr.Name)
+					path = append(path, constraint.Attr(attr.Name))
 				}
 			}
 			return path, nil
 		}
 	}
 
-	return []string{}, nil
+	return validation.Path{}, nil
 }
@@ -0,0 +1,339 @@
+// Package gostruct generates Go structs for the inputs of a Terraform module
+// from its converted variables, with a Validate method implementing the
+// translated validation blocks.
+package gostruct
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/naming"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+)
+
+// kind is the shape of a generated Go type.
+type kind int
+
+const (
+	kindString kind = iota
+	kindNumber
+	kindBool
+	kindAny
+	kindSlice
+	kindMap
+	kindStruct
+	kindTuple
+)
+
+// goType is a Go type generated for a schema.
+type goType struct {
+	kind   kind
+	schema *jsonschema.Schema
+	name   string    // struct and tuple types
+	elem   *goType   // slice and map types
+	fields []*field  // struct types
+	items  []*goType // tuple types
+}
+
+// field is a field of a generated struct.
+type field struct {
+	name     string // Go name
+	jsonName string
+	typ      *goType
+	pointer  bool
+	optional bool
+	doc      string
+}
+
+// expr returns the Go expression of the type.
+func (t *goType) expr() string {
+	switch t.kind {
+	case kindString:
+		return "string"
+	case kindNumber:
+		return "json.Number"
+	case kindBool:
+		return "bool"
+	case kindSlice:
+		return "[]" + t.elem.expr()
+	case kindMap:
+		return "map[string]" + t.elem.expr()
+	case kindStruct, kindTuple:
+		return t.name
+	}
+	return "interface{}"
+}
+
+// fieldByJSON returns the field with the given JSON name, or nil.
+func (t *goType) fieldByJSON(name string) *field {
+	for _, f := range t.fields {
+		if f.jsonName == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// generator accumulates the declarations of a generated file.
+type generator struct {
+	types   []*goType // struct and tuple types, in order of declaration
+	names   map[string]bool
+	imports map[string]bool
+	helpers map[string]bool
+	regexps []string // patterns compiled into package variables
+	counter int      // suffix of the variables of generated closures
+}
+
+// Generate renders a Go file in package pkg declaring a `<Module>Inputs`
+// struct with one field per variable, in declaration order, and a Validate
+// method checking the given validations. Numbers are json.Number so that
+// they keep Terraform's arbitrary precision. Optional attributes and
+// variables with a default are pointers, or nil-able slices and maps, tagged
+// omitempty. Validations that cannot be checked in Go are listed as TODO
+// comments.
+func Generate(pkg, module string, variables []converter.Variable, validations []constraint.Validation) (string, error) {
+	g := &generator{
+		names:   map[string]bool{},
+		imports: map[string]bool{},
+		helpers: map[string]bool{},
+	}
+
+	root := &goType{kind: kindStruct, name: g.typeName(naming.Pascal(module, "Module") + "Inputs")}
+	g.types = append(g.types, root)
+	for _, v := range variables {
+		typ := g.typeOf(v.Schema, naming.Pascal(v.Name, "Var"))
+		root.fields = append(root.fields, g.newField(v.Name, typ, !v.Required, v.Schema.AcceptsNull(), v.Schema.Description))
+	}
+	root.fields = uniqueFieldNames(root.fields)
+
+	validate := g.validateMethod(root, validations)
+	for name := range g.helpers {
+		for _, path := range helperImports[name] {
+			g.imports[path] = true
+		}
+	}
+
+	var body bytes.Buffer
+	for _, t := range g.types {
+		g.writeType(&body, t)
+	}
+	body.WriteString(validate)
+	for _, name := range render.SortedKeys(g.helpers) {
+		body.WriteString(helperSource[name])
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by tfschema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		b.WriteString("import (\n")
+		for _, path := range render.SortedKeys(g.imports) {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+		b.WriteString(")\n\n")
+	}
+	if len(g.regexps) > 0 {
+		b.WriteString("var (\n")
+		for i, pattern := range g.regexps {
+			fmt.Fprintf(&b, "\tpattern%d = regexp.MustCompile(%s)\n", i, quote(pattern))
+		}
+		b.WriteString(")\n\n")
+	}
+	b.Write(body.Bytes())
+
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return string(source), nil
+}
+
+// PackageName derives a Go package name from a module name by joining its
+// lower-case words, e.g. "terraformawsvpc" from "terraform-aws-vpc".
+func PackageName(module string) string {
+	name := strings.Join(naming.Words(module), "")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "inputs" + name
+	}
+	return name
+}
+
+// typeOf returns the Go type of a schema. Struct and tuple types are named
+// after name, the path of the value they describe.
+func (g *generator) typeOf(schema *jsonschema.Schema, name string) *goType {
+	t := &goType{schema: schema}
+	switch schema.BaseType() {
+	case "string":
+		t.kind = kindString
+	case "number", "integer":
+		t.kind = kindNumber
+		g.imports["encoding/json"] = true
+	case "boolean":
+		t.kind = kindBool
+	case "array":
+		if tuple := schema.TupleItems(); tuple != nil {
+			t.kind, t.name = kindTuple, g.typeName(name)
+			g.types = append(g.types, t)
+			for i, item := range tuple {
+				t.items = append(t.items, g.typeOf(item, fmt.Sprintf("%s%d", name, i)))
+			}
+			g.imports["encoding/json"] = true
+			g.imports["fmt"] = true
+			break
+		}
+		t.kind = kindSlice
+		t.elem = &goType{kind: kindAny}
+		if elem := schema.ElementSchema(); elem != nil {
+			t.elem = g.typeOf(elem, name+"Item")
+		}
+	case "object":
+		if values := schema.MapValues(); values != nil {
+			t.kind, t.elem = kindMap, g.typeOf(values, name+"Value")
+			break
+		}
+		if len(schema.Properties) == 0 {
+			t.kind, t.elem = kindMap, &goType{kind: kindAny}
+			break
+		}
+		t.kind, t.name = kindStruct, g.typeName(name)
+		g.types = append(g.types, t)
+		required := map[string]bool{}
+		if schema.Required != nil {
+			for _, attr := range *schema.Required {
+				required[attr] = true
+			}
+		}
+		for _, attr := range render.SortedKeys(schema.Properties) {
+			prop := schema.Properties[attr]
+			attrType := g.typeOf(prop, name+naming.Pascal(attr, "Attr"))
+			t.fields = append(t.fields, g.newField(attr, attrType, !required[attr], prop.AcceptsNull(), prop.Description))
+		}
+		t.fields = uniqueFieldNames(t.fields)
+	default:
+		t.kind = kindAny
+	}
+	return t
+}
+
+// newField returns a struct field for an attribute or variable. Optional and
+// nullable scalars and structs are pointers, so that absence and null are
+// distinct from the zero value.
+func (g *generator) newField(name string, typ *goType, optional, nullable bool, doc string) *field {
+	f := &field{name: naming.Pascal(name, "Field"), jsonName: name, typ: typ, optional: optional, doc: doc}
+	switch typ.kind {
+	case kindString, kindNumber, kindBool, kindStruct, kindTuple:
+		f.pointer = optional || nullable
+	}
+	return f
+}
+
+// uniqueFieldNames renames fields whose Go names collide, such as "a-b" and
+// "a_b", and keeps the Validate method free.
+func uniqueFieldNames(fields []*field) []*field {
+	seen := map[string]bool{"Validate": true}
+	for _, f := range fields {
+		base := f.name
+		for i := 2; seen[f.name]; i++ {
+			f.name = fmt.Sprintf("%s%d", base, i)
+		}
+		seen[f.name] = true
+	}
+	return fields
+}
+
+// typeName reserves a unique name for a declared type.
+func (g *generator) typeName(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+// writeType writes the declaration of a struct or tuple type.
+func (g *generator) writeType(b *bytes.Buffer, t *goType) {
+	if t.kind == kindTuple {
+		g.writeTuple(b, t)
+		return
+	}
+	fmt.Fprintf(b, "// %s holds", t.name)
+	if len(g.types) > 0 && g.types[0] == t {
+		b.WriteString(" the input variables of the module.\n")
+	} else {
+		b.WriteString(" the attributes of an object.\n")
+	}
+	fmt.Fprintf(b, "type %s struct {\n", t.name)
+	for _, f := range t.fields {
+		writeComment(b, f.doc, "\t")
+		typ := f.typ.expr()
+		if f.pointer {
+			typ = "*" + typ
+		}
+		tag := f.jsonName
+		if f.optional {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(b, "\t%s %s `json:%q`\n", f.name, typ, tag)
+	}
+	b.WriteString("}\n\n")
+}
+
+// writeTuple writes a tuple type as a struct with one field per element,
+// encoded as a JSON array.
+func (g *generator) writeTuple(b *bytes.Buffer, t *goType) {
+	fmt.Fprintf(b, "// %s is a tuple, encoded as a JSON array of %d elements.\n", t.name, len(t.items))
+	fmt.Fprintf(b, "type %s struct {\n", t.name)
+	for i, item := range t.items {
+		fmt.Fprintf(b, "\tV%d %s\n", i, item.expr())
+	}
+	b.WriteString("}\n\n")
+
+	elems := make([]string, len(t.items))
+	for i := range t.items {
+		elems[i] = fmt.Sprintf("t.V%d", i)
+	}
+	fmt.Fprintf(b, "// MarshalJSON encodes the tuple as a JSON array.\n")
+	fmt.Fprintf(b, "func (t %s) MarshalJSON() ([]byte, error) {\n", t.name)
+	fmt.Fprintf(b, "\treturn json.Marshal([]interface{}{%s})\n}\n\n", strings.Join(elems, ", "))
+
+	fmt.Fprintf(b, "// UnmarshalJSON decodes the tuple from a JSON array.\n")
+	fmt.Fprintf(b, "func (t *%s) UnmarshalJSON(data []byte) error {\n", t.name)
+	b.WriteString("\tvar elems []json.RawMessage\n")
+	b.WriteString("\tif err := json.Unmarshal(data, &elems); err != nil {\n\t\treturn err\n\t}\n")
+	fmt.Fprintf(b, "\tif len(elems) != %d {\n", len(t.items))
+	fmt.Fprintf(b, "\t\treturn fmt.Errorf(\"expected %d elements, got %%d\", len(elems))\n\t}\n", len(t.items))
+	for i := range t.items {
+		fmt.Fprintf(b, "\tif err := json.Unmarshal(elems[%d], &t.V%d); err != nil {\n\t\treturn err\n\t}\n", i, i)
+	}
+	b.WriteString("\treturn nil\n}\n\n")
+}
+
+// writeComment writes text as a Go line comment.
+func writeComment(b *bytes.Buffer, text, indent string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+// quote renders s as a Go string literal, preferring a raw string.
+func quote(s string) string {
+	if !strings.Contains(s, "`") && !strings.ContainsAny(s, "\n\r") {
+		return "`" + s + "`"
+	}
+	return fmt.Sprintf("%q", s)
+}
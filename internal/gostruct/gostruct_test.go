@@ -0,0 +1,172 @@
+package gostruct
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const module = `
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]*$", var.name))
+    error_message = "The name must be lower-case."
+  }
+}
+
+variable "environment" {
+  type    = string
+  default = "dev"
+
+  validation {
+    condition     = contains(["dev", "prod"], var.environment)
+    error_message = "Unknown environment."
+  }
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    subnets = optional(list(string), [])
+    peer    = tuple([string, number])
+  })
+
+  validation {
+    condition     = length(var.network.subnets) <= 2
+    error_message = "At most two subnets."
+  }
+}
+
+variable "replicas" {
+  type = number
+
+  validation {
+    condition     = var.replicas >= 1 && var.replicas <= 10
+    error_message = "Between 1 and 10 replicas."
+  }
+}
+
+variable "labels" {
+  type     = map(string)
+  nullable = true
+  default  = null
+}
+
+variable "tags" {
+  type = map(string)
+
+  validation {
+    condition     = alltrue([for k in keys(var.tags) : can(regex("^[a-z]+$", k))])
+    error_message = "Tag keys must be lower-case letters."
+  }
+}
+`
+
+func generate(t *testing.T) string {
+	t.Helper()
+	c := converter.New()
+	_, err := c.ConvertString(module)
+	require.NoError(t, err)
+
+	source, err := Generate("vpc", "terraform-aws-vpc", c.Variables(), c.Validations())
+	require.NoError(t, err)
+	return source
+}
+
+func TestGenerate(t *testing.T) {
+	// Compare with whitespace collapsed, ignoring the alignment of gofmt.
+	normalize := func(s string) string { return strings.Join(strings.Fields(s), " ") }
+	source := normalize(generate(t))
+
+	for _, want := range []string{
+		"// Code generated by tfschema. DO NOT EDIT.\n\npackage vpc\n",
+		"type TerraformAwsVpcInputs struct {\n\t// Resource name\n\tName string `json:\"name\"`\n",
+		"Environment *string `json:\"environment,omitempty\"`",
+		"Network Network `json:\"network\"`",
+		"Replicas json.Number `json:\"replicas\"`",
+		"Labels map[string]string `json:\"labels,omitempty\"`",
+		"Tags map[string]string `json:\"tags\"`",
+		"Subnets []string `json:\"subnets,omitempty\"`",
+		"Peer NetworkPeer `json:\"peer\"`",
+		"type NetworkPeer struct {\n\tV0 string\n\tV1 json.Number\n}",
+		"func (in *TerraformAwsVpcInputs) Validate() error {",
+		"// contains([\"dev\", \"prod\"], var.environment)",
+	} {
+		assert.Contains(t, source, normalize(want))
+	}
+}
+
+// TestGenerateValidate compiles the generated code and runs Validate on
+// decoded inputs.
+func TestGenerateValidate(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":    "module example.com/vpc\n\ngo 1.21\n",
+		"inputs.go": generate(t),
+		"main.go": `package vpc
+
+import "encoding/json"
+
+func Check(input string) string {
+	var in TerraformAwsVpcInputs
+	if err := json.Unmarshal([]byte(input), &in); err != nil {
+		return "decode: " + err.Error()
+	}
+	if err := in.Validate(); err != nil {
+		return err.Error()
+	}
+	return "ok"
+}
+`,
+		"main_test.go": `package vpc
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	for input, want := range map[string]string{
+		` + "`" + `{"name": "web", "network": {"cidr": "10.0.0.0/16", "peer": ["a", 1]}, "replicas": 3, "labels": null}` + "`" + `: "ok",
+		` + "`" + `{"name": "web", "environment": "prod", "network": {"cidr": "x", "subnets": ["a", "b"], "peer": ["a", 1]}, "replicas": 10.0, "tags": {"team": "x"}, "labels": {"a": "b"}}` + "`" + `: "ok",
+		` + "`" + `{"name": "Web", "network": {"cidr": "x", "peer": ["a", 1]}, "replicas": 3}` + "`" + `: "name: The name must be lower-case.",
+		` + "`" + `{"name": "web", "environment": "test", "network": {"cidr": "x", "peer": ["a", 1]}, "replicas": 3}` + "`" + `: "environment: Unknown environment.",
+		` + "`" + `{"name": "web", "network": {"cidr": "x", "subnets": ["a", "b", "c"], "peer": ["a", 1]}, "replicas": 3}` + "`" + `: "network: At most two subnets.",
+		` + "`" + `{"name": "web", "network": {"cidr": "x", "peer": ["a", 1]}, "replicas": 10.000000000000000001}` + "`" + `: "replicas: Between 1 and 10 replicas.",
+		` + "`" + `{"name": "web", "network": {"cidr": "x", "peer": ["a", 1]}, "replicas": 3, "tags": {"Team": "x"}}` + "`" + `: "tags: Tag keys must be lower-case letters.",
+		` + "`" + `{"name": "web", "network": {"cidr": "x", "peer": ["a"]}, "replicas": 3}` + "`" + `: "decode: expected 2 elements, got 1",
+	} {
+		if got := Check(input); got != want {
+			t.Errorf("Check(%s) = %q, want %q", input, got, want)
+		}
+	}
+}
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	cmd := exec.Command(goTool, "test", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func TestPackageName(t *testing.T) {
+	assert.Equal(t, "terraformawsvpc", PackageName("terraform-aws-vpc"))
+	assert.Equal(t, "inputs42mapentry", PackageName("42-map-entry"))
+	assert.Equal(t, "inputs", PackageName(""))
+}
@@ -0,0 +1,82 @@
+package gostruct
+
+// helperSource holds the functions that generated Validate methods call, by
+// name. A helper is only written to the file when a check uses it.
+var helperSource = map[string]string{
+	"number": `
+// validNumber reports whether n is a decimal number.
+func validNumber(n json.Number) bool {
+	_, ok := new(big.Float).SetString(string(n))
+	return ok
+}
+
+// compareNumber compares n with a decimal bound without losing precision.
+func compareNumber(n json.Number, bound string) int {
+	x, _ := new(big.Float).SetPrec(512).SetString(string(n))
+	y, _ := new(big.Float).SetPrec(512).SetString(bound)
+	if x == nil || y == nil {
+		return 0
+	}
+	return x.Cmp(y)
+}
+
+// isWholeNumber reports whether n has no fractional part.
+func isWholeNumber(n json.Number) bool {
+	x, ok := new(big.Float).SetPrec(512).SetString(string(n))
+	return ok && x.IsInt()
+}
+
+// isMultipleOf reports whether n is a whole multiple of divisor.
+func isMultipleOf(n json.Number, divisor string) bool {
+	x, ok := new(big.Float).SetPrec(512).SetString(string(n))
+	d, _ := new(big.Float).SetPrec(512).SetString(divisor)
+	return ok && d != nil && new(big.Float).SetPrec(512).Quo(x, d).IsInt()
+}
+`,
+	"dateTime": `
+// isDateTime reports whether s is an RFC 3339 timestamp.
+func isDateTime(s string) bool {
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
+`,
+	"ip": `
+// isIPv4 reports whether s is an IPv4 address.
+func isIPv4(s string) bool {
+	return net.ParseIP(s) != nil && !strings.Contains(s, ":")
+}
+
+// isIPv6 reports whether s is an IPv6 address.
+func isIPv6(s string) bool {
+	return net.ParseIP(s) != nil && strings.Contains(s, ":")
+}
+`,
+	"unique": `
+// uniqueItems reports whether the elements of items are distinct.
+func uniqueItems[T any](items []T) bool {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+`,
+	"hasKey": `
+// hasKey reports whether m has an entry for key.
+func hasKey[V any](m map[string]V, key string) bool {
+	_, ok := m[key]
+	return ok
+}
+`,
+}
+
+// helperImports lists the packages each helper needs.
+var helperImports = map[string][]string{
+	"number":   {"encoding/json", "math/big"},
+	"dateTime": {"time"},
+	"ip":       {"net", "strings"},
+	"unique":   {"reflect"},
+}
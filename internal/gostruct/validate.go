@@ -0,0 +1,400 @@
+package gostruct
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+)
+
+// value is a Go expression holding a value of a generated type.
+type value struct {
+	expr    string
+	typ     *goType
+	pointer bool
+}
+
+// validateMethod renders the Validate method of the root struct. Each
+// validation becomes one check appending its error_message, prefixed with
+// the variable name, to the returned errors.
+func (g *generator) validateMethod(root *goType, validations []constraint.Validation) string {
+	var b strings.Builder
+	b.WriteString("// Validate checks the inputs against the validation blocks of the module and\n")
+	b.WriteString("// returns the error messages of the failed checks, joined.\n")
+	fmt.Fprintf(&b, "func (in *%s) Validate() error {\n", root.name)
+	b.WriteString("var errs []error\n")
+	for _, v := range validations {
+		message := v.Variable + ": " + v.ErrorMessage
+		check, err := g.condition(v.Constraint, value{expr: "in", typ: root}, true)
+		if err != nil {
+			fmt.Fprintf(&b, "// TODO: %s\n", render.CommentLine(fmt.Sprintf("%q is not checked: %v", message, err)))
+			render.WriteSource(&b, "//", v.Source)
+			continue
+		}
+		render.WriteSource(&b, "//", v.Source)
+		fmt.Fprintf(&b, "if !(%s) {\nerrs = append(errs, errors.New(%s))\n}\n", check, strconv.Quote(message))
+	}
+	b.WriteString("return errors.Join(errs...)\n}\n\n")
+	g.imports["errors"] = true
+	return b.String()
+}
+
+// condition renders a constraint as a Go boolean expression over root. As in
+// constraint.Evaluate, vacuous is the result of an atom whose path leads to
+// an absent or nil value.
+func (g *generator) condition(c constraint.Constraint, root value, vacuous bool) (string, error) {
+	switch n := c.(type) {
+	case constraint.Atom:
+		return g.atom(n, root, vacuous)
+	case constraint.And, constraint.Or:
+		terms, sep := []constraint.Constraint(nil), " && "
+		if and, ok := n.(constraint.And); ok {
+			terms = and.Terms
+		} else {
+			terms, sep = n.(constraint.Or).Terms, " || "
+		}
+		parts := make([]string, len(terms))
+		for i, term := range terms {
+			part, err := g.condition(term, root, vacuous)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = "(" + part + ")"
+		}
+		return strings.Join(parts, sep), nil
+	case constraint.Not:
+		term, err := g.condition(n.Term, root, !vacuous)
+		if err != nil {
+			return "", err
+		}
+		return "!(" + term + ")", nil
+	case constraint.Implies:
+		cond, err := g.condition(n.If, root, false)
+		if err != nil {
+			return "", err
+		}
+		then, err := g.optionalCondition(n.Then, root, vacuous)
+		if err != nil {
+			return "", err
+		}
+		otherwise, err := g.optionalCondition(n.Else, root, vacuous)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("func() bool {\nif %s {\nreturn %s\n}\nreturn %s\n}()", cond, then, otherwise), nil
+	case constraint.Never:
+		return "false", nil
+	}
+	return "", fmt.Errorf("unsupported constraint %T", c)
+}
+
+func (g *generator) optionalCondition(c constraint.Constraint, root value, vacuous bool) (string, error) {
+	if c == nil {
+		return "true", nil
+	}
+	return g.condition(c, root, vacuous)
+}
+
+// atom renders an atom as a closure that walks its path from root, looping
+// over wildcards, and checks the predicate on every value it reaches.
+func (g *generator) atom(a constraint.Atom, root value, vacuous bool) (string, error) {
+	var b strings.Builder
+	b.WriteString("func() bool {\n")
+	loops := 0
+	missing := func() string {
+		switch {
+		case !vacuous:
+			return "return false"
+		case loops > 0:
+			return "continue"
+		}
+		return "return true"
+	}
+
+	first := g.counter + 1
+	current := root
+	for _, segment := range a.Path {
+		var err error
+		current, err = g.step(&b, current, segment, missing, &loops)
+		if err != nil {
+			return "", err
+		}
+	}
+	current = g.deref(&b, current, missing)
+	last := g.counter
+
+	predicate, err := g.predicate(a.Predicate, current)
+	if err != nil {
+		return "", err
+	}
+	// Go rejects variables that are declared and not used, which happens
+	// when the predicate holds for any value of the type.
+	declared := b.String() + predicate
+	for i := first; i <= last; i++ {
+		name := fmt.Sprintf("v%d", i)
+		if len(regexp.MustCompile(`\b`+name+`\b`).FindAllString(declared, 2)) < 2 {
+			fmt.Fprintf(&b, "_ = %s\n", name)
+		}
+	}
+	fmt.Fprintf(&b, "if !(%s) {\nreturn false\n}\n", predicate)
+	b.WriteString(strings.Repeat("}\n", loops))
+	b.WriteString("return true\n}()")
+	return b.String(), nil
+}
+
+// deref checks that a pointer, slice or map is not nil, treating nil as an
+// absent value, and returns the value pointed to.
+func (g *generator) deref(b *strings.Builder, v value, missing func() string) value {
+	switch {
+	case v.pointer:
+		fmt.Fprintf(b, "if %s == nil {\n%s\n}\n", v.expr, missing())
+		if v.typ.kind == kindStruct || v.typ.kind == kindTuple {
+			// Selectors dereference pointers to structs.
+			return value{expr: v.expr, typ: v.typ}
+		}
+		return value{expr: "*" + v.expr, typ: v.typ}
+	case v.typ.kind == kindSlice || v.typ.kind == kindMap:
+		fmt.Fprintf(b, "if %s == nil {\n%s\n}\n", v.expr, missing())
+	}
+	return v
+}
+
+// step applies one path segment to v.
+func (g *generator) step(b *strings.Builder, v value, segment constraint.Segment, missing func() string, loops *int) (value, error) {
+	if v.pointer {
+		fmt.Fprintf(b, "if %s == nil {\n%s\n}\n", v.expr, missing())
+		v = value{expr: v.expr, typ: v.typ}
+	}
+	t := v.typ
+
+	switch segment.Kind {
+	case constraint.AttributeSegment, constraint.KeySegment:
+		switch t.kind {
+		case kindStruct:
+			f := t.fieldByJSON(segment.Name)
+			if f == nil {
+				return value{}, fmt.Errorf("%s has no attribute %q", t.name, segment.Name)
+			}
+			return value{expr: v.expr + "." + f.name, typ: f.typ, pointer: f.pointer}, nil
+		case kindMap:
+			name := g.variable()
+			fmt.Fprintf(b, "%s, ok := %s[%s]\nif !ok {\n%s\n}\n", name, v.expr, strconv.Quote(segment.Name), missing())
+			return value{expr: name, typ: t.elem}, nil
+		}
+	case constraint.IndexSegment:
+		switch t.kind {
+		case kindSlice:
+			fmt.Fprintf(b, "if len(%s) <= %d {\n%s\n}\n", v.expr, segment.Index, missing())
+			return value{expr: fmt.Sprintf("%s[%d]", v.expr, segment.Index), typ: t.elem}, nil
+		case kindTuple:
+			if segment.Index < len(t.items) {
+				return value{expr: fmt.Sprintf("%s.V%d", v.expr, segment.Index), typ: t.items[segment.Index]}, nil
+			}
+		}
+	case constraint.WildcardSegment:
+		if t.kind == kindSlice || t.kind == kindMap {
+			name := g.variable()
+			fmt.Fprintf(b, "for _, %s := range %s {\n", name, v.expr)
+			*loops++
+			return value{expr: name, typ: t.elem}, nil
+		}
+	case constraint.KeysSegment:
+		if t.kind == kindMap {
+			name := g.variable()
+			fmt.Fprintf(b, "for %s := range %s {\n", name, v.expr)
+			*loops++
+			return value{expr: name, typ: &goType{kind: kindString}}, nil
+		}
+	}
+	return value{}, fmt.Errorf("cannot address %s on a value of type %s", constraint.Path{segment}, t.expr())
+}
+
+// variable returns a fresh variable name for generated code.
+func (g *generator) variable() string {
+	g.counter++
+	return fmt.Sprintf("v%d", g.counter)
+}
+
+// predicate renders a check of p on v. As in JSON Schema, a predicate on a
+// value of another type, such as a pattern on a number, holds.
+func (g *generator) predicate(p constraint.Predicate, v value) (string, error) {
+	x, kind := v.expr, v.typ.kind
+	if kind == kindAny {
+		return "", fmt.Errorf("values of type any have no Go type to check")
+	}
+
+	switch p := p.(type) {
+	case constraint.Length:
+		var length string
+		switch kind {
+		case kindString:
+			g.imports["unicode/utf8"] = true
+			length = "utf8.RuneCountInString(" + x + ")"
+		case kindSlice, kindMap:
+			length = "len(" + x + ")"
+		case kindStruct:
+			length = strconv.Itoa(len(v.typ.fields))
+		case kindTuple:
+			length = strconv.Itoa(len(v.typ.items))
+		default:
+			return "true", nil
+		}
+		var checks []string
+		if p.Min != nil {
+			checks = append(checks, fmt.Sprintf("%s >= %d", length, *p.Min))
+		}
+		if p.Max != nil {
+			checks = append(checks, fmt.Sprintf("%s <= %d", length, *p.Max))
+		}
+		return render.JoinChecks(checks), nil
+
+	case constraint.Range:
+		if kind != kindNumber {
+			return "true", nil
+		}
+		g.helpers["number"] = true
+		checks := []string{"validNumber(" + x + ")"}
+		for _, bound := range []struct {
+			value *float64
+			op    string
+		}{{p.Minimum, ">="}, {p.Maximum, "<="}, {p.ExclusiveMinimum, ">"}, {p.ExclusiveMaximum, "<"}} {
+			if bound.value != nil {
+				checks = append(checks, fmt.Sprintf("compareNumber(%s, %q) %s 0", x, render.Number(*bound.value), bound.op))
+			}
+		}
+		return render.JoinChecks(checks), nil
+
+	case constraint.Pattern:
+		if kind != kindString {
+			return "true", nil
+		}
+		pattern, err := g.pattern(p.Regexp())
+		if err != nil {
+			return "", err
+		}
+		return pattern + ".MatchString(" + x + ")", nil
+
+	case constraint.Format:
+		if kind != kindString {
+			return "true", nil
+		}
+		switch p.Format {
+		case "date-time":
+			g.helpers["dateTime"] = true
+			return "isDateTime(" + x + ")", nil
+		case "ipv4", "ipv6":
+			g.helpers["ip"] = true
+			return "is" + strings.ToUpper(p.Format[:2]) + p.Format[2:] + "(" + x + ")", nil
+		}
+		return "", fmt.Errorf("format %q is not supported", p.Format)
+
+	case constraint.Enum:
+		var checks []string
+		for _, allowed := range p.Values {
+			switch a := allowed.(type) {
+			case string:
+				if kind == kindString {
+					checks = append(checks, x+" == "+strconv.Quote(a))
+				}
+			case float64:
+				if kind == kindNumber {
+					g.helpers["number"] = true
+					checks = append(checks, fmt.Sprintf("validNumber(%s) && compareNumber(%s, %q) == 0", x, x, render.Number(a)))
+				}
+			case bool:
+				if kind == kindBool {
+					checks = append(checks, fmt.Sprintf("%s == %t", x, a))
+				}
+			}
+		}
+		if len(checks) == 0 {
+			return "false", nil
+		}
+		return "(" + strings.Join(checks, ") || (") + ")", nil
+
+	case constraint.Integer:
+		switch kind {
+		case kindNumber:
+			g.helpers["number"] = true
+			return "isWholeNumber(" + x + ")", nil
+		case kindString:
+			pattern, err := g.pattern(`^[+-]?[0-9]+$`)
+			if err != nil {
+				return "", err
+			}
+			return pattern + ".MatchString(" + x + ")", nil
+		}
+		return "true", nil
+
+	case constraint.MultipleOf:
+		if kind != kindNumber || p.Value == 0 {
+			return "true", nil
+		}
+		g.helpers["number"] = true
+		return fmt.Sprintf("isMultipleOf(%s, %q)", x, render.Number(p.Value)), nil
+
+	case constraint.UniqueItems:
+		if kind != kindSlice {
+			return "true", nil
+		}
+		g.helpers["unique"] = true
+		return "uniqueItems(" + x + ")", nil
+
+	case constraint.Required:
+		var checks []string
+		for _, property := range p.Properties {
+			switch kind {
+			case kindStruct:
+				f := v.typ.fieldByJSON(property)
+				if f == nil {
+					return "", fmt.Errorf("%s has no attribute %q", v.typ.name, property)
+				}
+				if f.pointer || f.typ.kind == kindSlice || f.typ.kind == kindMap || f.typ.kind == kindAny {
+					checks = append(checks, x+"."+f.name+" != nil")
+				}
+			case kindMap:
+				g.helpers["hasKey"] = true
+				checks = append(checks, fmt.Sprintf("hasKey(%s, %s)", x, strconv.Quote(property)))
+			}
+		}
+		return render.JoinChecks(checks), nil
+
+	case constraint.Contains:
+		if kind != kindSlice && kind != kindMap {
+			return "true", nil
+		}
+		name := g.variable()
+		element, err := g.condition(p.Element, value{expr: name, typ: v.typ.elem}, false)
+		if err != nil {
+			return "", err
+		}
+		loop := "for _, " + name + " := range " + x
+		if !strings.Contains(element, name) {
+			loop = "for range " + x
+		}
+		return fmt.Sprintf("func() bool {\n%s {\nif %s {\nreturn true\n}\n}\nreturn false\n}()", loop, element), nil
+
+	case constraint.Satisfies:
+		return g.condition(p.Constraint, v, true)
+	}
+	return "", fmt.Errorf("unsupported predicate %T", p)
+}
+
+// pattern returns the package variable holding a compiled pattern. Patterns
+// are ECMA-262, so the ones RE2 rejects cannot be checked.
+func (g *generator) pattern(pattern string) (string, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", fmt.Errorf("pattern %q is not valid in Go: %w", pattern, err)
+	}
+	g.imports["regexp"] = true
+	for i, existing := range g.regexps {
+		if existing == pattern {
+			return fmt.Sprintf("pattern%d", i), nil
+		}
+	}
+	g.regexps = append(g.regexps, pattern)
+	return fmt.Sprintf("pattern%d", len(g.regexps)-1), nil
+}
@@ -0,0 +1,664 @@
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+)
+
+// integerPattern matches the strings parseint accepts in base 10.
+const integerPattern = "^[+-]?[0-9]+$"
+
+// UnsupportedError reports a constraint that JSON Schema cannot express.
+type UnsupportedError struct {
+	Reason string
+}
+
+func (e *UnsupportedError) Error() string {
+	return e.Reason
+}
+
+// Constrain adds the keywords expressing c to schema, the schema of the value
+// the paths of c are relative to. Keywords from earlier constraints are kept:
+// length bounds only tighten, and further patterns, negations and conditions
+// go to allOf. Atoms constrain the schemas along their path in place, while
+// a Not, Or or Implies is expressed at the nearest common ancestor of its
+// atoms, as subschemas that mirror the structure below it.
+func Constrain(schema *Schema, c constraint.Constraint) error {
+	if err := requireIndexedKeys(schema, c); err != nil {
+		return err
+	}
+
+	switch n := c.(type) {
+	case constraint.And:
+		for _, term := range n.Terms {
+			if err := Constrain(schema, term); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case constraint.Atom:
+		targets, err := findTargets(schema, n.Path)
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			if err := constrainValue(target, n.Predicate); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	anchor := constraint.CommonPath(c)
+	targets, err := findTargets(schema, anchor)
+	if err != nil {
+		return err
+	}
+	c = constraint.Trim(c, len(anchor))
+	for _, target := range targets {
+		if err := combine(target, target, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireIndexedKeys requires the map entries the atoms of c index, such as
+// env in length(var.tags["env"]) > 0, since Terraform fails the condition
+// when the entry is missing instead of skipping it. Entries indexed only in
+// the branches of a conditional are left optional, as are attributes of
+// objects indexed with a string.
+func requireIndexedKeys(schema *Schema, c constraint.Constraint) error {
+	switch n := c.(type) {
+	case constraint.And:
+		for _, term := range n.Terms {
+			if err := requireIndexedKeys(schema, term); err != nil {
+				return err
+			}
+		}
+	case constraint.Or:
+		for _, term := range n.Terms {
+			if err := requireIndexedKeys(schema, term); err != nil {
+				return err
+			}
+		}
+	case constraint.Not:
+		return requireIndexedKeys(schema, n.Term)
+	case constraint.Implies:
+		return requireIndexedKeys(schema, n.If)
+	case constraint.Atom:
+		for i, segment := range n.Path {
+			if segment.Kind != constraint.KeySegment {
+				continue
+			}
+			parents, err := findTargets(schema, n.Path[:i])
+			if err != nil {
+				return err
+			}
+			for _, parent := range parents {
+				if _, isMap := parent.AdditionalProperties.(*Schema); !isMap || typeName(parent) != "object" {
+					continue
+				}
+				// The entry gets its copy of the value schema, whose type
+				// already rejects null, before it is required.
+				if _, err := navigate(parent, segment); err != nil {
+					return err
+				}
+				addRequired(parent, []string{segment.Name})
+			}
+		}
+	}
+	return nil
+}
+
+// constrainValue applies p to the schema of a value in place.
+func constrainValue(schema *Schema, p constraint.Predicate) error {
+	switch p := p.(type) {
+	case constraint.Satisfies:
+		return Constrain(schema, p.Constraint)
+	case constraint.Contains:
+		return addContains(schema, schema, p)
+	}
+	return addKeywords(schema, p)
+}
+
+// combine expresses a Not, Or, Implies or Never whose paths are relative to
+// the value target describes, adding the keywords to into. In place, into is
+// target itself; in a subschema, into mirrors target.
+func combine(target, into *Schema, c constraint.Constraint) error {
+	switch n := c.(type) {
+	case constraint.Never:
+		addNot(into, &Schema{})
+		return nil
+
+	case constraint.Not:
+		forbidden, err := mirror(target, n.Term, false)
+		if err != nil {
+			return err
+		}
+		if reflect.DeepEqual(forbidden, &Schema{}) {
+			// The term has no keyword for this type, so its negation has none either.
+			return &UnsupportedError{Reason: fmt.Sprintf("JSON Schema cannot negate the check on a value of type %q", typeName(target))}
+		}
+		addNot(into, forbidden)
+		return nil
+
+	case constraint.Or:
+		any := &Schema{}
+		for _, term := range n.Terms {
+			alternative, err := mirror(target, term, false)
+			if err != nil {
+				return err
+			}
+			any.AnyOf = append(any.AnyOf, *alternative)
+		}
+		into.AllOf = append(into.AllOf, any)
+		return nil
+
+	case constraint.Implies:
+		cond := &Schema{}
+		var err error
+		// The properties along the paths of the condition are required, so
+		// that an absent property does not satisfy the 'if' vacuously.
+		if cond.If, err = mirror(target, n.If, true); err != nil {
+			return fmt.Errorf("failed to build 'if' schema: %w", err)
+		}
+		if n.Then != nil {
+			if cond.Then, err = mirror(target, n.Then, false); err != nil {
+				return fmt.Errorf("failed to build 'then' schema: %w", err)
+			}
+		}
+		if n.Else != nil {
+			if cond.Else, err = mirror(target, n.Else, false); err != nil {
+				return fmt.Errorf("failed to build 'else' schema: %w", err)
+			}
+		}
+		if into.If == nil {
+			into.If, into.Then, into.Else = cond.If, cond.Then, cond.Else
+			return nil
+		}
+		into.AllOf = append(into.AllOf, cond)
+		return nil
+	}
+	return fmt.Errorf("unsupported constraint %T", c)
+}
+
+// mirror returns a standalone schema expressing c on the value target
+// describes. It only mirrors the structure of target along the paths of c.
+// With require set, each property along a path is also marked as required.
+func mirror(target *Schema, c constraint.Constraint, require bool) (*Schema, error) {
+	sub := &Schema{}
+	if err := mirrorInto(target, sub, c, require); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func mirrorInto(target, sub *Schema, c constraint.Constraint, require bool) error {
+	switch n := c.(type) {
+	case constraint.And:
+		for _, term := range n.Terms {
+			if err := mirrorInto(target, sub, term, require); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case constraint.Atom:
+		target, sub, err := descend(target, sub, n.Path, require)
+		if err != nil {
+			return err
+		}
+		switch p := n.Predicate.(type) {
+		case constraint.Satisfies:
+			return mirrorInto(target, sub, p.Constraint, require)
+		case constraint.Contains:
+			return addContains(target, sub, p)
+		}
+		// Keywords such as length bounds depend on the type, so the subschema
+		// borrows the type of the target while they are added.
+		borrowed := sub.Type == nil
+		if borrowed {
+			sub.Type = target.Type
+		}
+		if err := addKeywords(sub, n.Predicate); err != nil {
+			return err
+		}
+		if borrowed && reflect.DeepEqual(sub.Type, target.Type) {
+			sub.Type = nil
+		}
+		return nil
+	}
+
+	anchor := constraint.CommonPath(c)
+	target, sub, err := descend(target, sub, anchor, require)
+	if err != nil {
+		return err
+	}
+	return combine(target, sub, constraint.Trim(c, len(anchor)))
+}
+
+// descend follows path from target and its mirror sub, creating the mirrored
+// schemas that do not exist yet, and returns the schemas at its end.
+func descend(target, sub *Schema, path constraint.Path, require bool) (*Schema, *Schema, error) {
+	for _, segment := range path {
+		if target == nil {
+			return nil, nil, fmt.Errorf("cannot build subschema through a nil schema")
+		}
+
+		switch segment.Kind {
+		case constraint.WildcardSegment:
+			if items := target.ElementSchema(); items != nil {
+				target = items
+				if sub.ElementSchema() == nil {
+					sub.Items = &Schema{}
+				}
+				sub = sub.ElementSchema()
+			} else if values := target.MapValues(); values != nil {
+				target = values
+				if sub.MapValues() == nil {
+					sub.AdditionalProperties = &Schema{}
+				}
+				sub = sub.MapValues()
+			} else {
+				return nil, nil, fmt.Errorf("cannot apply wildcard to a schema without items or additional properties")
+			}
+
+		case constraint.KeysSegment:
+			target = target.PropertyNames
+			if target == nil {
+				target = &Schema{Type: "string"}
+			}
+			if sub.PropertyNames == nil {
+				sub.PropertyNames = &Schema{}
+			}
+			sub = sub.PropertyNames
+
+		case constraint.IndexSegment:
+			index := segment.Index
+			switch items := target.Items.(type) {
+			case []*Schema:
+				if index >= len(items) {
+					return nil, nil, fmt.Errorf("index %d out of range for tuple of length %d", index, len(items))
+				}
+				target = items[index]
+			case *Schema:
+				target = items
+			default:
+				return nil, nil, fmt.Errorf("cannot apply indexed path to a schema without items")
+			}
+			// Positional items leave every other element unconstrained.
+			tuple, _ := sub.Items.([]*Schema)
+			for len(tuple) <= index {
+				tuple = append(tuple, &Schema{})
+			}
+			sub.Items = tuple
+			sub = tuple[index]
+
+		default:
+			// An attribute of an object, or a single entry of a map.
+			name := segment.Name
+			if prop, ok := target.Properties[name]; ok {
+				target = prop
+			} else if values := target.MapValues(); values != nil {
+				target = values
+			} else {
+				return nil, nil, fmt.Errorf("property '%s' not found in schema", name)
+			}
+			if sub.Properties == nil {
+				sub.Properties = make(map[string]*Schema)
+			}
+			if sub.Properties[name] == nil {
+				sub.Properties[name] = &Schema{}
+			}
+			if require {
+				required := []string{}
+				if sub.Required != nil {
+					required = *sub.Required
+				}
+				if !containsString(required, name) {
+					required = append(required, name)
+				}
+				sub.Required = &required
+			}
+			sub = sub.Properties[name]
+		}
+	}
+	return target, sub, nil
+}
+
+// addContains requires an element of the list or map target describes to
+// satisfy the element constraint of p, adding the keywords to into.
+func addContains(target, into *Schema, p constraint.Contains) error {
+	if items := target.ElementSchema(); items != nil {
+		match, err := mirror(items, p.Element, true)
+		if err != nil {
+			return fmt.Errorf("failed to build 'contains' schema: %w", err)
+		}
+		one := 1
+		if into.Contains == nil {
+			into.Contains, into.MinContains = match, &one
+			return nil
+		}
+		into.AllOf = append(into.AllOf, &Schema{Contains: match, MinContains: &one})
+		return nil
+	}
+
+	if values := target.MapValues(); values != nil {
+		// Not every value fails to match.
+		match, err := mirror(values, p.Element, true)
+		if err != nil {
+			return fmt.Errorf("failed to build value schema: %w", err)
+		}
+		addNot(into, &Schema{AdditionalProperties: &Schema{Not: match}})
+		return nil
+	}
+
+	return fmt.Errorf("anytrue validation can only be applied to lists, sets and maps")
+}
+
+// addKeywords adds the keywords of a predicate on a single value to schema,
+// picking them by the type of the schema.
+func addKeywords(schema *Schema, p constraint.Predicate) error {
+	switch p := p.(type) {
+	case constraint.Length:
+		minField, maxField := lengthFields(schema, typeName(schema))
+		if minField == nil {
+			return nil
+		}
+		if p.Min != nil && (*minField == nil || *p.Min > **minField) {
+			*minField = p.Min
+		}
+		if p.Max != nil && (*maxField == nil || *p.Max < **maxField) {
+			*maxField = p.Max
+		}
+
+	case constraint.Range:
+		if p.Minimum != nil {
+			schema.Minimum = p.Minimum
+		}
+		if p.Maximum != nil {
+			schema.Maximum = p.Maximum
+		}
+		if p.ExclusiveMinimum != nil {
+			schema.ExclusiveMinimum = p.ExclusiveMinimum
+		}
+		if p.ExclusiveMaximum != nil {
+			schema.ExclusiveMaximum = p.ExclusiveMaximum
+		}
+
+	case constraint.Pattern:
+		addPattern(schema, p.Pattern)
+
+	case constraint.Format:
+		schema.Format = p.Format
+
+	case constraint.Enum:
+		schema.Enum = p.Values
+
+	case constraint.Integer:
+		if typeName(schema) == "string" {
+			addPattern(schema, integerPattern)
+			return nil
+		}
+		schema.Type = toIntegerType(schema.Type)
+		for i := range schema.AnyOf {
+			schema.AnyOf[i].Type = toIntegerType(schema.AnyOf[i].Type)
+		}
+
+	case constraint.MultipleOf:
+		value := p.Value
+		schema.MultipleOf = &value
+
+	case constraint.UniqueItems:
+		unique := true
+		schema.UniqueItems = &unique
+
+	case constraint.Required:
+		addRequired(schema, p.Properties)
+		// A property that is present but null does not satisfy the check either.
+		for _, property := range p.Properties {
+			forbidNull(schema, property)
+		}
+
+	default:
+		return fmt.Errorf("unsupported predicate %T", p)
+	}
+	return nil
+}
+
+// addRequired adds properties to the required properties of schema.
+func addRequired(schema *Schema, properties []string) {
+	required := []string{}
+	if schema.Required != nil {
+		required = append(required, *schema.Required...)
+	}
+	for _, property := range properties {
+		if !containsString(required, property) {
+			required = append(required, property)
+		}
+	}
+	// Sort required fields alphabetically (terraschema compatibility)
+	sort.Strings(required)
+	schema.Required = &required
+}
+
+// addNot forbids the given subschema on schema, keeping any existing 'not'
+// by moving the new one into allOf.
+func addNot(schema *Schema, forbidden *Schema) {
+	if schema.Not == nil {
+		schema.Not = forbidden
+		return
+	}
+	schema.AllOf = append(schema.AllOf, &Schema{Not: forbidden})
+}
+
+// forbidNull adds not: {type: "null"} to the schema of a property, creating
+// the property schema if there is none yet.
+func forbidNull(schema *Schema, property string) {
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]*Schema)
+	}
+	prop := schema.Properties[property]
+	if prop == nil {
+		prop = &Schema{}
+		schema.Properties[property] = prop
+	}
+	if prop.Not != nil && prop.Not.Type == "null" {
+		return
+	}
+	addNot(prop, &Schema{Type: "null"})
+}
+
+// addPattern requires schema to match pattern. A schema holds one pattern, so
+// further patterns are added to allOf.
+func addPattern(schema *Schema, pattern string) {
+	if schema.Pattern == "" || schema.Pattern == pattern {
+		schema.Pattern = pattern
+		return
+	}
+	schema.AllOf = append(schema.AllOf, &Schema{Pattern: pattern})
+}
+
+// lengthFields returns the keywords bounding the length of a value of the
+// given JSON type, or nil if the type has no length.
+func lengthFields(schema *Schema, baseType string) (min, max **int) {
+	switch baseType {
+	case "string":
+		return &schema.MinLength, &schema.MaxLength
+	case "array":
+		return &schema.MinItems, &schema.MaxItems
+	case "object":
+		return &schema.MinProperties, &schema.MaxProperties
+	}
+	return nil, nil
+}
+
+// toIntegerType narrows "number" to "integer" in a schema type, leaving any
+// other type untouched.
+func toIntegerType(t interface{}) interface{} {
+	switch v := t.(type) {
+	case string:
+		if v == "number" {
+			return "integer"
+		}
+	case []interface{}:
+		narrowed := make([]interface{}, len(v))
+		for i, entry := range v {
+			narrowed[i] = toIntegerType(entry)
+		}
+		return narrowed
+	}
+	return t
+}
+
+// typeName returns the primary non-null type held in the type keyword of
+// schema. Unlike BaseType, it does not look into anyOf.
+func typeName(schema *Schema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// findTargets navigates schema along path. A path usually leads to a single
+// schema, but every value of a map also includes the entries that other
+// validations have split out into properties.
+func findTargets(schema *Schema, path constraint.Path) ([]*Schema, error) {
+	targets := []*Schema{schema}
+	for _, segment := range path {
+		var next []*Schema
+		for _, current := range targets {
+			schemas, err := navigate(current, segment)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, schemas...)
+		}
+		targets = next
+	}
+	return targets, nil
+}
+
+// navigate returns the schemas a path segment leads to from current.
+func navigate(current *Schema, segment constraint.Segment) ([]*Schema, error) {
+	if current == nil {
+		return nil, fmt.Errorf("cannot apply validation to a nil schema")
+	}
+
+	baseType := typeName(current)
+	mapValues, isMap := current.AdditionalProperties.(*Schema)
+	isMap = isMap && baseType == "object"
+
+	switch segment.Kind {
+	case constraint.WildcardSegment:
+		switch baseType {
+		case "object":
+			if isMap {
+				// Entries split out into properties no longer match
+				// additionalProperties, so they are targeted as well.
+				targets := []*Schema{mapValues}
+				for _, name := range sortedKeys(current.Properties) {
+					targets = append(targets, current.Properties[name])
+				}
+				return targets, nil
+			}
+			return nil, fmt.Errorf("cannot apply wildcard validation to object without schema for additional properties")
+		case "array", "set":
+			if current.Items != nil {
+				if items, ok := current.Items.(*Schema); ok {
+					return []*Schema{items}, nil
+				}
+				// For arrays of schemas (tuples), we can't apply wildcard validation
+				return nil, fmt.Errorf("cannot apply wildcard validation to tuple types")
+			}
+			return nil, fmt.Errorf("cannot apply wildcard validation to array with no item schema")
+		default:
+			return nil, fmt.Errorf("wildcard validation can only be applied to object or array/set types, not '%s'", baseType)
+		}
+
+	case constraint.KeysSegment:
+		// Map keys are constrained through propertyNames.
+		if baseType == "array" || baseType == "set" {
+			// for i, v in var.list : <condition on i> checks the indices.
+			return nil, &UnsupportedError{Reason: "JSON Schema cannot constrain the indices of a list"}
+		}
+		if !isMap {
+			return nil, fmt.Errorf("key validation can only be applied to map types, not '%s'", baseType)
+		}
+		if current.PropertyNames == nil {
+			current.PropertyNames = &Schema{Type: "string"}
+		}
+		return []*Schema{current.PropertyNames}, nil
+
+	case constraint.IndexSegment:
+		index := segment.Index
+		if current.PrefixItems != nil && index < len(current.PrefixItems) {
+			return []*Schema{current.PrefixItems[index]}, nil
+		}
+		switch items := current.Items.(type) {
+		case *Schema:
+			return []*Schema{items}, nil
+		case []*Schema:
+			// For tuples with array of schemas, use the indexed schema if available
+			if index < len(items) {
+				return []*Schema{items[index]}, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot apply indexed validation to a schema without Items or PrefixItems")
+	}
+
+	// An attribute of an object, or a single entry of a map.
+	name := segment.Name
+	if baseType != "object" {
+		return nil, fmt.Errorf("cannot apply validation to path segment '%s' on non-object type", name)
+	}
+	if prop, ok := current.Properties[name]; ok {
+		return []*Schema{prop}, nil
+	}
+	if isMap {
+		// The entry gets its own copy of the value schema, so that the
+		// validation does not constrain the other values of the map.
+		if current.Properties == nil {
+			current.Properties = make(map[string]*Schema)
+		}
+		entry := mapValues.Clone()
+		current.Properties[name] = entry
+		return []*Schema{entry}, nil
+	}
+	if current.Properties == nil {
+		// An object with unknown attributes.
+		return []*Schema{current}, nil
+	}
+	return nil, fmt.Errorf("property '%s' not found in schema", name)
+}
+
+// sortedKeys returns the names of properties in a stable order.
+func sortedKeys(properties map[string]*Schema) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
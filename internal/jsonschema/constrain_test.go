@@ -0,0 +1,153 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int { return &v }
+
+func subnets() *Schema {
+	return &Schema{Type: "array", Items: &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"public": {Type: "boolean"},
+			"cidr":   {Type: "string"},
+		},
+	}}
+}
+
+func TestConstrain(t *testing.T) {
+	public := constraint.Atom{Path: constraint.Path{constraint.Attr("public")}, Predicate: constraint.Enum{Values: []interface{}{true}}}
+	cidr := constraint.Atom{Path: constraint.Path{constraint.Attr("cidr")}, Predicate: constraint.Length{Min: intPtr(9)}}
+
+	tests := []struct {
+		name       string
+		constraint constraint.Constraint
+		want       string
+	}{
+		{
+			"length tightens",
+			constraint.And{Terms: []constraint.Constraint{
+				constraint.Atom{Path: constraint.Path{constraint.Wildcard(), constraint.Attr("cidr")}, Predicate: constraint.Length{Min: intPtr(9), Max: intPtr(18)}},
+				constraint.Atom{Path: constraint.Path{constraint.Wildcard(), constraint.Attr("cidr")}, Predicate: constraint.Length{Min: intPtr(7)}},
+			}},
+			`{"type":"array","items":{"type":"object","properties":{"cidr":{"type":"string","minLength":9,"maxLength":18},"public":{"type":"boolean"}}}}`,
+		},
+		{
+			"negation of each element",
+			constraint.Prefix(constraint.Not{Term: public}, constraint.Path{constraint.Wildcard()}),
+			`{"type":"array","items":{"type":"object","properties":{"cidr":{"type":"string"},"public":{"type":"boolean","not":{"enum":[true]}}}}}`,
+		},
+		{
+			"conditional on each element",
+			constraint.Prefix(constraint.Implies{If: public, Then: cidr}, constraint.Path{constraint.Wildcard()}),
+			`{"type":"array","items":{"type":"object","properties":{"cidr":{"type":"string"},"public":{"type":"boolean"}},"if":{"properties":{"public":{"enum":[true]}},"required":["public"]},"then":{"properties":{"cidr":{"minLength":9}}}}}`,
+		},
+		{
+			"integer string keeps the pattern",
+			constraint.And{Terms: []constraint.Constraint{
+				constraint.Atom{Path: constraint.Path{constraint.Wildcard(), constraint.Attr("cidr")}, Predicate: constraint.Pattern{Pattern: "^[0-9]+$"}},
+				constraint.Atom{Path: constraint.Path{constraint.Wildcard(), constraint.Attr("cidr")}, Predicate: constraint.Integer{}},
+			}},
+			`{"type":"array","items":{"type":"object","properties":{"cidr":{"type":"string","pattern":"^[0-9]+$","allOf":[{"pattern":"^[+-]?[0-9]+$"}]},"public":{"type":"boolean"}}}}`,
+		},
+		{
+			"element the list contains",
+			constraint.Atom{Predicate: constraint.Contains{Element: constraint.And{Terms: []constraint.Constraint{public, cidr}}}},
+			`{"type":"array","items":{"type":"object","properties":{"cidr":{"type":"string"},"public":{"type":"boolean"}}},"contains":{"properties":{"cidr":{"minLength":9},"public":{"enum":[true]}},"required":["public","cidr"]},"minContains":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := subnets()
+			require.NoError(t, Constrain(schema, tt.constraint))
+			got, err := json.Marshal(schema)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestConstrainRequiredForbidsNull(t *testing.T) {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{
+		"cert": {Type: "string"},
+		"key":  {Type: "string"},
+	}}
+	require.NoError(t, Constrain(schema, constraint.Implies{
+		If:   constraint.Atom{Predicate: constraint.Required{Properties: []string{"cert"}}},
+		Then: constraint.Atom{Predicate: constraint.Required{Properties: []string{"key"}}},
+	}))
+	got, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {"cert": {"type": "string"}, "key": {"type": "string"}},
+		"if": {"properties": {"cert": {"not": {"type": "null"}}}, "required": ["cert"]},
+		"then": {"properties": {"key": {"not": {"type": "null"}}}, "required": ["key"]}
+	}`, string(got))
+}
+
+func TestConstrainRequiresIndexedMapEntries(t *testing.T) {
+	env := constraint.Atom{Path: constraint.Path{constraint.Attr("tags"), constraint.Key("env")}, Predicate: constraint.Length{Min: intPtr(1)}}
+	name := constraint.Atom{Path: constraint.Path{constraint.Attr("service"), constraint.Key("name")}, Predicate: constraint.Length{Min: intPtr(1)}}
+	stage := constraint.Atom{Path: constraint.Path{constraint.Attr("tags"), constraint.Key("stage")}, Predicate: constraint.Enum{Values: []interface{}{"prod"}}}
+
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{
+		"tags":    {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		"service": {Type: "object", Properties: map[string]*Schema{"name": {Type: "string"}}},
+	}}
+	require.NoError(t, Constrain(schema, constraint.And{Terms: []constraint.Constraint{
+		env,
+		name,
+		// The entry in the branch is only indexed when the condition holds.
+		constraint.Implies{If: constraint.Not{Term: env}, Then: stage},
+	}}))
+	got, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"properties": {
+			"tags": {
+				"type": "object",
+				"properties": {"env": {"type": "string", "minLength": 1}},
+				"additionalProperties": {"type": "string"},
+				"required": ["env"],
+				"if": {"properties": {"env": {"not": {"minLength": 1}}}, "required": ["env"]},
+				"then": {"properties": {"stage": {"enum": ["prod"]}}}
+			},
+			"service": {"type": "object", "properties": {"name": {"type": "string", "minLength": 1}}}
+		}
+	}`, string(got))
+}
+
+func TestConstrainUnsupported(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     *Schema
+		constraint constraint.Constraint
+	}{
+		{
+			"indices of a list",
+			&Schema{Type: "array", Items: &Schema{Type: "string"}},
+			constraint.Atom{Path: constraint.Path{constraint.Keys()}, Predicate: constraint.Range{Minimum: new(float64)}},
+		},
+		{
+			"negated length of a value of unknown type",
+			&Schema{},
+			constraint.Not{Term: constraint.Atom{Predicate: constraint.Length{Min: intPtr(3), Max: intPtr(3)}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unsupported *UnsupportedError
+			assert.ErrorAs(t, Constrain(tt.schema, tt.constraint), &unsupported)
+		})
+	}
+}
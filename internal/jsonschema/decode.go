@@ -0,0 +1,57 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// UnmarshalJSON decodes a schema. The keywords that hold either a schema or
+// another JSON value, items and additionalProperties, are decoded into
+// *Schema and []*Schema like the converter builds them, so that the helpers
+// on Schema work on decoded schemas too.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	var raw struct {
+		Items                json.RawMessage `json:"items"`
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = Schema(p)
+
+	switch firstByte(raw.Items) {
+	case '{':
+		var items Schema
+		if err := json.Unmarshal(raw.Items, &items); err != nil {
+			return err
+		}
+		s.Items = &items
+	case '[':
+		var items []*Schema
+		if err := json.Unmarshal(raw.Items, &items); err != nil {
+			return err
+		}
+		s.Items = items
+	}
+	if firstByte(raw.AdditionalProperties) == '{' {
+		var values Schema
+		if err := json.Unmarshal(raw.AdditionalProperties, &values); err != nil {
+			return err
+		}
+		s.AdditionalProperties = &values
+	}
+	return nil
+}
+
+func firstByte(data json.RawMessage) byte {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0]
+}
@@ -0,0 +1,85 @@
+package jsonschema
+
+import "fmt"
+
+// Draft identifies a JSON Schema specification version.
+type Draft string
+
+// Supported JSON Schema drafts.
+const (
+	Draft07     Draft = "draft-07"
+	Draft201909 Draft = "2019-09"
+	Draft202012 Draft = "2020-12"
+)
+
+// ParseDraft returns the draft with the given name.
+func ParseDraft(name string) (Draft, error) {
+	switch d := Draft(name); d {
+	case Draft07, Draft201909, Draft202012:
+		return d, nil
+	}
+	return "", fmt.Errorf("unsupported JSON Schema draft %q (supported: %s, %s, %s)", name, Draft07, Draft201909, Draft202012)
+}
+
+// URI returns the $schema URI of the draft.
+func (d Draft) URI() string {
+	switch d {
+	case Draft201909:
+		return "https://json-schema.org/draft/2019-09/schema"
+	case Draft202012:
+		return "https://json-schema.org/draft/2020-12/schema"
+	}
+	return "http://json-schema.org/draft-07/schema#"
+}
+
+// Adapt rewrites a schema built with draft-07 keywords, plus the later
+// keywords draft-07 ignores, into the spelling of the given draft.
+func Adapt(schema *Schema, draft Draft) {
+	schema.Schema = draft.URI()
+	walk(schema, func(s *Schema) {
+		if draft == Draft07 {
+			s.MinContains = nil
+		}
+		if draft == Draft202012 {
+			// Tuples moved from the array form of items to prefixItems.
+			if tuple, ok := s.Items.([]*Schema); ok {
+				s.PrefixItems = tuple
+				s.Items = nil
+			}
+		}
+	})
+}
+
+// walk calls fn on schema and every subschema it contains.
+func walk(schema *Schema, fn func(*Schema)) {
+	if schema == nil {
+		return
+	}
+	fn(schema)
+	for _, prop := range schema.Properties {
+		walk(prop, fn)
+	}
+	switch items := schema.Items.(type) {
+	case *Schema:
+		walk(items, fn)
+	case []*Schema:
+		for _, item := range items {
+			walk(item, fn)
+		}
+	}
+	if ap, ok := schema.AdditionalProperties.(*Schema); ok {
+		walk(ap, fn)
+	}
+	for _, item := range schema.PrefixItems {
+		walk(item, fn)
+	}
+	for i := range schema.AnyOf {
+		walk(&schema.AnyOf[i], fn)
+	}
+	for _, sub := range schema.AllOf {
+		walk(sub, fn)
+	}
+	for _, sub := range []*Schema{schema.PropertyNames, schema.Contains, schema.Not, schema.If, schema.Then, schema.Else} {
+		walk(sub, fn)
+	}
+}
@@ -2,31 +2,184 @@ package jsonschema
 
 // Schema represents a JSON Schema object.
 type Schema struct {
-	Schema               string             `json:"$schema,omitempty"`
-	Type                 interface{}        `json:"type,omitempty"`
-	Title                string             `json:"title,omitempty"`
-	Description          string             `json:"description,omitempty"`
-	Default              interface{}        `json:"default,omitempty"`
-	Properties           map[string]*Schema `json:"properties,omitempty"`
-	Required             *[]string          `json:"required,omitempty"`
-	Items                interface{}        `json:"items,omitempty"` // Can be *Schema or []*Schema
-	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
-	PrefixItems          []*Schema          `json:"prefixItems,omitempty"`
-	AdditionalItems      *bool              `json:"additionalItems,omitempty"`
-	MinLength            *int               `json:"minLength,omitempty"`
-	MaxLength            *int               `json:"maxLength,omitempty"`
-	MinItems             *int               `json:"minItems,omitempty"`
-	MaxItems             *int               `json:"maxItems,omitempty"`
-	MinProperties        *int               `json:"minProperties,omitempty"`
-	MaxProperties        *int               `json:"maxProperties,omitempty"`
-	Pattern              string             `json:"pattern,omitempty"`
-	Minimum              *float64           `json:"minimum,omitempty"`
-	Maximum              *float64           `json:"maximum,omitempty"`
-	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
-	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
-	Enum                 []interface{}      `json:"enum,omitempty"`
-	UniqueItems          *bool              `json:"uniqueItems,omitempty"`
-	Sensitive            *bool              `json:"sensitive,omitempty"`
-	Nullable             *bool              `json:"nullable,omitempty"`
-	AnyOf                []Schema           `json:"anyOf,omitempty"`
+	Schema               string              `json:"$schema,omitempty"`
+	Type                 interface{}         `json:"type,omitempty"`
+	Title                string              `json:"title,omitempty"`
+	Description          string              `json:"description,omitempty"`
+	Default              interface{}         `json:"default,omitempty"`
+	Properties           map[string]*Schema  `json:"properties,omitempty"`
+	Required             *[]string           `json:"required,omitempty"`
+	Items                interface{}         `json:"items,omitempty"` // Can be *Schema or []*Schema
+	AdditionalProperties interface{}         `json:"additionalProperties,omitempty"`
+	PropertyNames        *Schema             `json:"propertyNames,omitempty"`
+	Dependencies         map[string][]string `json:"dependencies,omitempty"`      // only read from input schemas
+	DependentRequired    map[string][]string `json:"dependentRequired,omitempty"` // only read from input schemas
+	PrefixItems          []*Schema           `json:"prefixItems,omitempty"`
+	AdditionalItems      *bool               `json:"additionalItems,omitempty"`
+	MinLength            *int                `json:"minLength,omitempty"`
+	MaxLength            *int                `json:"maxLength,omitempty"`
+	MinItems             *int                `json:"minItems,omitempty"`
+	MaxItems             *int                `json:"maxItems,omitempty"`
+	MinProperties        *int                `json:"minProperties,omitempty"`
+	MaxProperties        *int                `json:"maxProperties,omitempty"`
+	Pattern              string              `json:"pattern,omitempty"`
+	Format               string              `json:"format,omitempty"`
+	Minimum              *float64            `json:"minimum,omitempty"`
+	Maximum              *float64            `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64            `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64            `json:"exclusiveMaximum,omitempty"`
+	MultipleOf           *float64            `json:"multipleOf,omitempty"`
+	Enum                 []interface{}       `json:"enum,omitempty"`
+	UniqueItems          *bool               `json:"uniqueItems,omitempty"`
+	Contains             *Schema             `json:"contains,omitempty"`
+	MinContains          *int                `json:"minContains,omitempty"` // 2019-09 and later
+	Sensitive            *bool               `json:"sensitive,omitempty"`
+	Nullable             *bool               `json:"nullable,omitempty"`
+	AnyOf                []Schema            `json:"anyOf,omitempty"`
+	AllOf                []*Schema           `json:"allOf,omitempty"`
+	Not                  *Schema             `json:"not,omitempty"`
+	If                   *Schema             `json:"if,omitempty"`
+	Then                 *Schema             `json:"then,omitempty"`
+	Else                 *Schema             `json:"else,omitempty"`
+}
+
+// Clone returns a deep copy of the schema, so that the copy can be refined
+// without affecting the original. Keywords held by pointer, such as
+// minLength, are shared: rules replace them rather than modify them in place.
+func (s *Schema) Clone() *Schema {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	if s.Properties != nil {
+		c.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, prop := range s.Properties {
+			c.Properties[name] = prop.Clone()
+		}
+	}
+	if s.Required != nil {
+		required := append([]string{}, *s.Required...)
+		c.Required = &required
+	}
+	switch items := s.Items.(type) {
+	case *Schema:
+		c.Items = items.Clone()
+	case []*Schema:
+		c.Items = cloneAll(items)
+	}
+	if ap, ok := s.AdditionalProperties.(*Schema); ok {
+		c.AdditionalProperties = ap.Clone()
+	}
+	c.Dependencies = cloneDependencies(s.Dependencies)
+	c.DependentRequired = cloneDependencies(s.DependentRequired)
+	if s.PrefixItems != nil {
+		c.PrefixItems = cloneAll(s.PrefixItems)
+	}
+	if s.Enum != nil {
+		c.Enum = append([]interface{}{}, s.Enum...)
+	}
+	if s.AnyOf != nil {
+		c.AnyOf = make([]Schema, len(s.AnyOf))
+		for i := range s.AnyOf {
+			c.AnyOf[i] = *s.AnyOf[i].Clone()
+		}
+	}
+	if s.AllOf != nil {
+		c.AllOf = cloneAll(s.AllOf)
+	}
+	c.PropertyNames = s.PropertyNames.Clone()
+	c.Contains = s.Contains.Clone()
+	c.Not = s.Not.Clone()
+	c.If = s.If.Clone()
+	c.Then = s.Then.Clone()
+	c.Else = s.Else.Clone()
+	return &c
+}
+
+func cloneAll(schemas []*Schema) []*Schema {
+	clones := make([]*Schema, len(schemas))
+	for i, schema := range schemas {
+		clones[i] = schema.Clone()
+	}
+	return clones
+}
+
+func cloneDependencies(deps map[string][]string) map[string][]string {
+	if deps == nil {
+		return nil
+	}
+	clones := make(map[string][]string, len(deps))
+	for name, required := range deps {
+		clones[name] = append([]string{}, required...)
+	}
+	return clones
+}
+
+// BaseType returns the primary non-null type of the schema. Nullable
+// variables hold their type in an anyOf alongside null, so the anyOf is
+// searched when the schema has no type of its own.
+func (s *Schema) BaseType() string {
+	switch t := s.Type.(type) {
+	case string:
+		if t != "null" {
+			return t
+		}
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+	if s.Type == nil {
+		for i := range s.AnyOf {
+			if t := s.AnyOf[i].BaseType(); t != "" {
+				return t
+			}
+		}
+	}
+	return ""
+}
+
+// AcceptsNull reports whether the schema lists null among its types, either
+// directly or in an anyOf.
+func (s *Schema) AcceptsNull() bool {
+	switch t := s.Type.(type) {
+	case string:
+		return t == "null"
+	case []interface{}:
+		for _, v := range t {
+			if v == "null" {
+				return true
+			}
+		}
+	}
+	for i := range s.AnyOf {
+		if s.AnyOf[i].AcceptsNull() {
+			return true
+		}
+	}
+	return false
+}
+
+// TupleItems returns the positional item schemas of a tuple, from either the
+// array form of items or prefixItems, or nil if the schema is not a tuple.
+func (s *Schema) TupleItems() []*Schema {
+	if items, ok := s.Items.([]*Schema); ok {
+		return items
+	}
+	return s.PrefixItems
+}
+
+// ElementSchema returns the schema of the elements of a list or set, or nil.
+func (s *Schema) ElementSchema() *Schema {
+	items, _ := s.Items.(*Schema)
+	return items
+}
+
+// MapValues returns the schema of the values of a map, or nil if the schema
+// does not describe a map.
+func (s *Schema) MapValues() *Schema {
+	values, _ := s.AdditionalProperties.(*Schema)
+	return values
 }
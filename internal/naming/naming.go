@@ -0,0 +1,56 @@
+// Package naming converts Terraform names into identifiers of the languages
+// that tfschema generates code for.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Words splits a name such as "terraform-aws-vpc" or "subnetIds" into its
+// lower-case words.
+func Words(name string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && len(current) > 0:
+			// A new word starts at subnetIds and at the last capital of HTTPServer.
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return words
+}
+
+// Pascal converts a name to PascalCase, e.g. "terraform-aws-vpc" to
+// "TerraformAwsVpc". Names that would start with a digit are prefixed with
+// prefix.
+func Pascal(name, prefix string) string {
+	var b strings.Builder
+	for _, word := range Words(name) {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	result := b.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = prefix + result
+	}
+	return result
+}
@@ -0,0 +1,27 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPascal(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"terraform-aws-vpc", "TerraformAwsVpc"},
+		{"subnet_ids", "SubnetIds"},
+		{"subnetIds", "SubnetIds"},
+		{"HTTPServer", "HttpServer"},
+		{"10-network", "Module10Network"},
+		{"", "Module"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Pascal(tt.name, "Module"))
+		})
+	}
+}
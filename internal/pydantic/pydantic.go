@@ -0,0 +1,348 @@
+// Package pydantic generates pydantic v2 models for the inputs of a Terraform
+// module from its converted variables.
+package pydantic
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/naming"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+)
+
+// identifierPattern matches attribute names that are valid Python
+// identifiers.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// keywords are the Python keywords and soft keywords that cannot name a
+// field.
+var keywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true,
+	"def": true, "del": true, "elif": true, "else": true, "except": true,
+	"finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true,
+	"not": true, "or": true, "pass": true, "raise": true, "return": true,
+	"try": true, "while": true, "with": true, "yield": true,
+}
+
+// syntax renders Python literals. JSON string escapes are valid in Python.
+var syntax = render.Syntax{Null: "None", True: "True", False: "False", String: render.JSONString}
+
+// model is a generated BaseModel class.
+type model struct {
+	name   string
+	doc    string
+	fields []string
+	alias  bool            // some field has an alias
+	used   map[string]bool // field names
+}
+
+// generator accumulates the classes and imports of a generated file.
+type generator struct {
+	models  []*model // in order of declaration, nested models first
+	names   map[string]bool
+	typing  map[string]bool
+	imports map[string]bool // names imported from pydantic
+}
+
+// Generate renders a Python module declaring a `<Module>Inputs` pydantic
+// model with one field per variable, in declaration order. Objects become
+// nested models, declared before the models using them. Variables with a
+// default and optional() attributes are Optional, enums are Literal, length,
+// range and pattern keywords become Field constraints and sensitive strings
+// are SecretStr.
+func Generate(module string, variables []converter.Variable) string {
+	g := &generator{
+		names:   map[string]bool{},
+		typing:  map[string]bool{},
+		imports: map[string]bool{"BaseModel": true},
+	}
+
+	rootName := naming.Pascal(module, "Module") + "Inputs"
+	g.names[rootName] = true
+	root := &model{name: rootName, doc: "Input variables of the module.", used: map[string]bool{}}
+	for _, v := range variables {
+		var def interface{}
+		hasDefault := !v.Required
+		if hasDefault {
+			def = v.Schema.Default
+		}
+		root.fields = append(root.fields, g.field(root, v.Name, v.Schema, naming.Pascal(v.Name, "Var"), hasDefault, def))
+	}
+	g.models = append(g.models, root)
+
+	var b bytes.Buffer
+	b.WriteString("# Code generated by tfschema. DO NOT EDIT.\n\n")
+	if len(g.typing) > 0 {
+		fmt.Fprintf(&b, "from typing import %s\n\n", strings.Join(render.SortedKeys(g.typing), ", "))
+	}
+	fmt.Fprintf(&b, "from pydantic import %s\n", strings.Join(render.SortedKeys(g.imports), ", "))
+	for _, m := range g.models {
+		fmt.Fprintf(&b, "\n\nclass %s(BaseModel):\n", m.name)
+		fmt.Fprintf(&b, "    %s\n", docstring(m.doc))
+		if m.alias {
+			b.WriteString("\n    model_config = ConfigDict(populate_by_name=True)\n")
+		}
+		if len(m.fields) > 0 {
+			b.WriteString("\n")
+		}
+		for _, f := range m.fields {
+			fmt.Fprintf(&b, "    %s\n", f)
+		}
+	}
+	return b.String()
+}
+
+// field renders a field of m for an attribute or variable. Fields that may be
+// left out are Optional, defaulting to def.
+func (g *generator) field(m *model, name string, schema *jsonschema.Schema, typeName string, optional bool, def interface{}) string {
+	sensitive := schema.Sensitive != nil && *schema.Sensitive
+	typ := g.typeOf(schema, typeName, sensitive)
+	if optional && !strings.HasPrefix(typ, "Optional[") && typ != "Any" {
+		g.typing["Optional"] = true
+		typ = "Optional[" + typ + "]"
+	}
+
+	var args []string
+	if optional {
+		args = append(args, "default="+syntax.Literal(def))
+		if def != nil && g.hasModel(schema) {
+			// pydantic does not validate defaults, which would leave a
+			// dict where a model is expected.
+			args = append(args, "validate_default=True")
+		}
+	}
+	fieldName := name
+	if !identifierPattern.MatchString(name) || keywords[name] || m.used[name] {
+		fieldName = pythonName(name)
+		for i := 2; m.used[fieldName]; i++ {
+			fieldName = fmt.Sprintf("%s_%d", pythonName(name), i)
+		}
+		args = append(args, "alias="+syntax.Literal(name))
+		m.alias = true
+		g.imports["ConfigDict"] = true
+	}
+	m.used[fieldName] = true
+	if schema.Description != "" {
+		args = append(args, "description="+syntax.Literal(strings.TrimSpace(schema.Description)))
+	}
+	args = append(args, constraints(schema, typ)...)
+	if sensitive && !strings.Contains(typ, "SecretStr") {
+		args = append(args, "repr=False")
+	}
+
+	switch {
+	case len(args) == 0:
+		return fmt.Sprintf("%s: %s", fieldName, typ)
+	case optional && len(args) == 1:
+		return fmt.Sprintf("%s: %s = %s", fieldName, typ, syntax.Literal(def))
+	}
+	g.imports["Field"] = true
+	return fmt.Sprintf("%s: %s = Field(%s)", fieldName, typ, strings.Join(args, ", "))
+}
+
+// typeOf renders the Python type of a schema, declaring models for objects.
+// Struct models are named after name, the path of the value they describe.
+func (g *generator) typeOf(schema *jsonschema.Schema, name string, sensitive bool) string {
+	t := g.baseTypeOf(schema, name, sensitive)
+	if schema.AcceptsNull() && t != "Any" {
+		g.typing["Optional"] = true
+		t = "Optional[" + t + "]"
+	}
+	return t
+}
+
+func (g *generator) baseTypeOf(schema *jsonschema.Schema, name string, sensitive bool) string {
+	if len(schema.Enum) > 0 {
+		g.typing["Literal"] = true
+		values := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			values[i] = syntax.Literal(v)
+		}
+		return "Literal[" + strings.Join(values, ", ") + "]"
+	}
+
+	switch schema.BaseType() {
+	case "string":
+		if sensitive {
+			g.imports["SecretStr"] = true
+			return "SecretStr"
+		}
+		return "str"
+	case "number":
+		if m := schema.MultipleOf; m == nil || *m == 0 || *m != float64(int64(*m)) {
+			return "float"
+		}
+		// Multiples of a whole number are whole.
+		return "int"
+	case "integer":
+		return "int"
+	case "boolean":
+		return "bool"
+	case "array":
+		if tuple := schema.TupleItems(); tuple != nil {
+			g.typing["Tuple"] = true
+			items := make([]string, len(tuple))
+			for i, item := range tuple {
+				items[i] = g.element(item, fmt.Sprintf("%s%d", name, i))
+			}
+			return "Tuple[" + strings.Join(items, ", ") + "]"
+		}
+		g.typing["List"] = true
+		if elem := schema.ElementSchema(); elem != nil {
+			return "List[" + g.element(elem, name+"Item") + "]"
+		}
+		g.typing["Any"] = true
+		return "List[Any]"
+	case "object":
+		if values := schema.MapValues(); values != nil {
+			g.typing["Dict"] = true
+			return "Dict[str, " + g.element(values, name+"Value") + "]"
+		}
+		if len(schema.Properties) == 0 {
+			g.typing["Any"], g.typing["Dict"] = true, true
+			return "Dict[str, Any]"
+		}
+		return g.model(schema, name)
+	}
+	g.typing["Any"] = true
+	return "Any"
+}
+
+// element renders the type of a list, tuple or map element, attaching its
+// constraints with Annotated.
+func (g *generator) element(schema *jsonschema.Schema, name string) string {
+	t := g.typeOf(schema, name, false)
+	if args := constraints(schema, t); len(args) > 0 {
+		g.typing["Annotated"] = true
+		g.imports["Field"] = true
+		return fmt.Sprintf("Annotated[%s, Field(%s)]", t, strings.Join(args, ", "))
+	}
+	return t
+}
+
+// model declares a model for the attributes of an object, in alphabetical
+// order, and returns its name. Attributes declared with optional() default
+// to the default given to optional(), or to None.
+func (g *generator) model(schema *jsonschema.Schema, name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.names[unique] = true
+
+	required := map[string]bool{}
+	if schema.Required != nil {
+		for _, attr := range *schema.Required {
+			required[attr] = true
+		}
+	}
+	m := &model{name: unique, doc: "Attributes of an object.", used: map[string]bool{}}
+	if schema.Description != "" {
+		m.doc = strings.TrimSpace(schema.Description)
+	}
+	for _, attr := range render.SortedKeys(schema.Properties) {
+		prop := schema.Properties[attr]
+		m.fields = append(m.fields, g.field(m, attr, prop, unique+naming.Pascal(attr, "Attr"), !required[attr], prop.Default))
+	}
+	g.models = append(g.models, m)
+	return unique
+}
+
+// hasModel reports whether the type of a schema involves a model.
+func (g *generator) hasModel(schema *jsonschema.Schema) bool {
+	switch schema.BaseType() {
+	case "array":
+		if tuple := schema.TupleItems(); tuple != nil {
+			for _, item := range tuple {
+				if g.hasModel(item) {
+					return true
+				}
+			}
+			return false
+		}
+		if elem := schema.ElementSchema(); elem != nil {
+			return g.hasModel(elem)
+		}
+	case "object":
+		if values := schema.MapValues(); values != nil {
+			return g.hasModel(values)
+		}
+		return len(schema.Properties) > 0
+	}
+	return false
+}
+
+// constraints renders the length, range and pattern keywords of a schema as
+// Field arguments for a value of type typ. SecretStr only supports length
+// constraints, so the pattern of a sensitive string is left out.
+func constraints(schema *jsonschema.Schema, typ string) []string {
+	var args []string
+	minLength, maxLength := schema.MinLength, schema.MaxLength
+	switch schema.BaseType() {
+	case "array":
+		minLength, maxLength = schema.MinItems, schema.MaxItems
+		if schema.TupleItems() != nil {
+			// Tuple already fixes the length.
+			minLength, maxLength = nil, nil
+		}
+	case "object":
+		minLength, maxLength = schema.MinProperties, schema.MaxProperties
+	}
+	if minLength != nil {
+		args = append(args, fmt.Sprintf("min_length=%d", *minLength))
+	}
+	if maxLength != nil {
+		args = append(args, fmt.Sprintf("max_length=%d", *maxLength))
+	}
+	for _, bound := range []struct {
+		name  string
+		value *float64
+	}{
+		{"ge", schema.Minimum},
+		{"gt", schema.ExclusiveMinimum},
+		{"le", schema.Maximum},
+		{"lt", schema.ExclusiveMaximum},
+		{"multiple_of", schema.MultipleOf},
+	} {
+		if bound.value != nil {
+			args = append(args, bound.name+"="+render.Number(*bound.value))
+		}
+	}
+	if schema.Pattern != "" && !strings.Contains(typ, "SecretStr") {
+		args = append(args, "pattern="+syntax.Literal(schema.Pattern))
+	}
+	return args
+}
+
+// pythonName converts an attribute name to a snake_case identifier, such as
+// "availability_zones" for "availability-zones" and "class_" for "class".
+func pythonName(name string) string {
+	words := naming.Words(name)
+	ident := strings.Join(words, "_")
+	if ident == "" || ident[0] >= '0' && ident[0] <= '9' {
+		ident = "field_" + ident
+	}
+	if keywords[ident] {
+		ident += "_"
+	}
+	return ident
+}
+
+// docstring renders text as a Python docstring.
+func docstring(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"""`, `\"\"\"`)
+	if strings.HasSuffix(text, `"`) {
+		text += " "
+	}
+	if !strings.Contains(text, "\n") {
+		return `"""` + text + `"""`
+	}
+	return `"""` + strings.ReplaceAll(text, "\n", "\n    ") + "\n    " + `"""`
+}
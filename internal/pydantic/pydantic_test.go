@@ -0,0 +1,137 @@
+package pydantic
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = length(var.name) >= 3 && length(var.name) <= 32
+    error_message = "Between 3 and 32 characters."
+  }
+}
+
+variable "environment" {
+  type    = string
+  default = "dev"
+
+  validation {
+    condition     = contains(["dev", "prod"], var.environment)
+    error_message = "Unknown environment."
+  }
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    subnets = optional(list(string), [])
+    port    = optional(number, 8080)
+    peer    = tuple([string, number])
+    "availability-zones" = list(string)
+  })
+  default = null
+}
+
+variable "replicas" {
+  type    = number
+  default = 2
+
+  validation {
+    condition     = var.replicas >= 1 && var.replicas <= 10
+    error_message = "Between 1 and 10 replicas."
+  }
+}
+
+variable "disk_size" {
+  type = number
+
+  validation {
+    condition     = var.disk_size % 8 == 0
+    error_message = "A multiple of 8."
+  }
+}
+
+variable "tags" {
+  type = map(string)
+
+  validation {
+    condition     = alltrue([for v in values(var.tags) : can(regex("^[a-z]+$", v))])
+    error_message = "Lower-case tag values."
+  }
+}
+
+variable "servers" {
+  type = list(object({ host = string, port = number }))
+  default = [{ host = "a", port = 80 }]
+}
+`)
+	require.NoError(t, err)
+
+	want := `# Code generated by tfschema. DO NOT EDIT.
+
+from typing import Annotated, Dict, List, Literal, Optional, Tuple
+
+from pydantic import BaseModel, ConfigDict, Field, SecretStr
+
+
+class Network(BaseModel):
+    """Attributes of an object."""
+
+    model_config = ConfigDict(populate_by_name=True)
+
+    availability_zones: List[str] = Field(alias="availability-zones")
+    cidr: str
+    peer: Tuple[str, float]
+    port: Optional[float] = 8080
+    subnets: Optional[List[str]] = []
+
+
+class ServersItem(BaseModel):
+    """Attributes of an object."""
+
+    host: str
+    port: float
+
+
+class TerraformAwsVpcInputs(BaseModel):
+    """Input variables of the module."""
+
+    name: str = Field(description="Resource name", min_length=3, max_length=32)
+    environment: Optional[Literal["dev", "prod"]] = "dev"
+    password: SecretStr
+    network: Optional[Network] = None
+    replicas: Optional[float] = Field(default=2, ge=1, le=10)
+    disk_size: int = Field(multiple_of=8)
+    tags: Dict[str, Annotated[str, Field(pattern="^[a-z]+$")]]
+    servers: Optional[List[ServersItem]] = Field(default=[{"host": "a", "port": 80}], validate_default=True)
+`
+	assert.Equal(t, want, Generate("terraform-aws-vpc", c.Variables()))
+}
+
+func TestLiteral(t *testing.T) {
+	assert.Equal(t, "None", syntax.Literal(nil))
+	assert.Equal(t, "True", syntax.Literal(true))
+	assert.Equal(t, `"a\\d<b>"`, syntax.Literal(`a\d<b>`))
+	assert.Equal(t, `{"a": [1, False]}`, syntax.Literal(map[string]interface{}{"a": []interface{}{1.0, false}}))
+}
+
+func TestPythonName(t *testing.T) {
+	assert.Equal(t, "availability_zones", pythonName("availability-zones"))
+	assert.Equal(t, "class_", pythonName("class"))
+	assert.Equal(t, "field_2fa", pythonName("2fa"))
+}
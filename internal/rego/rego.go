@@ -0,0 +1,570 @@
+// Package rego generates an Open Policy Agent policy checking the inputs of
+// a Terraform module before a plan. The type constraint and the translated
+// validation blocks of each variable become rego.v1 deny rules over
+// input.<variable>, so that policies can run on a terraform.tfvars.json.
+package rego
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+	"github.com/alex-tw-lam/tfschema/internal/validation"
+)
+
+// integerPattern matches the strings Terraform's parseint accepts in base 10.
+const integerPattern = `^[+-]?[0-9]+$`
+
+// syntax renders Rego literals, which have the syntax of JSON.
+var syntax = render.Syntax{Null: "null", True: "true", False: "false", String: render.JSONString}
+
+// generator renders rules into b. Helpers go to pending, which is written
+// after the deny rule calling them.
+type generator struct {
+	b       strings.Builder
+	pending strings.Builder
+	helpers int
+	vars    int
+}
+
+// Generate renders a policy in package pkg. Each variable gets a rule for
+// its type, then one rule per validation, denying with its error_message.
+// Validations that could not be translated, or whose constraint has no Rego
+// form, are left as TODO comments with their HCL.
+func Generate(pkg string, variables []converter.Variable, validations []constraint.Validation, untranslatable []validation.Untranslatable) string {
+	g := &generator{}
+	g.b.WriteString("# Code generated by tfschema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.b, "package %s\n\nimport rego.v1\n", pkg)
+
+	for _, v := range variables {
+		g.typeRule(v)
+		for _, val := range validations {
+			if val.Variable == v.Name {
+				g.validationRule(val)
+			}
+		}
+		for _, u := range untranslatable {
+			if u.Variable == v.Name {
+				fmt.Fprintf(&g.b, "\n# TODO: %s\n", render.CommentLine(fmt.Sprintf("%q is not checked: %s", u.ErrorMessage, u.Reason)))
+				render.WriteSource(&g.b, "#", u.Source)
+			}
+		}
+	}
+	return g.b.String()
+}
+
+// typeRule renders the rule denying values of v that do not have its type.
+func (g *generator) typeRule(v converter.Variable) {
+	ref := "input" + accessor(constraint.Attr(v.Name))
+	var guards []string
+	if !v.Required || v.Schema.AcceptsNull() {
+		guards = []string{ref + " != null"}
+	}
+	defs := g.typeChecks(v.Schema, ref, guards)
+	if len(defs) == 0 {
+		return
+	}
+	typ := strings.Join(strings.Fields(v.Type), " ")
+	if typ == "" {
+		typ = v.Schema.BaseType()
+	}
+	fmt.Fprintf(&g.b, "\n# %s\n", render.CommentLine(fmt.Sprintf("var.%s: %s", v.Name, typ)))
+	g.deny(defs, fmt.Sprintf("The %s variable must be of type %s.", v.Name, typ))
+}
+
+// typeChecks returns the rule bodies, one per check, that hold when the
+// value at ref, once guards hold, is not of the type of s. Values of type
+// any are not checked.
+func (g *generator) typeChecks(s *jsonschema.Schema, ref string, guards []string) [][]string {
+	with := func(lits ...string) []string {
+		return append(append([]string{}, guards...), lits...)
+	}
+	switch s.BaseType() {
+	case "string":
+		return [][]string{with("not is_string(" + ref + ")")}
+	case "number", "integer":
+		return [][]string{with("not is_number(" + ref + ")")}
+	case "boolean":
+		return [][]string{with("not is_boolean(" + ref + ")")}
+	case "array":
+		defs := [][]string{with("not is_array(" + ref + ")")}
+		if tuple := s.TupleItems(); tuple != nil {
+			defs = append(defs, with("is_array("+ref+")", fmt.Sprintf("count(%s) != %d", ref, len(tuple))))
+			for i, item := range tuple {
+				elem := fmt.Sprintf("%s[%d]", ref, i)
+				defs = append(defs, g.typeChecks(item, elem, with(append([]string{"is_array(" + ref + ")"}, present(elem, true, item)...)...))...)
+			}
+			return defs
+		}
+		if items := s.ElementSchema(); items != nil {
+			x := g.fresh()
+			defs = append(defs, g.typeChecks(items, x, with("is_array("+ref+")", x+" := "+ref+"[_]", x+" != null"))...)
+		}
+		return defs
+	case "object":
+		defs := [][]string{with("not is_object(" + ref + ")")}
+		if values := s.MapValues(); values != nil {
+			x := g.fresh()
+			return append(defs, g.typeChecks(values, x, with("is_object("+ref+")", x+" := "+ref+"[_]", x+" != null"))...)
+		}
+		required := map[string]bool{}
+		if s.Required != nil {
+			for _, name := range *s.Required {
+				required[name] = true
+			}
+		}
+		for _, name := range render.SortedKeys(s.Properties) {
+			prop := s.Properties[name]
+			attr := ref + accessor(constraint.Attr(name))
+			defs = append(defs, g.typeChecks(prop, attr, with(append([]string{"is_object(" + ref + ")"}, present(attr, required[name], prop)...)...))...)
+		}
+		return defs
+	}
+	return nil
+}
+
+// present returns the guard of a check on the value at ref: a value that
+// may be left out or null is only checked when it is set, and a required
+// value must not be absent, though it may be null when s accepts it.
+func present(ref string, required bool, s *jsonschema.Schema) []string {
+	switch {
+	case !required:
+		return []string{ref + " != null"}
+	case s.AcceptsNull():
+		return []string{"not is_null(" + ref + ")"}
+	}
+	return nil
+}
+
+// validationRule renders the rule denying the inputs that fail v.
+func (g *generator) validationRule(v constraint.Validation) {
+	g.pending.Reset()
+	defs, err := g.violations(v.Constraint, "input", true)
+	if err != nil {
+		fmt.Fprintf(&g.b, "\n# TODO: %s\n", render.CommentLine(fmt.Sprintf("%q is not checked: %v", v.ErrorMessage, err)))
+		render.WriteSource(&g.b, "#", v.Source)
+		return
+	}
+	if len(defs) == 0 {
+		return
+	}
+	g.b.WriteString("\n")
+	render.WriteSource(&g.b, "#", v.Source)
+	g.deny(defs, v.ErrorMessage)
+}
+
+// violations returns rule bodies over root, one of which holds when c does
+// not. Failing atoms, conjunctions, negations and implications are written
+// out; disjunctions are negated as a whole.
+func (g *generator) violations(c constraint.Constraint, root string, vacuous bool) ([][]string, error) {
+	switch n := c.(type) {
+	case constraint.Atom:
+		walk, refs := g.walk(n.Path, root)
+		failures, err := g.failures(n.Predicate, refs[len(refs)-1])
+		if err != nil {
+			return nil, err
+		}
+		defs := make([][]string, len(failures))
+		for i, failure := range failures {
+			defs[i] = append(append([]string{}, walk...), failure...)
+		}
+		if !vacuous {
+			defs = append(defs, g.missing(n.Path, root)...)
+		}
+		return defs, nil
+	case constraint.And:
+		var defs [][]string
+		for _, term := range n.Terms {
+			termDefs, err := g.violations(term, root, vacuous)
+			if err != nil {
+				return nil, err
+			}
+			defs = append(defs, termDefs...)
+		}
+		return defs, nil
+	case constraint.Not:
+		lits, err := g.condition(n.Term, root, !vacuous)
+		if err != nil {
+			return nil, err
+		}
+		return [][]string{lits}, nil
+	case constraint.Implies:
+		cond, err := g.condition(n.If, root, false)
+		if err != nil {
+			return nil, err
+		}
+		var defs [][]string
+		if n.Then != nil {
+			thenDefs, err := g.violations(n.Then, root, vacuous)
+			if err != nil {
+				return nil, err
+			}
+			for _, def := range thenDefs {
+				defs = append(defs, append(append([]string{}, cond...), def...))
+			}
+		}
+		if n.Else != nil {
+			elseDefs, err := g.violations(n.Else, root, vacuous)
+			if err != nil {
+				return nil, err
+			}
+			negated := g.negate(root, cond)
+			for _, def := range elseDefs {
+				defs = append(defs, append([]string{negated}, def...))
+			}
+		}
+		return defs, nil
+	case constraint.Never:
+		return [][]string{{"true"}}, nil
+	}
+	lits, err := g.condition(c, root, vacuous)
+	if err != nil {
+		return nil, err
+	}
+	return [][]string{{g.negate(root, lits)}}, nil
+}
+
+// deny renders a deny rule with message when any of defs holds, followed by
+// the pending helpers. Several definitions get a helper rule of their own.
+func (g *generator) deny(defs [][]string, message string) {
+	body := defs[0]
+	if len(defs) > 1 {
+		body = []string{g.rule("input", "invalid", defs)}
+	}
+	g.b.WriteString("deny contains msg if {\n")
+	for _, lit := range body {
+		fmt.Fprintf(&g.b, "\t%s\n", lit)
+	}
+	fmt.Fprintf(&g.b, "\tmsg := %s\n}\n", render.JSONString(message))
+	g.b.WriteString(g.pending.String())
+	g.pending.Reset()
+}
+
+// rule renders a helper holding when any of defs holds and returns the
+// expression calling it. Helpers over input are rules; those over a local
+// variable, such as an element, are functions of it.
+func (g *generator) rule(root, kind string, defs [][]string) string {
+	g.helpers++
+	name := fmt.Sprintf("%s_%d", kind, g.helpers)
+	head := name
+	if root != "input" {
+		head = name + "(" + root + ")"
+	}
+	for _, def := range defs {
+		if len(def) == 0 {
+			def = []string{"true"}
+		}
+		fmt.Fprintf(&g.pending, "\n%s if {\n", head)
+		for _, lit := range def {
+			fmt.Fprintf(&g.pending, "\t%s\n", lit)
+		}
+		g.pending.WriteString("}\n")
+	}
+	return head
+}
+
+func (g *generator) fresh() string {
+	g.vars++
+	return fmt.Sprintf("x%d", g.vars)
+}
+
+// condition returns literals over root that all hold when c does, writing
+// the helpers they call. As in constraint.Evaluate, vacuous is the result of
+// an atom whose path leads to an absent or null value.
+func (g *generator) condition(c constraint.Constraint, root string, vacuous bool) ([]string, error) {
+	switch n := c.(type) {
+	case constraint.Atom:
+		return g.atom(n, root, vacuous)
+	case constraint.And:
+		var lits []string
+		for _, term := range n.Terms {
+			termLits, err := g.condition(term, root, vacuous)
+			if err != nil {
+				return nil, err
+			}
+			lits = append(lits, termLits...)
+		}
+		return lits, nil
+	case constraint.Or:
+		var defs [][]string
+		for _, term := range n.Terms {
+			termLits, err := g.condition(term, root, vacuous)
+			if err != nil {
+				return nil, err
+			}
+			defs = append(defs, termLits)
+		}
+		if len(defs) == 1 {
+			return defs[0], nil
+		}
+		return []string{g.rule(root, "any", defs)}, nil
+	case constraint.Not:
+		lits, err := g.condition(n.Term, root, !vacuous)
+		if err != nil {
+			return nil, err
+		}
+		return []string{g.negate(root, lits)}, nil
+	case constraint.Implies:
+		cond, err := g.condition(n.If, root, false)
+		if err != nil {
+			return nil, err
+		}
+		then, otherwise := []string{}, []string{}
+		if n.Then != nil {
+			if then, err = g.condition(n.Then, root, vacuous); err != nil {
+				return nil, err
+			}
+		}
+		if n.Else != nil {
+			if otherwise, err = g.condition(n.Else, root, vacuous); err != nil {
+				return nil, err
+			}
+		}
+		if n.Then == nil && n.Else == nil {
+			return nil, nil
+		}
+		var defs [][]string
+		switch {
+		case n.Else == nil:
+			defs = [][]string{{g.negate(root, cond)}, append(append([]string{}, cond...), then...)}
+		case n.Then == nil:
+			defs = [][]string{cond, append([]string{g.negate(root, cond)}, otherwise...)}
+		default:
+			negated := g.negate(root, cond)
+			defs = [][]string{append(append([]string{}, cond...), then...), append([]string{negated}, otherwise...)}
+		}
+		return []string{g.rule(root, "implied", defs)}, nil
+	case constraint.Never:
+		return []string{"false"}, nil
+	}
+	return nil, fmt.Errorf("unsupported constraint %T", c)
+}
+
+// negate returns a literal holding when the literals do not all hold.
+func (g *generator) negate(root string, lits []string) string {
+	if len(lits) == 1 {
+		if strings.HasPrefix(lits[0], "not ") {
+			return strings.TrimPrefix(lits[0], "not ")
+		}
+		return "not " + lits[0]
+	}
+	return "not " + g.rule(root, "all", [][]string{lits})
+}
+
+// atom returns the literals of an atom: no value at its path fails the
+// predicate and, unless vacuous, no value on the way is absent or null.
+func (g *generator) atom(a constraint.Atom, root string, vacuous bool) ([]string, error) {
+	defs, err := g.violations(constraint.Atom{Path: a.Path, Predicate: a.Predicate}, root, true)
+	if err != nil {
+		return nil, err
+	}
+	lits := []string{"not " + g.rule(root, "invalid", defs)}
+	// Nothing is missing on the way to input itself.
+	if missing := g.missing(a.Path, root); !vacuous && len(missing) > 0 {
+		lits = append(lits, "not "+g.rule(root, "missing", missing))
+	}
+	return lits, nil
+}
+
+// walk returns the literals binding every value at path from root, and the
+// reference of the value after each segment.
+func (g *generator) walk(path constraint.Path, root string) ([]string, []string) {
+	var lits []string
+	refs := []string{root}
+	ref := root
+	for _, segment := range path {
+		switch segment.Kind {
+		case constraint.WildcardSegment:
+			x := g.fresh()
+			lits = append(lits, x+" := "+ref+"[_]")
+			ref = x
+		case constraint.KeysSegment:
+			x := g.fresh()
+			lits = append(lits, "is_object("+ref+")", "_ = "+ref+"["+x+"]")
+			ref = x
+		default:
+			ref += accessor(segment)
+		}
+		refs = append(refs, ref)
+	}
+	return lits, refs
+}
+
+// missing returns the definitions of a helper holding when path leads to
+// an absent or null value from root, one per segment.
+func (g *generator) missing(path constraint.Path, root string) [][]string {
+	var defs [][]string
+	if root != "input" {
+		defs = append(defs, []string{root + " == null"})
+	}
+	for i, segment := range path {
+		walk, refs := g.walk(path[:i], root)
+		parent := refs[len(refs)-1]
+		var ways [][]string
+		switch segment.Kind {
+		case constraint.WildcardSegment:
+			x := g.fresh()
+			ways = [][]string{{parent + " != null", x + " := " + parent + "[_]", x + " == null"}}
+		case constraint.KeysSegment:
+			ways = [][]string{{parent + " != null", "not is_object(" + parent + ")"}}
+		case constraint.IndexSegment:
+			ways = [][]string{
+				{parent + " != null", "is_array(" + parent + ")", fmt.Sprintf("count(%s) <= %d", parent, segment.Index)},
+				{parent + " != null", parent + accessor(segment) + " == null"},
+			}
+		default:
+			ways = [][]string{{parent + " != null", absent(parent, segment.Name)}}
+		}
+		for _, lits := range ways {
+			if i == 0 && root == "input" {
+				lits = lits[1:]
+			}
+			defs = append(defs, append(append([]string{}, walk...), lits...))
+		}
+	}
+	return defs
+}
+
+// absent returns a literal holding when the object x has no property name or
+// a null one. Writing it as not x.name != null would not do, as OPA leaves
+// the negation of a comparison with an undefined operand undefined.
+func absent(x, name string) string {
+	return fmt.Sprintf("object.get(%s, %s, null) == null", x, render.JSONString(name))
+}
+
+// failures returns the literals, one list per way of failing, that hold when
+// the non-null value x fails p. As in constraint.Evaluate, a predicate on a
+// value of another type holds.
+func (g *generator) failures(p constraint.Predicate, x string) ([][]string, error) {
+	switch p := p.(type) {
+	case constraint.Length:
+		var defs [][]string
+		if p.Min != nil {
+			defs = append(defs, []string{fmt.Sprintf("count(%s) < %d", x, *p.Min)})
+		}
+		if p.Max != nil {
+			defs = append(defs, []string{fmt.Sprintf("count(%s) > %d", x, *p.Max)})
+		}
+		return defs, nil
+	case constraint.Range:
+		var defs [][]string
+		for _, bound := range []struct {
+			value *float64
+			op    string
+		}{{p.Minimum, "<"}, {p.Maximum, ">"}, {p.ExclusiveMinimum, "<="}, {p.ExclusiveMaximum, ">="}} {
+			if bound.value != nil {
+				defs = append(defs, []string{"is_number(" + x + ")", x + " " + bound.op + " " + render.Number(*bound.value)})
+			}
+		}
+		return defs, nil
+	case constraint.Pattern:
+		if _, err := regexp.Compile(p.Regexp()); err != nil {
+			return nil, fmt.Errorf("pattern %q is not supported: %w", p.Regexp(), err)
+		}
+		return [][]string{{"is_string(" + x + ")", "not regex.match(" + render.JSONString(p.Regexp()) + ", " + x + ")"}}, nil
+	case constraint.Format:
+		switch p.Format {
+		case "date-time":
+			return [][]string{{"is_string(" + x + ")", "not time.parse_rfc3339_ns(" + x + ")"}}, nil
+		case "ipv4":
+			return [][]string{
+				{"is_string(" + x + ")", "contains(" + x + `, ":")`},
+				{"is_string(" + x + ")", "not net.cidr_is_valid(concat(\"\", [" + x + `, "/32"]))`},
+			}, nil
+		case "ipv6":
+			return [][]string{
+				{"is_string(" + x + ")", "not contains(" + x + `, ":")`},
+				{"is_string(" + x + ")", "not net.cidr_is_valid(concat(\"\", [" + x + `, "/128"]))`},
+			}, nil
+		}
+		return nil, fmt.Errorf("format %q is not supported", p.Format)
+	case constraint.Enum:
+		values := make([]string, len(p.Values))
+		for i, v := range p.Values {
+			values[i] = syntax.Literal(v)
+		}
+		if len(values) == 1 {
+			return [][]string{{x + " != null", x + " != " + values[0]}}, nil
+		}
+		return [][]string{{x + " != null", "count({" + strings.Join(values, ", ") + "} & {" + x + "}) == 0"}}, nil
+	case constraint.Integer:
+		return [][]string{
+			{"is_number(" + x + ")", x + " != floor(" + x + ")"},
+			{"is_string(" + x + ")", "not regex.match(" + render.JSONString(integerPattern) + ", " + x + ")"},
+		}, nil
+	case constraint.MultipleOf:
+		if p.Value == 0 {
+			return nil, nil
+		}
+		quotient := fmt.Sprintf("%s / %s", x, render.Number(p.Value))
+		return [][]string{{"is_number(" + x + ")", quotient + " != floor(" + quotient + ")"}}, nil
+	case constraint.UniqueItems:
+		y := g.fresh()
+		return [][]string{{"is_array(" + x + ")", fmt.Sprintf("count(%s) != count({%s | %s := %s[_]})", x, y, y, x)}}, nil
+	case constraint.Required:
+		defs := make([][]string, len(p.Properties))
+		for i, property := range p.Properties {
+			defs[i] = []string{x + " != null", absent(x, property)}
+		}
+		return defs, nil
+	case constraint.Contains:
+		var bind []string
+		if !isVariable(x) {
+			v := g.fresh()
+			bind, x = []string{v + " := " + x}, v
+		}
+		e := g.fresh()
+		lits, err := g.condition(p.Element, e, false)
+		if err != nil {
+			return nil, err
+		}
+		some := g.rule(x, "some", [][]string{append([]string{e + " := " + x + "[_]"}, lits...)})
+		return [][]string{append(bind, x+" != null", "not "+some)}, nil
+	case constraint.Satisfies:
+		var bind []string
+		if !isVariable(x) {
+			v := g.fresh()
+			bind, x = []string{v + " := " + x}, v
+		}
+		defs, err := g.violations(p.Constraint, x, true)
+		if err != nil {
+			return nil, err
+		}
+		for i, def := range defs {
+			defs[i] = append(append([]string{}, bind...), def...)
+		}
+		return defs, nil
+	}
+	return nil, fmt.Errorf("unsupported predicate %T", p)
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// keywords are the Rego keywords, which cannot follow a dot.
+var keywords = map[string]bool{
+	"as": true, "contains": true, "default": true, "else": true, "every": true, "false": true,
+	"if": true, "import": true, "in": true, "not": true, "null": true, "package": true,
+	"some": true, "true": true, "with": true,
+}
+
+func isVariable(s string) bool {
+	return identifier.MatchString(s) && !keywords[s]
+}
+
+// accessor renders the reference to the value a segment addresses.
+func accessor(segment constraint.Segment) string {
+	switch segment.Kind {
+	case constraint.IndexSegment:
+		return "[" + strconv.Itoa(segment.Index) + "]"
+	case constraint.AttributeSegment:
+		if isVariable(segment.Name) {
+			return "." + segment.Name
+		}
+	}
+	return "[" + render.JSONString(segment.Name) + "]"
+}
@@ -0,0 +1,342 @@
+package rego
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const module = `
+variable "name" {
+  type = string
+
+  validation {
+    condition     = can(regex("^[a-z]+$", var.name))
+    error_message = "The name must be lower-case letters."
+  }
+}
+
+variable "zones" {
+  type    = list(string)
+  default = []
+
+  validation {
+    condition     = anytrue([for z in var.zones : z == "a"])
+    error_message = "Zone a is required."
+  }
+}
+
+variable "sizes" {
+  type = object({
+    min = number
+    max = optional(number)
+  })
+  default = { min = 1 }
+
+  validation {
+    condition     = var.sizes.min >= 1
+    error_message = "min must be positive."
+  }
+}
+
+variable "tier" {
+  type     = string
+  nullable = true
+  default  = "free"
+
+  validation {
+    condition     = var.tier == null || length(var.tier) > 2
+    error_message = "The tier name is too short."
+  }
+
+  validation {
+    condition     = var.tier == "pro" ? length(var.zones) >= 2 : true
+    error_message = "The pro tier needs two zones."
+  }
+}
+`
+
+const expected = `# Code generated by tfschema. DO NOT EDIT.
+
+package terraform.app
+
+import rego.v1
+
+# var.name: string
+deny contains msg if {
+	not is_string(input.name)
+	msg := "The name variable must be of type string."
+}
+
+# can(regex("^[a-z]+$", var.name))
+deny contains msg if {
+	is_string(input.name)
+	not regex.match("^[a-z]+$", input.name)
+	msg := "The name must be lower-case letters."
+}
+
+# var.zones: list(string)
+deny contains msg if {
+	invalid_1
+	msg := "The zones variable must be of type list(string)."
+}
+
+invalid_1 if {
+	input.zones != null
+	not is_array(input.zones)
+}
+
+invalid_1 if {
+	input.zones != null
+	is_array(input.zones)
+	x1 := input.zones[_]
+	x1 != null
+	not is_string(x1)
+}
+
+# anytrue([for z in var.zones : z == "a"])
+deny contains msg if {
+	x2 := input.zones
+	x2 != null
+	not some_4(x2)
+	msg := "Zone a is required."
+}
+
+invalid_2(x3) if {
+	x3 != null
+	x3 != "a"
+}
+
+missing_3(x3) if {
+	x3 == null
+}
+
+some_4(x2) if {
+	x3 := x2[_]
+	not invalid_2(x3)
+	not missing_3(x3)
+}
+
+# var.sizes: object({ min = number max = optional(number) })
+deny contains msg if {
+	invalid_5
+	msg := "The sizes variable must be of type object({ min = number max = optional(number) })."
+}
+
+invalid_5 if {
+	input.sizes != null
+	not is_object(input.sizes)
+}
+
+invalid_5 if {
+	input.sizes != null
+	is_object(input.sizes)
+	input.sizes.max != null
+	not is_number(input.sizes.max)
+}
+
+invalid_5 if {
+	input.sizes != null
+	is_object(input.sizes)
+	not is_number(input.sizes.min)
+}
+
+# var.sizes.min >= 1
+deny contains msg if {
+	is_number(input.sizes.min)
+	input.sizes.min < 1
+	msg := "min must be positive."
+}
+
+# var.tier: string
+deny contains msg if {
+	input.tier != null
+	not is_string(input.tier)
+	msg := "The tier variable must be of type string."
+}
+
+# var.tier == "pro" ? length(var.zones) >= 2 : true
+deny contains msg if {
+	not invalid_6
+	not missing_7
+	count(input.zones) < 2
+	msg := "The pro tier needs two zones."
+}
+
+invalid_6 if {
+	input.tier != null
+	input.tier != "pro"
+}
+
+missing_7 if {
+	object.get(input, "tier", null) == null
+}
+
+# TODO: "The tier name is too short." is not checked: no validation rule parser recognised the condition
+# var.tier == null || length(var.tier) > 2
+`
+
+// pair requires one variable when another is set.
+const pair = `
+variable "cert" {
+  type    = string
+  default = null
+}
+
+variable "key" {
+  type    = string
+  default = null
+
+  validation {
+    condition     = var.cert != null ? var.key != null : true
+    error_message = "The key must be set together with the cert."
+  }
+}
+`
+
+func TestGenerate(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(module)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, Generate("terraform.app", c.Variables(), c.Validations(), c.Untranslatable()))
+}
+
+// TestGenerateCheck parses and type checks the generated policy with opa.
+func TestGenerateCheck(t *testing.T) {
+	opa, err := exec.LookPath("opa")
+	if err != nil {
+		t.Skip("opa is not installed")
+	}
+
+	for name, source := range map[string]string{
+		"module": module,
+		"pair":   pair,
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := converter.New()
+			_, err := c.ConvertString(source)
+			require.NoError(t, err)
+
+			path := filepath.Join(t.TempDir(), "policy.rego")
+			policy := Generate("terraform.app", c.Variables(), c.Validations(), c.Untranslatable())
+			require.NoError(t, os.WriteFile(path, []byte(policy), 0o644))
+
+			output, err := exec.Command(opa, "check", "--strict", path).CombinedOutput()
+			require.NoError(t, err, string(output))
+		})
+	}
+}
+
+// TestGenerateEval evaluates the generated policies with opa.
+func TestGenerateEval(t *testing.T) {
+	opa, err := exec.LookPath("opa")
+	if err != nil {
+		t.Skip("opa is not installed")
+	}
+
+	tests := []struct {
+		source string
+		input  string
+		want   []string
+	}{
+		{module, `{"name": "web", "zones": ["a", "b"], "tier": "pro"}`, []string{}},
+		{module, `{"name": "Web", "zones": ["a"]}`, []string{"The name must be lower-case letters."}},
+		{module, `{"name": "web", "zones": ["a"], "tier": "pro"}`, []string{"The pro tier needs two zones."}},
+		{module, `{"name": "web", "zones": ["b"], "sizes": {"min": 0}}`, []string{"Zone a is required.", "min must be positive."}},
+		{pair, `{}`, []string{}},
+		{pair, `{"cert": "x", "key": "y"}`, []string{}},
+		{pair, `{"cert": "x"}`, []string{"The key must be set together with the cert."}},
+		{pair, `{"cert": "x", "key": null}`, []string{"The key must be set together with the cert."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			c := converter.New()
+			_, err := c.ConvertString(tt.source)
+			require.NoError(t, err)
+
+			dir := t.TempDir()
+			policy := Generate("terraform.app", c.Variables(), c.Validations(), c.Untranslatable())
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(policy), 0o644))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "input.json"), []byte(tt.input), 0o644))
+
+			cmd := exec.Command(opa, "eval", "--format", "json", "--data", "policy.rego", "--input", "input.json", "data.terraform.app.deny")
+			cmd.Dir = dir
+			output, err := cmd.Output()
+			require.NoError(t, err)
+			var result struct {
+				Result []struct {
+					Expressions []struct {
+						Value []string `json:"value"`
+					} `json:"expressions"`
+				} `json:"result"`
+			}
+			require.NoError(t, json.Unmarshal(output, &result))
+			require.Len(t, result.Result, 1)
+			assert.ElementsMatch(t, tt.want, result.Result[0].Expressions[0].Value)
+		})
+	}
+}
+
+func TestGenerateConstraints(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "endpoint" {
+  type = object({
+    host = string
+    port = optional(number)
+  })
+}
+`)
+	require.NoError(t, err)
+
+	host := constraint.Path{constraint.Attr("endpoint"), constraint.Attr("host")}
+	port := constraint.Path{constraint.Attr("endpoint"), constraint.Attr("port")}
+	validations := []constraint.Validation{
+		{
+			Variable:     "endpoint",
+			ErrorMessage: "The host must be an IP address.",
+			Constraint: constraint.Or{Terms: []constraint.Constraint{
+				constraint.Atom{Path: host, Predicate: constraint.Format{Format: "ipv4"}},
+				constraint.Atom{Path: host, Predicate: constraint.Format{Format: "ipv6"}},
+			}},
+		},
+		{
+			Variable:     "endpoint",
+			ErrorMessage: "The port must be 80 or 443.",
+			Constraint:   constraint.Atom{Path: port, Predicate: constraint.Enum{Values: []interface{}{80.0, 443.0}}},
+		},
+		{
+			Variable:     "endpoint",
+			Source:       "var.endpoint.host != \"\"",
+			ErrorMessage: "The host must be a URI.",
+			Constraint:   constraint.Atom{Path: host, Predicate: constraint.Format{Format: "uri"}},
+		},
+	}
+	policy := Generate("terraform.app", c.Variables(), validations, nil)
+
+	for _, want := range []string{
+		"deny contains msg if {\n\tnot any_4\n\tmsg := \"The host must be an IP address.\"\n}\n",
+		"\ninvalid_2 if {\n\tis_string(input.endpoint.host)\n\tcontains(input.endpoint.host, \":\")\n}\n",
+		"\nany_4 if {\n\tnot invalid_2\n}\n\nany_4 if {\n\tnot invalid_3\n}\n",
+		"\tinput.endpoint.port != null\n\tcount({80, 443} & {input.endpoint.port}) == 0\n",
+		"# TODO: \"The host must be a URI.\" is not checked: format \"uri\" is not supported\n# var.endpoint.host != \"\"\n",
+	} {
+		assert.Contains(t, policy, want)
+	}
+}
+
+func TestAccessor(t *testing.T) {
+	assert.Equal(t, ".name", accessor(constraint.Attr("name")))
+	assert.Equal(t, `["instance-type"]`, accessor(constraint.Attr("instance-type")))
+	assert.Equal(t, `["else"]`, accessor(constraint.Attr("else")))
+	assert.Equal(t, `["env"]`, accessor(constraint.Key("env")))
+	assert.Equal(t, "[2]", accessor(constraint.Index(2)))
+}
@@ -0,0 +1,111 @@
+// Package render holds the helpers the code generators share to render
+// literals, comments and conjunctions in the languages they write.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Syntax describes the literals of a language whose lists and maps are
+// written as [a, b] and {key: value}.
+type Syntax struct {
+	Null, True, False string
+	String            func(string) string // renders a string literal
+	Key               func(string) string // renders a map key, or nil to use String
+}
+
+// Literal renders a JSON value, as decoded by encoding/json or the converter,
+// as a literal. Map entries are sorted by key.
+func (s Syntax) Literal(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return s.Null
+	case bool:
+		if v {
+			return s.True
+		}
+		return s.False
+	case string:
+		return s.String(v)
+	case float64:
+		return Number(v)
+	case int:
+		return strconv.Itoa(v)
+	case []interface{}:
+		elems := make([]string, len(v))
+		for i, elem := range v {
+			elems[i] = s.Literal(elem)
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case map[string]interface{}:
+		key := s.Key
+		if key == nil {
+			key = s.String
+		}
+		entries := make([]string, 0, len(v))
+		for _, k := range SortedKeys(v) {
+			entries = append(entries, key(k)+": "+s.Literal(v[k]))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return s.Null
+	}
+	return string(encoded)
+}
+
+// Number renders a number in decimal notation, without an exponent or
+// trailing zeros.
+func Number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// JSONString renders s as a JSON string, which is also a valid string
+// literal in Rego and Python. HTML characters are not escaped.
+func JSONString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// CommentLine folds text onto one line, for a line comment.
+func CommentLine(s string) string {
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// WriteSource writes the HCL of a condition as line comments starting with
+// marker, such as "#" or "//", leaving out blank lines.
+func WriteSource(b *strings.Builder, marker, source string) {
+	for _, line := range strings.Split(strings.TrimSpace(source), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Fprintf(b, "%s %s\n", marker, line)
+		}
+	}
+}
+
+// JoinChecks joins boolean expressions with &&, which Go and CEL share. No
+// checks join into true.
+func JoinChecks(checks []string) string {
+	if len(checks) == 0 {
+		return "true"
+	}
+	return strings.Join(checks, " && ")
+}
+
+// SortedKeys returns the keys of a map in lexical order.
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
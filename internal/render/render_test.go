@@ -0,0 +1,38 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiteral(t *testing.T) {
+	python := Syntax{Null: "None", True: "True", False: "False", String: JSONString}
+	assert.Equal(t, "None", python.Literal(nil))
+	assert.Equal(t, "False", python.Literal(false))
+	assert.Equal(t, `"a\\d<b>"`, python.Literal(`a\d<b>`))
+	assert.Equal(t, `{"a": [1, 2.5, True], "b": {}}`, python.Literal(map[string]interface{}{"b": map[string]interface{}{}, "a": []interface{}{1, 2.5, true}}))
+
+	bare := Syntax{Null: "null", True: "true", False: "false", String: JSONString, Key: func(k string) string { return k }}
+	assert.Equal(t, `{a: "x"}`, bare.Literal(map[string]interface{}{"a": "x"}))
+}
+
+func TestNumber(t *testing.T) {
+	assert.Equal(t, "8080", Number(8080))
+	assert.Equal(t, "0.5", Number(0.5))
+	assert.Equal(t, "100000000000000000000000", Number(1e23))
+}
+
+func TestWriteSource(t *testing.T) {
+	var b strings.Builder
+	WriteSource(&b, "#", "\n  var.a > 0 &&\n\n  var.b < 1\n")
+	assert.Equal(t, "# var.a > 0 &&\n# var.b < 1\n", b.String())
+	assert.Equal(t, "a b", CommentLine("a\nb"))
+}
+
+func TestJoinChecks(t *testing.T) {
+	assert.Equal(t, "true", JoinChecks(nil))
+	assert.Equal(t, "a && b", JoinChecks([]string{"a", "b"}))
+	assert.Equal(t, []string{"a", "b", "c"}, SortedKeys(map[string]int{"c": 1, "a": 2, "b": 3}))
+}
@@ -0,0 +1,177 @@
+// Package typescript generates TypeScript declarations for the inputs of a
+// Terraform module from its converted variables.
+package typescript
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/naming"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+)
+
+// identifierPattern matches property names that need no quotes.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// Generate renders an `export interface <Module>Inputs` declaring one
+// property per variable, in declaration order. Variables with a default are
+// optional, and descriptions and defaults become JSDoc comments.
+func Generate(module string, variables []converter.Variable) string {
+	var b strings.Builder
+	b.WriteString("// Code generated by tfschema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "export interface %sInputs {\n", naming.Pascal(module, "Module"))
+	for _, v := range variables {
+		writeDoc(&b, v.Schema, "  ")
+		optional := ""
+		if !v.Required {
+			optional = "?"
+		}
+		fmt.Fprintf(&b, "  %s%s: %s;\n", propertyName(v.Name), optional, typeOf(v.Schema, "  "))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// writeDoc writes the JSDoc comment of a variable, if it has a description,
+// a default or is sensitive.
+func writeDoc(b *strings.Builder, schema *jsonschema.Schema, indent string) {
+	var lines []string
+	if schema.Description != "" {
+		lines = append(lines, strings.Split(strings.TrimSpace(schema.Description), "\n")...)
+	}
+	if schema.Default != nil {
+		if encoded, err := json.Marshal(schema.Default); err == nil {
+			lines = append(lines, "@default "+string(encoded))
+		}
+	}
+	if schema.Sensitive != nil && *schema.Sensitive {
+		lines = append(lines, "@remarks Sensitive: the value is redacted from Terraform output.")
+	}
+	if len(lines) == 0 {
+		return
+	}
+	for i, line := range lines {
+		// A "*/" in the text would end the comment early.
+		lines[i] = strings.ReplaceAll(strings.TrimRight(line, " \t"), "*/", "*\\/")
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+// typeOf renders the TypeScript type of a schema. indent is the indentation
+// of the line the type starts on, for nested object literals.
+func typeOf(schema *jsonschema.Schema, indent string) string {
+	t := baseTypeOf(schema, indent)
+	if schema.AcceptsNull() && t != "unknown" {
+		t += " | null"
+	}
+	return t
+}
+
+func baseTypeOf(schema *jsonschema.Schema, indent string) string {
+	if len(schema.Enum) > 0 {
+		return literalUnion(schema.Enum)
+	}
+
+	switch schema.BaseType() {
+	case "string":
+		return "string"
+	case "number", "integer":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if tuple := schema.TupleItems(); tuple != nil {
+			elems := make([]string, len(tuple))
+			for i, item := range tuple {
+				elems[i] = typeOf(item, indent)
+			}
+			return "[" + strings.Join(elems, ", ") + "]"
+		}
+		if elem := schema.ElementSchema(); elem != nil {
+			return arrayOf(elem, indent)
+		}
+		return "unknown[]"
+	case "object":
+		if values := schema.MapValues(); values != nil {
+			return "Record<string, " + typeOf(values, indent) + ">"
+		}
+		if len(schema.Properties) == 0 {
+			return "Record<string, unknown>"
+		}
+		return objectLiteral(schema, indent)
+	}
+	return "unknown"
+}
+
+// objectLiteral renders the attributes of an object type, in alphabetical
+// order. Attributes declared with optional() are not required.
+func objectLiteral(schema *jsonschema.Schema, indent string) string {
+	required := map[string]bool{}
+	if schema.Required != nil {
+		for _, name := range *schema.Required {
+			required[name] = true
+		}
+	}
+	names := render.SortedKeys(schema.Properties)
+
+	inner := indent + "  "
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s;\n", inner, propertyName(name), optional, typeOf(schema.Properties[name], inner))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// arrayOf renders an array of elem, parenthesising unions.
+func arrayOf(elem *jsonschema.Schema, indent string) string {
+	t := typeOf(elem, indent)
+	if len(elem.Enum) > 1 || (elem.AcceptsNull() && t != "unknown") {
+		return "(" + t + ")[]"
+	}
+	return t + "[]"
+}
+
+// literalUnion renders enum values as a union of literal types.
+func literalUnion(values []interface{}) string {
+	literals := make([]string, 0, len(values))
+	for _, v := range values {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "unknown"
+		}
+		literals = append(literals, string(encoded))
+	}
+	return strings.Join(literals, " | ")
+}
+
+// propertyName quotes names that are not valid identifiers, such as names
+// with dashes.
+func propertyName(name string) string {
+	if identifierPattern.MatchString(name) {
+		return name
+	}
+	encoded, _ := json.Marshal(name)
+	return string(encoded)
+}
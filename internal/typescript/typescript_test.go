@@ -0,0 +1,75 @@
+package typescript
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(`
+variable "name" {
+  type        = string
+  description = "Resource name"
+}
+
+variable "environment" {
+  type    = string
+  default = "dev"
+
+  validation {
+    condition     = contains(["dev", "prod"], var.environment)
+    error_message = "Unknown environment."
+  }
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    subnets = optional(list(string), [])
+    peer    = tuple([string, number])
+  })
+}
+
+variable "tags" {
+  type     = map(string)
+  nullable = true
+}
+
+variable "zones" {
+  type = list(string)
+
+  validation {
+    condition     = alltrue([for z in var.zones : contains(["a", "b"], z)])
+    error_message = "Unknown zone."
+  }
+}
+`)
+	require.NoError(t, err)
+
+	want := `// Code generated by tfschema. DO NOT EDIT.
+
+export interface TerraformAwsVpcInputs {
+  /** Resource name */
+  name: string;
+  /** @default "dev" */
+  environment?: "dev" | "prod";
+  network: {
+    cidr: string;
+    peer: [string, number];
+    subnets?: string[];
+  };
+  tags: Record<string, string> | null;
+  zones: ("a" | "b")[];
+}
+`
+	assert.Equal(t, want, Generate("terraform-aws-vpc", c.Variables()))
+}
+
+func TestPropertyName(t *testing.T) {
+	assert.Equal(t, "subnet_ids", propertyName("subnet_ids"))
+	assert.Equal(t, `"availability-zones"`, propertyName("availability-zones"))
+}
@@ -0,0 +1,167 @@
+// Package uischema generates the uiSchema that react-jsonschema-form renders
+// alongside the JSON Schema of a Terraform module, from its converted
+// variables.
+package uischema
+
+import (
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// UISchema is a react-jsonschema-form uiSchema: "ui:" options of a field,
+// and the uiSchemas of its properties by name.
+type UISchema map[string]interface{}
+
+// Generate returns the uiSchema of the inputs. Fields follow the declaration
+// order of the variables, and of the attributes of object types. Sensitive
+// strings get a password widget, strings with a multi-line default, such as
+// a heredoc, a textarea and enums a select. Descriptions become help text.
+func Generate(variables []converter.Variable) UISchema {
+	ui := UISchema{}
+	order := make([]string, 0, len(variables))
+	for _, v := range variables {
+		order = append(order, v.Name)
+		sensitive := v.Schema.Sensitive != nil && *v.Schema.Sensitive
+		field := fieldOf(v.Schema, parseType(v.Type), sensitive)
+		if v.Schema.Description != "" {
+			field["ui:help"] = v.Schema.Description
+		}
+		if len(field) > 0 {
+			ui[v.Name] = field
+		}
+	}
+	if len(order) > 0 {
+		ui["ui:order"] = order
+	}
+	return ui
+}
+
+// fieldOf returns the uiSchema of a value of schema s, whose type constraint
+// is typ when known. The values within a sensitive variable are sensitive.
+func fieldOf(s *jsonschema.Schema, typ hclsyntax.Expression, sensitive bool) UISchema {
+	ui := UISchema{}
+	typ = unwrap(typ, "optional")
+	switch s.BaseType() {
+	case "string":
+		switch {
+		case sensitive:
+			ui["ui:widget"] = "password"
+		case hasEnum(s):
+			ui["ui:widget"] = "select"
+		case multiLine(s.Default):
+			ui["ui:widget"] = "textarea"
+		}
+	case "number", "integer", "boolean":
+		if hasEnum(s) {
+			ui["ui:widget"] = "select"
+		}
+	case "array":
+		if tuple := s.TupleItems(); tuple != nil {
+			var elems []hclsyntax.Expression
+			if t, ok := argument(typ, "tuple").(*hclsyntax.TupleConsExpr); ok {
+				elems = t.Exprs
+			}
+			items := make([]UISchema, len(tuple))
+			empty := true
+			for i, item := range tuple {
+				var elem hclsyntax.Expression
+				if i < len(elems) {
+					elem = elems[i]
+				}
+				items[i] = fieldOf(item, elem, sensitive)
+				empty = empty && len(items[i]) == 0
+			}
+			if !empty {
+				ui["items"] = items
+			}
+			break
+		}
+		if items := s.ElementSchema(); items != nil {
+			element := argument(typ, "list")
+			if element == nil {
+				element = argument(typ, "set")
+			}
+			if field := fieldOf(items, element, sensitive); len(field) > 0 {
+				ui["items"] = field
+			}
+		}
+	case "object":
+		if values := s.MapValues(); values != nil {
+			if field := fieldOf(values, argument(typ, "map"), sensitive); len(field) > 0 {
+				ui["additionalProperties"] = field
+			}
+			break
+		}
+		attributes := map[string]hclsyntax.Expression{}
+		var order []string
+		if object, ok := argument(typ, "object").(*hclsyntax.ObjectConsExpr); ok {
+			for _, item := range object.Items {
+				if name := hcl.ExprAsKeyword(item.KeyExpr); name != "" && s.Properties[name] != nil {
+					attributes[name] = item.ValueExpr
+					order = append(order, name)
+				}
+			}
+		}
+		for name, prop := range s.Properties {
+			if field := fieldOf(prop, attributes[name], sensitive); len(field) > 0 {
+				ui[name] = field
+			}
+		}
+		if len(order) == len(s.Properties) && len(order) > 1 {
+			ui["ui:order"] = order
+		}
+	}
+	return ui
+}
+
+// hasEnum reports whether s, or one of its allOf entries, has an enum.
+func hasEnum(s *jsonschema.Schema) bool {
+	if len(s.Enum) > 0 {
+		return true
+	}
+	for _, sub := range s.AllOf {
+		if len(sub.Enum) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// multiLine reports whether a default is a string with a line break, as
+// heredocs are.
+func multiLine(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && strings.Contains(s, "\n")
+}
+
+// parseType parses the HCL of a type constraint, or returns nil.
+func parseType(typ string) hclsyntax.Expression {
+	expr, diags := hclsyntax.ParseExpression([]byte(typ), "type", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil
+	}
+	return expr
+}
+
+// argument returns the first argument of a call to the type function name,
+// such as the element type of list(string), or nil.
+func argument(typ hclsyntax.Expression, name string) hclsyntax.Expression {
+	call, ok := typ.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != name || len(call.Args) == 0 {
+		return nil
+	}
+	return call.Args[0]
+}
+
+// unwrap returns the type within a call to name, such as optional(string),
+// or typ itself.
+func unwrap(typ hclsyntax.Expression, name string) hclsyntax.Expression {
+	if inner := argument(typ, name); inner != nil {
+		return inner
+	}
+	return typ
+}
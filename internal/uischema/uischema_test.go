@@ -0,0 +1,98 @@
+package uischema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const module = `
+variable "name" {
+  type        = string
+  description = "Name of the deployment"
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+}
+
+variable "user_data" {
+  type    = string
+  default = <<-EOT
+    #!/bin/sh
+    echo hello
+  EOT
+}
+
+variable "tier" {
+  type    = string
+  default = "free"
+
+  validation {
+    condition     = contains(["free", "pro"], var.tier)
+    error_message = "Unknown tier."
+  }
+}
+
+variable "database" {
+  type = object({
+    engine   = string
+    password = optional(string)
+    port     = optional(number, 5432)
+  })
+  sensitive = true
+}
+
+variable "endpoints" {
+  type = list(object({
+    url    = string
+    scheme = optional(string, "https")
+  }))
+  default = []
+
+  validation {
+    condition     = alltrue([for e in var.endpoints : contains(["http", "https"], e.scheme)])
+    error_message = "Unknown scheme."
+  }
+}
+
+variable "replicas" {
+  type    = number
+  default = 1
+}
+`
+
+func TestGenerate(t *testing.T) {
+	c := converter.New()
+	_, err := c.ConvertString(module)
+	require.NoError(t, err)
+
+	ui, err := json.Marshal(Generate(c.Variables()))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "ui:order": ["name", "password", "user_data", "tier", "database", "endpoints", "replicas"],
+  "name": {"ui:help": "Name of the deployment"},
+  "password": {"ui:widget": "password"},
+  "user_data": {"ui:widget": "textarea"},
+  "tier": {"ui:widget": "select"},
+  "database": {
+    "ui:order": ["engine", "password", "port"],
+    "engine": {"ui:widget": "password"},
+    "password": {"ui:widget": "password"}
+  },
+  "endpoints": {
+    "items": {
+      "ui:order": ["url", "scheme"],
+      "scheme": {"ui:widget": "select"}
+    }
+  }
+}`, string(ui))
+}
+
+func TestGenerateEmpty(t *testing.T) {
+	assert.Empty(t, Generate(nil))
+}
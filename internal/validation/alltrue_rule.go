@@ -3,8 +3,8 @@ package validation
 import (
 	"fmt"
 	"log"
-	"reflect"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 )
@@ -13,7 +13,7 @@ func init() {
 	RegisterRuleParserWithPriority(parseAllTrueRule, 20)
 }
 
-func parseAllTrueRule(expr hcl.Expression, varName string) (Rule, []string, error) {
+func parseAllTrueRule(expr hcl.Expression, varName string) (Rule, Path, error) {
 	log.Printf("[alltrue] called for var=%s, expr=%T", varName, expr)
 	call, ok := expr.(*hclsyntax.FunctionCallExpr)
 	if !ok || call.Name != "alltrue" {
@@ -26,63 +26,123 @@ func parseAllTrueRule(expr hcl.Expression, varName string) (Rule, []string, erro
 
 	log.Printf("[alltrue] call.Name=%s argType=%T", call.Name, call.Args[0])
 
-	// The argument can be a tuple expression wrapping the ForExpr or the ForExpr directly
-	var forExpr *hclsyntax.ForExpr
-	if tuple, ok := call.Args[0].(*hclsyntax.TupleConsExpr); ok {
-		if len(tuple.Exprs) != 1 {
-			return nil, nil, nil
-		}
-		forExpr, ok = tuple.Exprs[0].(*hclsyntax.ForExpr)
-		if !ok {
-			return nil, nil, nil
-		}
-	} else if fe, ok := call.Args[0].(*hclsyntax.ForExpr); ok {
-		forExpr = fe
-	} else {
+	// alltrue(flatten([for ... : [for ... : cond]])) checks every element of
+	// the nested collections.
+	arg := call.Args[0]
+	flattened := false
+	if flatten, ok := arg.(*hclsyntax.FunctionCallExpr); ok && flatten.Name == "flatten" && len(flatten.Args) == 1 {
+		arg = flatten.Args[0]
+		flattened = true
+	}
+
+	forExpr := forExprArgument(arg)
+	if forExpr == nil {
+		return nil, nil, nil
+	}
+
+	rule, path, err := parseForElements(forExpr, varName, flattened)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse inner expression in alltrue: %w", err)
+	}
+	if rule == nil {
+		log.Printf("[alltrue] no subparser matched inner expression for loopVar=%s", forExpr.ValVar)
 		return nil, nil, nil
 	}
+	log.Printf("[alltrue] returning rule for fullPath=%v", path)
+	return rule, path, nil
+}
 
-	innerExpr := forExpr.ValExpr
+// parseForElements parses the condition a for expression checks on every
+// element of its collection, returning a rule scoped to those elements. With
+// flattened set, a nested for expression descends into the element's own
+// collection. An if clause makes the condition apply only to the elements it
+// selects.
+func parseForElements(forExpr *hclsyntax.ForExpr, varName string, flattened bool) (Rule, Path, error) {
+	innerExpr := unwrapParen(forExpr.ValExpr)
 
-	// Unwrap any parentheses around the inner expression for simpler parsing.
-	if paren, ok := innerExpr.(*hclsyntax.ParenthesesExpr); ok {
-		innerExpr = paren.Expression
+	collExpr := forExpr.CollExpr
+	loopVar := forExpr.ValVar
+	var elementSegment constraint.Segment
+	if keysCall, ok := collExpr.(*hclsyntax.FunctionCallExpr); ok && keysCall.Name == "keys" && len(keysCall.Args) == 1 {
+		// for k in keys(var.m) iterates over the map keys.
+		collExpr = keysCall.Args[0]
+		elementSegment = constraint.Keys()
+	} else if forExpr.KeyVar != "" && referencesVar(innerExpr, forExpr.KeyVar) {
+		// for k, v in var.m : <condition on k> constrains the map keys.
+		if referencesVar(innerExpr, forExpr.ValVar) {
+			return nil, nil, &UntranslatableError{Reason: "JSON Schema cannot relate map keys to their values"}
+		}
+		loopVar = forExpr.KeyVar
+		elementSegment = constraint.Keys()
+	} else {
+		elementSegment = constraint.Wildcard()
 	}
 
-	collectionPath, err := pathHandler.ExtractPathFromExpression(forExpr.CollExpr, varName)
+	collectionPath, err := pathHandler.ExtractPathFromExpression(collExpr, varName)
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not extract collection path from for expression: %w", err)
 	}
+	collectionPath = collectionPath.Append(elementSegment)
 
-	// Get a list of all parsers except for this one to avoid recursion.
-	otherParsers := make([]ParserFunc, 0)
-	for _, p := range GetParsers() {
-		if !isThisParser(p, parseAllTrueRule) {
-			otherParsers = append(otherParsers, p)
+	var rule Rule
+	var elementPath Path
+	if nested := forExprArgument(innerExpr); nested != nil {
+		if !flattened {
+			return nil, nil, &UntranslatableError{Reason: "nested for expressions must be flattened to be checked with alltrue"}
 		}
-	}
-
-	for _, parser := range otherParsers {
-		log.Printf("[alltrue] trying subparser %p (%T)", parser, parser)
-		rule, innerPath, err := parser(innerExpr, forExpr.ValVar)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse inner expression in alltrue: %w", err)
+		if !referencesVar(nested.CollExpr, loopVar) {
+			return nil, nil, &UntranslatableError{Reason: "a nested for expression must iterate over a collection of the outer element"}
 		}
-		if rule != nil {
-			log.Printf("[alltrue] subparser produced rule %#v with innerPath=%v", rule, innerPath)
+		for _, outer := range []string{forExpr.KeyVar, forExpr.ValVar} {
+			if outer != "" && (referencesVar(nested.ValExpr, outer) || nested.CondExpr != nil && referencesVar(nested.CondExpr, outer)) {
+				return nil, nil, &UntranslatableError{Reason: "JSON Schema cannot relate the elements of a nested collection to the outer element"}
+			}
 		}
-		if rule != nil {
-			fullPath := append(collectionPath, "*")
-			fullPath = append(fullPath, innerPath...)
-			log.Printf("[alltrue] returning rule for fullPath=%v", fullPath)
-			return rule, fullPath, nil
+		rule, elementPath, err = parseForElements(nested, loopVar, flattened)
+		if err != nil || rule == nil {
+			return nil, nil, err
+		}
+	} else {
+		var matched bool
+		rule, elementPath, matched, err = parseConditionOperand(innerExpr, loopVar)
+		if err != nil || !matched || rule == nil {
+			return nil, nil, err
 		}
 	}
-	log.Printf("[alltrue] no subparser matched inner expression for loopVar=%s", forExpr.ValVar)
 
-	return nil, nil, nil
+	if forExpr.CondExpr == nil {
+		return rule, collectionPath.Append(elementPath...), nil
+	}
+
+	// for x in var.list : <cond> if <filter> only checks the selected elements.
+	filterRule, filterPath, matched, err := parseConditionOperand(forExpr.CondExpr, loopVar)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse for expression filter: %w", err)
+	}
+	if !matched {
+		return nil, nil, &UntranslatableError{Reason: "the for expression filter cannot be expressed in JSON Schema"}
+	}
+	if filterRule == nil {
+		return rule, collectionPath.Append(elementPath...), nil // The filter selects every element.
+	}
+	conditional, commonPath := newConditionalRule(
+		ScopedRule{Rule: filterRule, Path: filterPath},
+		&ScopedRule{Rule: rule, Path: elementPath},
+		nil,
+	)
+	return conditional, collectionPath.Append(commonPath...), nil
 }
 
-func isThisParser(p ParserFunc, self ParserFunc) bool {
-	return reflect.ValueOf(p).Pointer() == reflect.ValueOf(self).Pointer()
+// forExprArgument returns the for expression passed to alltrue or anytrue,
+// either directly or wrapped in a tuple as in alltrue([for ...]).
+func forExprArgument(arg hcl.Expression) *hclsyntax.ForExpr {
+	arg = unwrapParen(arg)
+	if tuple, ok := arg.(*hclsyntax.TupleConsExpr); ok {
+		if len(tuple.Exprs) != 1 {
+			return nil
+		}
+		arg = unwrapParen(tuple.Exprs[0])
+	}
+	forExpr, _ := arg.(*hclsyntax.ForExpr)
+	return forExpr
 }
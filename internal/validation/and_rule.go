@@ -0,0 +1,69 @@
+package validation
+
+import (
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func init() {
+	// Runs last, so that conjunctions other parsers understand as a whole,
+	// such as a pair of range bounds, are left to them.
+	RegisterRuleParserWithPriority(parseAndRule, -10)
+}
+
+// AndRule requires all of its terms to hold. The paths of the terms are
+// relative to the schema the rule applies to.
+type AndRule struct {
+	Terms []ScopedRule
+}
+
+// Constraint lowers the and validation rule into the constraint IR, or returns
+// nil if one of its terms cannot be lowered.
+func (r *AndRule) Constraint() constraint.Constraint {
+	and := constraint.And{}
+	for _, term := range r.Terms {
+		c := lower(term)
+		if c == nil {
+			return nil
+		}
+		and.Terms = append(and.Terms, c)
+	}
+	return and
+}
+
+// parseAndRule handles conditions joined with && that no single parser
+// recognises, each of which another parser recognises, such as
+// var.n >= 1 && floor(var.n) == var.n. The rule is anchored at the nearest
+// common ancestor of the paths of the terms.
+func parseAndRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	parts := conjuncts(expr)
+	if len(parts) < 2 {
+		return nil, nil, nil // Not a conjunction.
+	}
+
+	var terms []ScopedRule
+	var paths []Path
+	for _, part := range parts {
+		rule, path, matched, err := parseConditionOperand(part, varName)
+		if _, untranslatable := untranslatableReason(err); untranslatable {
+			return nil, nil, err
+		}
+		if err != nil || !matched {
+			return nil, nil, nil
+		}
+		if rule == nil {
+			continue // A literal true term always holds.
+		}
+		terms = append(terms, ScopedRule{Rule: rule, Path: path})
+		paths = append(paths, path)
+	}
+	if len(terms) == 0 {
+		return nil, nil, nil
+	}
+
+	common := constraint.CommonPrefix(paths...)
+	for i := range terms {
+		terms[i].Path = terms[i].Path[len(common):]
+	}
+	return &AndRule{Terms: terms}, common, nil
+}
@@ -0,0 +1,73 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAndRule(t *testing.T) {
+	one, two := 1.0, 2.0
+	tests := []struct {
+		condition string
+		want      constraint.Constraint
+		wantPath  Path
+	}{
+		{
+			`var.n >= 1 && floor(var.n) == var.n`,
+			constraint.And{Terms: []constraint.Constraint{
+				atom(constraint.Range{Minimum: &one}),
+				atom(constraint.Integer{}),
+			}},
+			Path{},
+		},
+		{
+			`var.n >= 1 && var.n % 2 == 0`,
+			constraint.And{Terms: []constraint.Constraint{
+				atom(constraint.Range{Minimum: &one}),
+				atom(constraint.MultipleOf{Value: two}),
+			}},
+			Path{},
+		},
+		{`var.n >= 1 && var.n > local.limit`, nil, nil},
+		{`var.n >= 1`, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			rule, path, err := parseAndRule(expr, "n")
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, rule)
+				return
+			}
+			require.NotNil(t, rule)
+			assert.Equal(t, tt.want, rule.Constraint())
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
+
+func TestParseRangeRuleLeavesMixedConjunctions(t *testing.T) {
+	for _, condition := range []string{
+		`var.n >= 1 && floor(var.n) == var.n`,
+		`var.n >= 1 && var.n % 2 == 0`,
+		`var.n.a >= 1 && var.n.b <= 10`,
+	} {
+		t.Run(condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			rule, _, err := parseRangeRule(expr, "n")
+			require.NoError(t, err)
+			assert.Nil(t, rule)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func init() {
+	RegisterRuleParserWithPriority(parseAnyTrueRule, 20)
+}
+
+// ContainsRule requires at least one element of a collection to satisfy
+// Element, whose path is relative to the element schema.
+type ContainsRule struct {
+	Element ScopedRule
+}
+
+// Constraint lowers the contains validation rule into the constraint IR, or
+// returns nil if the element rule cannot be lowered.
+func (r *ContainsRule) Constraint() constraint.Constraint {
+	element := lower(r.Element)
+	if element == nil {
+		return nil
+	}
+	return atom(constraint.Contains{Element: element})
+}
+
+// parseAnyTrueRule handles anytrue([for x in var.list : <condition>]), which
+// holds when at least one element satisfies the condition. A loop over the
+// keys of a map is handled by parseAnyKeyRule.
+func parseAnyTrueRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	call, ok := unwrapParen(expr).(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "anytrue" || len(call.Args) != 1 {
+		return nil, nil, nil // Not an anytrue() call.
+	}
+
+	forExpr := forExprArgument(call.Args[0])
+	if forExpr == nil || forExpr.CondExpr != nil {
+		return nil, nil, nil
+	}
+
+	collExpr := forExpr.CollExpr
+	keyVar := ""
+	if keysCall, ok := unwrapParen(collExpr).(*hclsyntax.FunctionCallExpr); ok && keysCall.Name == "keys" && len(keysCall.Args) == 1 {
+		// for k in keys(var.m) iterates over the map keys.
+		collExpr = keysCall.Args[0]
+		keyVar = forExpr.ValVar
+	} else if forExpr.KeyVar != "" && referencesVar(forExpr.ValExpr, forExpr.KeyVar) {
+		if referencesVar(forExpr.ValExpr, forExpr.ValVar) {
+			return nil, nil, &UntranslatableError{Reason: "JSON Schema cannot relate map keys to their values"}
+		}
+		keyVar = forExpr.KeyVar
+	}
+
+	collectionPath, err := pathHandler.ExtractPathFromExpression(collExpr, varName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not extract collection path from for expression: %w", err)
+	}
+	if keyVar != "" {
+		rule, err := parseAnyKeyRule(forExpr.ValExpr, keyVar)
+		if err != nil || rule == nil {
+			return nil, nil, err
+		}
+		return rule, collectionPath, nil
+	}
+
+	rule, elementPath, matched, err := parseConditionOperand(forExpr.ValExpr, forExpr.ValVar)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse inner expression in anytrue: %w", err)
+	}
+	if !matched || rule == nil {
+		return nil, nil, nil
+	}
+	return &ContainsRule{Element: ScopedRule{Rule: rule, Path: elementPath}}, collectionPath, nil
+}
+
+// parseAnyKeyRule handles the condition of an anytrue loop over the keys of a
+// map. Some key equals one of a set of names exactly when the map has one of
+// them, so k == "owner" becomes a required key; JSON Schema has no way to
+// require that some key satisfies any other condition.
+func parseAnyKeyRule(condition hcl.Expression, keyVar string) (Rule, error) {
+	rule, path, matched, err := parseConditionOperand(condition, keyVar)
+	if err != nil {
+		if _, untranslatable := untranslatableReason(err); untranslatable {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to parse inner expression in anytrue: %w", err)
+	}
+	if matched && rule == nil {
+		return nil, nil // A literal true condition; left to the other parsers.
+	}
+
+	var names []string
+	if matched {
+		names, matched = keyNames(lower(ScopedRule{Rule: rule, Path: path}))
+	}
+	if !matched {
+		return nil, &UntranslatableError{Reason: "JSON Schema can only require that some key of a map equals a given name"}
+	}
+	if len(names) == 1 {
+		return &RequiredRule{Properties: names}, nil
+	}
+	or := &OrRule{}
+	for _, name := range names {
+		or.Alternatives = append(or.Alternatives, ScopedRule{Rule: &RequiredRule{Properties: []string{name}}, Path: Path{}})
+	}
+	return or, nil
+}
+
+// keyNames returns the names a key must equal, if c is an enum of strings on
+// the key itself.
+func keyNames(c constraint.Constraint) ([]string, bool) {
+	a, ok := c.(constraint.Atom)
+	if !ok || len(a.Path) > 0 {
+		return nil, false
+	}
+	enum, ok := a.Predicate.(constraint.Enum)
+	if !ok || len(enum.Values) == 0 {
+		return nil, false
+	}
+	names := make([]string, 0, len(enum.Values))
+	for _, value := range enum.Values {
+		name, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		names = append(names, name)
+	}
+	return names, true
+}
@@ -0,0 +1,60 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAnyTrueRuleOverKeys(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      constraint.Constraint
+	}{
+		{
+			`anytrue([for k in keys(var.tags) : k == "owner"])`,
+			atom(constraint.Required{Properties: []string{"owner"}}),
+		},
+		{
+			`anytrue([for k, v in var.tags : k == "team" || k == "squad"])`,
+			constraint.Or{Terms: []constraint.Constraint{
+				atom(constraint.Required{Properties: []string{"team"}}),
+				atom(constraint.Required{Properties: []string{"squad"}}),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			rule, path, err := parseAnyTrueRule(expr, "tags")
+			require.NoError(t, err)
+			require.NotNil(t, rule)
+			assert.Empty(t, path)
+			assert.Equal(t, tt.want, rule.Constraint())
+		})
+	}
+}
+
+func TestParseAnyTrueRuleOverKeysUntranslatable(t *testing.T) {
+	for _, condition := range []string{
+		`anytrue([for k in keys(var.tags) : startswith(k, "team-")])`,
+		`anytrue([for k in keys(var.tags) : length(k) > 3])`,
+		`anytrue([for k, v in var.tags : k == v])`,
+	} {
+		t.Run(condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			_, _, err := parseAnyTrueRule(expr, "tags")
+			var untranslatable *UntranslatableError
+			assert.ErrorAs(t, err, &untranslatable)
+		})
+	}
+}
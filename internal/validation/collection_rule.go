@@ -0,0 +1,92 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func init() {
+	// Must run before the length parser, which only accepts literal lengths.
+	RegisterRuleParserWithPriority(parseCollectionRule, 12)
+}
+
+// UniqueItemsRule requires the elements of an array to be distinct.
+type UniqueItemsRule struct{}
+
+// Constraint lowers the unique items validation rule into the constraint IR.
+func (r *UniqueItemsRule) Constraint() constraint.Constraint {
+	return atom(constraint.UniqueItems{})
+}
+
+// parseCollectionRule recognises the set idioms used on collections:
+//
+//	length(distinct(var.x)) == length(var.x)  -> uniqueItems
+//	length(setsubtract(var.x, [...])) == 0    -> items restricted to the list
+func parseCollectionRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	binary, ok := unwrapParen(expr).(*hclsyntax.BinaryOpExpr)
+	if !ok || binary.Op != hclsyntax.OpEqual {
+		return nil, nil, nil
+	}
+
+	for _, pair := range [][2]hcl.Expression{{binary.LHS, binary.RHS}, {binary.RHS, binary.LHS}} {
+		inner, ok := lengthArgument(pair[0])
+		if !ok {
+			continue
+		}
+		call, ok := inner.(*hclsyntax.FunctionCallExpr)
+		if !ok {
+			continue
+		}
+
+		switch call.Name {
+		case "distinct", "toset":
+			if len(call.Args) != 1 || !isReference(call.Args[0], varName) {
+				continue
+			}
+			other, ok := lengthArgument(pair[1])
+			if !ok || !sameTraversal(unwrapParen(call.Args[0]), other) {
+				continue
+			}
+			path, err := pathHandler.ExtractPathFromExpression(call.Args[0], varName)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &UniqueItemsRule{}, path, nil
+
+		case "setsubtract":
+			if len(call.Args) != 2 || !isReference(call.Args[0], varName) {
+				continue
+			}
+			if value, err := extractNumericValue(unwrapParen(pair[1])); err != nil || value != 0 {
+				continue
+			}
+			allowed, ok := unwrapParen(call.Args[1]).(*hclsyntax.TupleConsExpr)
+			if !ok {
+				return nil, nil, &UntranslatableError{Reason: "second argument to 'setsubtract' is not a literal list"}
+			}
+			values, err := literalValues(allowed)
+			if err != nil {
+				return nil, nil, &UntranslatableError{Reason: fmt.Sprintf("second argument to 'setsubtract' is not a literal list: %v", err)}
+			}
+			path, err := pathHandler.ExtractPathFromExpression(call.Args[0], varName)
+			if err != nil {
+				return nil, nil, err
+			}
+			// Every remaining element must be one of the allowed values.
+			return &EnumRule{Values: values}, path.Append(constraint.Wildcard()), nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// lengthArgument returns the argument of a length() call.
+func lengthArgument(expr hcl.Expression) (hcl.Expression, bool) {
+	call, ok := unwrapParen(expr).(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "length" || len(call.Args) != 1 {
+		return nil, false
+	}
+	return unwrapParen(call.Args[0]), true
+}
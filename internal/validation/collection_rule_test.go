@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCollectionRule(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      Rule
+		wantPath  Path
+	}{
+		{`length(distinct(var.azs)) == length(var.azs)`, &UniqueItemsRule{}, nil},
+		{`length(var.azs) == length(toset(var.azs))`, &UniqueItemsRule{}, nil},
+		{`length(setsubtract(var.azs, ["a", "b"])) == 0`, &EnumRule{Values: []interface{}{"a", "b"}}, Path{constraint.Wildcard()}},
+		{`length(distinct(var.azs)) == length(var.other)`, nil, nil},
+		{`length(setsubtract(var.azs, ["a"])) == 1`, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			rule, path, err := parseCollectionRule(expr, "azs")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule)
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
+
+func TestParseCollectionRuleUntranslatable(t *testing.T) {
+	for _, condition := range []string{
+		`length(setsubtract(var.azs, local.allowed)) == 0`,
+		`length(setsubtract(var.azs, [local.primary, "b"])) == 0`,
+	} {
+		t.Run(condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			_, _, err := parseCollectionRule(expr, "azs")
+			var untranslatable *UntranslatableError
+			assert.ErrorAs(t, err, &untranslatable)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func init() {
+	RegisterRuleParserWithPriority(parseConditionalRule, 20)
+}
+
+// ConditionalRule represents a ternary validation expression, translated into
+// JSON Schema if/then/else. The paths of the scoped rules are relative to the
+// schema the conditional rule is applied to.
+type ConditionalRule struct {
+	If   ScopedRule
+	Then *ScopedRule // nil when the branch imposes no constraint
+	Else *ScopedRule // nil when the branch imposes no constraint
+}
+
+// Constraint lowers the conditional validation rule into the constraint IR, or
+// returns nil if one of its parts cannot be lowered.
+func (r *ConditionalRule) Constraint() constraint.Constraint {
+	cond := lower(r.If)
+	if cond == nil {
+		return nil
+	}
+	implies := constraint.Implies{If: cond}
+	if r.Then != nil {
+		if implies.Then = lower(*r.Then); implies.Then == nil {
+			return nil
+		}
+	}
+	if r.Else != nil {
+		if implies.Else = lower(*r.Else); implies.Else == nil {
+			return nil
+		}
+	}
+	return implies
+}
+
+// NeverRule is produced for a literal false branch; no value satisfies it.
+type NeverRule struct{}
+
+// Constraint lowers the never-satisfied rule into the constraint IR.
+func (r *NeverRule) Constraint() constraint.Constraint {
+	return constraint.Never{}
+}
+
+func parseConditionalRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	cond, ok := unwrapParen(expr).(*hclsyntax.ConditionalExpr)
+	if !ok {
+		return nil, nil, nil // Not a ternary expression.
+	}
+
+	rule, path, err := buildConditionalRule(cond, func(operand hcl.Expression) (Rule, Path, bool, error) {
+		return parseConditionOperand(operand, varName)
+	})
+	if err != nil || rule == nil {
+		return nil, nil, err
+	}
+	return rule, path, nil
+}
+
+// operandParser parses one operand of a ternary expression, reporting whether
+// it was recognised. A nil rule for a recognised operand means no constraint.
+type operandParser func(expr hcl.Expression) (Rule, Path, bool, error)
+
+// buildConditionalRule parses the predicate and both branches of a ternary
+// expression and anchors the resulting rule at the nearest common ancestor of
+// the constrained paths.
+func buildConditionalRule(cond *hclsyntax.ConditionalExpr, parseOperand operandParser) (*ConditionalRule, Path, error) {
+	predRule, predPath, matched, err := parseOperand(cond.Condition)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse condition of ternary expression: %w", err)
+	}
+	if !matched || predRule == nil {
+		return nil, nil, nil // A constant or unrecognised predicate cannot drive an if/then.
+	}
+
+	thenRule, thenPath, matched, err := parseOperand(cond.TrueResult)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse true branch of ternary expression: %w", err)
+	}
+	if !matched {
+		return nil, nil, nil
+	}
+
+	elseRule, elsePath, matched, err := parseOperand(cond.FalseResult)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse false branch of ternary expression: %w", err)
+	}
+	if !matched {
+		return nil, nil, nil
+	}
+
+	if thenRule == nil && elseRule == nil {
+		return nil, nil, nil // Both branches are unconstrained.
+	}
+
+	var thenScoped, elseScoped *ScopedRule
+	if thenRule != nil {
+		thenScoped = &ScopedRule{Rule: thenRule, Path: thenPath}
+	}
+	if elseRule != nil {
+		elseScoped = &ScopedRule{Rule: elseRule, Path: elsePath}
+	}
+	rule, path := newConditionalRule(ScopedRule{Rule: predRule, Path: predPath}, thenScoped, elseScoped)
+	return rule, path, nil
+}
+
+// newConditionalRule anchors a conditional rule at the nearest common ancestor
+// of the paths it constrains, returning that ancestor's path.
+func newConditionalRule(ifRule ScopedRule, thenRule, elseRule *ScopedRule) (*ConditionalRule, Path) {
+	paths := []Path{ifRule.Path}
+	if thenRule != nil {
+		paths = append(paths, thenRule.Path)
+	}
+	if elseRule != nil {
+		paths = append(paths, elseRule.Path)
+	}
+	common := constraint.CommonPrefix(paths...)
+
+	rule := &ConditionalRule{
+		If: ScopedRule{Rule: ifRule.Rule, Path: ifRule.Path[len(common):]},
+	}
+	if thenRule != nil {
+		rule.Then = &ScopedRule{Rule: thenRule.Rule, Path: thenRule.Path[len(common):]}
+	}
+	if elseRule != nil {
+		rule.Else = &ScopedRule{Rule: elseRule.Rule, Path: elseRule.Path[len(common):]}
+	}
+	return rule, common
+}
+
+// parseConditionOperand parses the predicate or a branch of a ternary
+// expression using the registered rule parsers. A literal true is matched
+// without producing a rule, as it imposes no constraint.
+func parseConditionOperand(expr hcl.Expression, varName string) (Rule, Path, bool, error) {
+	expr = unwrapParen(expr)
+
+	switch e := expr.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		if e.Val.Type() != cty.Bool || e.Val.IsNull() {
+			return nil, nil, false, nil
+		}
+		if e.Val.True() {
+			return nil, nil, true, nil
+		}
+		return &NeverRule{}, nil, true, nil
+
+	case *hclsyntax.ScopeTraversalExpr:
+		// A bare boolean reference such as var.cfg.enabled or s.public.
+		if !referencesVar(e, varName) {
+			return nil, nil, false, nil
+		}
+		path, err := pathHandler.ExtractPathFromExpression(e, varName)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return &EnumRule{Values: []interface{}{true}}, path, true, nil
+
+	case *hclsyntax.UnaryOpExpr:
+		trav, ok := unwrapParen(e.Val).(*hclsyntax.ScopeTraversalExpr)
+		if e.Op != hclsyntax.OpLogicalNot || !ok || !referencesVar(trav, varName) {
+			return nil, nil, false, nil
+		}
+		path, err := pathHandler.ExtractPathFromExpression(trav, varName)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return &EnumRule{Values: []interface{}{false}}, path, true, nil
+	}
+
+	for _, parser := range GetParsers() {
+		rule, path, err := parser(expr, varName)
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if rule != nil {
+			return rule, path, true, nil
+		}
+	}
+	return nil, nil, false, nil
+}
+
+// referencesVar reports whether expr refers to the given variable, either as
+// var.<name> or as a loop variable of that name.
+func referencesVar(expr hcl.Expression, varName string) bool {
+	for _, trav := range expr.Variables() {
+		if trav.RootName() == varName {
+			return true
+		}
+		if trav.RootName() != "var" || len(trav) < 2 {
+			continue
+		}
+		if attr, ok := trav[1].(hcl.TraverseAttr); ok && attr.Name == varName {
+			return true
+		}
+	}
+	return false
+}
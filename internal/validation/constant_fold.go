@@ -0,0 +1,174 @@
+package validation
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// constantContext evaluates the sub-expressions of a condition that do not
+// depend on any variable. It offers the Terraform functions whose results do
+// not depend on the environment.
+var constantContext = &hcl.EvalContext{
+	Functions: map[string]function.Function{
+		"abs":        stdlib.AbsoluteFunc,
+		"ceil":       stdlib.CeilFunc,
+		"chomp":      stdlib.ChompFunc,
+		"coalesce":   stdlib.CoalesceFunc,
+		"floor":      stdlib.FloorFunc,
+		"format":     stdlib.FormatFunc,
+		"join":       stdlib.JoinFunc,
+		"length":     lengthFunc,
+		"log":        stdlib.LogFunc,
+		"lower":      stdlib.LowerFunc,
+		"max":        stdlib.MaxFunc,
+		"min":        stdlib.MinFunc,
+		"parseint":   stdlib.ParseIntFunc,
+		"pow":        stdlib.PowFunc,
+		"signum":     stdlib.SignumFunc,
+		"strrev":     stdlib.ReverseFunc,
+		"substr":     stdlib.SubstrFunc,
+		"title":      stdlib.TitleFunc,
+		"trim":       stdlib.TrimFunc,
+		"trimprefix": stdlib.TrimPrefixFunc,
+		"trimspace":  stdlib.TrimSpaceFunc,
+		"trimsuffix": stdlib.TrimSuffixFunc,
+		"upper":      stdlib.UpperFunc,
+	},
+}
+
+// lengthFunc is Terraform's length, which counts the characters of a string
+// as well as the elements of a collection.
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "value", Type: cty.DynamicPseudoType},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})
+
+// foldConstants replaces the sub-expressions of expr that do not reference
+// any variable, such as 64 - 4 or pow(2, 16), with literals of their value, so
+// that rule parsers only have to recognise literals. References to the given
+// local values, such as local.ipv4_regex, are folded as well. Only strings,
+// numbers and booleans are folded; collections keep their syntax, which
+// parsers such as the enum parser inspect element by element.
+func foldConstants(expr hcl.Expression, locals map[string]cty.Value) hcl.Expression {
+	syntaxExpr, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return expr
+	}
+	f := folder{ctx: constantContext, locals: locals}
+	if len(locals) > 0 {
+		f.ctx = constantContext.NewChild()
+		f.ctx.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+	}
+	return f.fold(syntaxExpr)
+}
+
+// folder folds the constant sub-expressions of a condition.
+type folder struct {
+	ctx    *hcl.EvalContext
+	locals map[string]cty.Value
+}
+
+func (f folder) fold(expr hclsyntax.Expression) hclsyntax.Expression {
+	if literal, ok := f.constantLiteral(expr); ok {
+		return literal
+	}
+
+	// Fold the operands of an expression that depends on a variable, without
+	// modifying the original syntax tree.
+	switch e := expr.(type) {
+	case *hclsyntax.BinaryOpExpr:
+		folded := *e
+		folded.LHS, folded.RHS = f.fold(e.LHS), f.fold(e.RHS)
+		return &folded
+	case *hclsyntax.UnaryOpExpr:
+		folded := *e
+		folded.Val = f.fold(e.Val)
+		return &folded
+	case *hclsyntax.ParenthesesExpr:
+		folded := *e
+		folded.Expression = f.fold(e.Expression)
+		return &folded
+	case *hclsyntax.FunctionCallExpr:
+		folded := *e
+		folded.Args = f.foldAll(e.Args)
+		return &folded
+	case *hclsyntax.ConditionalExpr:
+		folded := *e
+		folded.Condition = f.fold(e.Condition)
+		folded.TrueResult = f.fold(e.TrueResult)
+		folded.FalseResult = f.fold(e.FalseResult)
+		return &folded
+	case *hclsyntax.TupleConsExpr:
+		folded := *e
+		folded.Exprs = f.foldAll(e.Exprs)
+		return &folded
+	case *hclsyntax.IndexExpr:
+		folded := *e
+		folded.Collection, folded.Key = f.fold(e.Collection), f.fold(e.Key)
+		return &folded
+	case *hclsyntax.ForExpr:
+		folded := *e
+		folded.CollExpr, folded.ValExpr = f.fold(e.CollExpr), f.fold(e.ValExpr)
+		if e.KeyExpr != nil {
+			folded.KeyExpr = f.fold(e.KeyExpr)
+		}
+		if e.CondExpr != nil {
+			folded.CondExpr = f.fold(e.CondExpr)
+		}
+		return &folded
+	}
+	return expr
+}
+
+func (f folder) foldAll(exprs []hclsyntax.Expression) []hclsyntax.Expression {
+	folded := make([]hclsyntax.Expression, len(exprs))
+	for i, expr := range exprs {
+		folded[i] = f.fold(expr)
+	}
+	return folded
+}
+
+// constantLiteral evaluates expr if it references no variable other than the
+// known local values and yields a known primitive value.
+func (f folder) constantLiteral(expr hclsyntax.Expression) (hclsyntax.Expression, bool) {
+	switch expr.(type) {
+	case *hclsyntax.LiteralValueExpr, *hclsyntax.TemplateExpr,
+		*hclsyntax.TupleConsExpr, *hclsyntax.ObjectConsExpr:
+		// Already literal, or a collection whose syntax parsers rely on.
+		return nil, false
+	}
+	for _, trav := range expr.Variables() {
+		if !f.isLocal(trav) {
+			return nil, false
+		}
+	}
+	val, diags := expr.Value(f.ctx)
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() || !val.Type().IsPrimitiveType() {
+		return nil, false
+	}
+	return &hclsyntax.LiteralValueExpr{Val: val, SrcRange: expr.Range()}, true
+}
+
+// isLocal reports whether trav refers to one of the known local values.
+func (f folder) isLocal(trav hcl.Traversal) bool {
+	if trav.RootName() != "local" || len(trav) < 2 {
+		return false
+	}
+	attr, ok := trav[1].(hcl.TraverseAttr)
+	if !ok {
+		return false
+	}
+	_, known := f.locals[attr.Name]
+	return known
+}
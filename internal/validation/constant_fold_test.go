@@ -0,0 +1,89 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestFoldConstants(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      cty.Value
+	}{
+		{"64 - 4", cty.NumberIntVal(60)},
+		{"16 * 1024", cty.NumberIntVal(16384)},
+		{"pow(2, 16)", cty.NumberIntVal(65536)},
+		{"-(12 * 60)", cty.NumberIntVal(-720)},
+		{"max(1, 2) > 1", cty.True},
+		{"upper(\"prod\")", cty.StringVal("PROD")},
+		{"length(\"abcd\")", cty.NumberIntVal(4)},
+		{"length([1, 2, 3])", cty.NumberIntVal(3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors())
+
+			literal, ok := foldConstants(expr, nil).(*hclsyntax.LiteralValueExpr)
+			require.True(t, ok, "expected a literal")
+			assert.True(t, literal.Val.Equals(tt.want).True(), "got %#v", literal.Val)
+		})
+	}
+}
+
+func TestFoldConstantsKeepsVariables(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte("length(var.name) <= 64 - 4"), "test.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	folded, ok := foldConstants(expr, nil).(*hclsyntax.BinaryOpExpr)
+	require.True(t, ok)
+	assert.IsType(t, &hclsyntax.FunctionCallExpr{}, folded.LHS)
+	assert.IsType(t, &hclsyntax.LiteralValueExpr{}, folded.RHS)
+	// The original expression is left untouched.
+	assert.IsType(t, &hclsyntax.BinaryOpExpr{}, expr.(*hclsyntax.BinaryOpExpr).RHS)
+
+	rule, path, err := parseLengthRule(folded, "name")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+	assert.NotNil(t, rule)
+}
+
+func TestFoldConstantsKeepsCollections(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`contains(["a", lower("B")], var.x)`), "test.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	call := foldConstants(expr, nil).(*hclsyntax.FunctionCallExpr)
+	tuple, ok := call.Args[0].(*hclsyntax.TupleConsExpr)
+	require.True(t, ok)
+	assert.IsType(t, &hclsyntax.TemplateExpr{}, tuple.Exprs[0])
+	assert.IsType(t, &hclsyntax.LiteralValueExpr{}, tuple.Exprs[1])
+}
+
+func TestFoldConstantsResolvesLocals(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`can(regex(local.ipv4_regex, var.ip)) && length(var.ip) <= local.max + 1 && local.other`), "test.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	folded := foldConstants(expr, map[string]cty.Value{
+		"ipv4_regex": cty.StringVal(`^\d+$`),
+		"max":        cty.NumberIntVal(14),
+	}).(*hclsyntax.BinaryOpExpr)
+	checks := folded.LHS.(*hclsyntax.BinaryOpExpr)
+
+	regex := checks.LHS.(*hclsyntax.FunctionCallExpr).Args[0].(*hclsyntax.FunctionCallExpr)
+	literal, ok := regex.Args[0].(*hclsyntax.LiteralValueExpr)
+	require.True(t, ok, "expected the local pattern to be folded")
+	assert.Equal(t, `^\d+$`, literal.Val.AsString())
+
+	limit, ok := checks.RHS.(*hclsyntax.BinaryOpExpr).RHS.(*hclsyntax.LiteralValueExpr)
+	require.True(t, ok, "expected the local limit to be folded")
+	assert.True(t, limit.Val.Equals(cty.NumberIntVal(15)).True())
+
+	// Unknown locals are kept as references.
+	assert.IsType(t, &hclsyntax.ScopeTraversalExpr{}, folded.RHS)
+}
@@ -0,0 +1,125 @@
+package validation
+
+import (
+	"sort"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// parseCrossVariableRule parses a condition that refers to variables other
+// than varName, as allowed since Terraform 1.9. The returned rule applies to
+// the root schema and its path starts with a variable name.
+func parseCrossVariableRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	expr = unwrapParen(expr)
+
+	switch e := expr.(type) {
+	case *hclsyntax.ConditionalExpr:
+		rule, path, err := buildConditionalRule(e, parseRootOperand)
+		if err != nil || rule == nil {
+			return nil, nil, err
+		}
+		return rule, path, nil
+
+	case *hclsyntax.BinaryOpExpr:
+		lhs, lhsRef := traversal(e.LHS)
+		rhs, rhsRef := traversal(e.RHS)
+		if isComparison(e.Op) && lhsRef && rhsRef && lhs.RootName() == "var" && rhs.RootName() == "var" {
+			return nil, nil, &UntranslatableError{Reason: "JSON Schema cannot compare the values of two fields"}
+		}
+	}
+
+	rule, path, matched, err := parseRootOperand(expr)
+	if err != nil || !matched || rule == nil {
+		return nil, nil, err
+	}
+	return rule, path, nil
+}
+
+// parseRootOperand parses an expression that constrains exactly one variable,
+// returning a path relative to the root schema.
+func parseRootOperand(expr hcl.Expression) (Rule, Path, bool, error) {
+	expr = unwrapParen(expr)
+
+	names := referencedVariables(expr)
+	if len(names) == 0 {
+		// Only literal operands are meaningful without a variable.
+		return parseConditionOperand(expr, "")
+	}
+	if len(names) != 1 {
+		return nil, nil, false, nil
+	}
+	name := names[0]
+
+	if isNotNullCheck(expr, name) {
+		return &RequiredRule{Properties: []string{name}}, nil, true, nil
+	}
+
+	rule, path, matched, err := parseConditionOperand(expr, name)
+	if err != nil || !matched {
+		return nil, nil, matched, err
+	}
+	return rule, Path{constraint.Attr(name)}.Append(path...), true, nil
+}
+
+// isNotNullCheck reports whether expr is var.<name> != null.
+func isNotNullCheck(expr hcl.Expression, name string) bool {
+	binary, ok := expr.(*hclsyntax.BinaryOpExpr)
+	if !ok || binary.Op != hclsyntax.OpNotEqual {
+		return false
+	}
+	isWholeVar := func(e hcl.Expression) bool {
+		trav, ok := unwrapParen(e).(*hclsyntax.ScopeTraversalExpr)
+		return ok && len(trav.Traversal) == 2 && referencesVar(trav, name)
+	}
+	return (isWholeVar(binary.LHS) && isNullLiteral(binary.RHS)) ||
+		(isNullLiteral(binary.LHS) && isWholeVar(binary.RHS))
+}
+
+func isNullLiteral(expr hcl.Expression) bool {
+	lit, ok := unwrapParen(expr).(*hclsyntax.LiteralValueExpr)
+	return ok && lit.Val.IsNull()
+}
+
+func isComparison(op *hclsyntax.Operation) bool {
+	switch op {
+	case hclsyntax.OpGreaterThan, hclsyntax.OpGreaterThanOrEqual,
+		hclsyntax.OpLessThan, hclsyntax.OpLessThanOrEqual,
+		hclsyntax.OpEqual, hclsyntax.OpNotEqual:
+		return true
+	}
+	return false
+}
+
+// referencedVariables returns the sorted names of all variables expr refers
+// to through var.<name>.
+func referencedVariables(expr hcl.Expression) []string {
+	seen := make(map[string]bool)
+	for _, trav := range expr.Variables() {
+		if trav.RootName() != "var" || len(trav) < 2 {
+			continue
+		}
+		if attr, ok := trav[1].(hcl.TraverseAttr); ok {
+			seen[attr.Name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// otherVariables returns the declared variables other than varName that expr
+// refers to.
+func otherVariables(expr hcl.Expression, varName string, declared []string) []string {
+	var others []string
+	for _, name := range referencedVariables(expr) {
+		if name != varName && containsString(declared, name) {
+			others = append(others, name)
+		}
+	}
+	return others
+}
@@ -3,7 +3,7 @@ package validation
 import (
 	"fmt"
 
-	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
@@ -11,6 +11,7 @@ import (
 
 func init() {
 	RegisterRuleParserWithPriority(parseEnumRule, 0)
+	RegisterRuleParserWithPriority(parseNotEqualRule, 0)
 }
 
 // EnumRule represents an enum validation rule.
@@ -18,13 +19,22 @@ type EnumRule struct {
 	Values []interface{}
 }
 
-// Apply applies the enum validation rule to a JSON schema.
-func (r *EnumRule) Apply(schema *jsonschema.Schema) error {
-	schema.Enum = r.Values
-	return nil
+// Constraint lowers the enum validation rule into the constraint IR.
+func (r *EnumRule) Constraint() constraint.Constraint {
+	return atom(constraint.Enum{Values: r.Values})
 }
 
-func parseEnumRule(expr hcl.Expression, varName string) (Rule, []string, error) {
+// NotEnumRule represents a validation rule excluding specific values.
+type NotEnumRule struct {
+	Values []interface{}
+}
+
+// Constraint lowers the excluded values validation rule into the constraint IR.
+func (r *NotEnumRule) Constraint() constraint.Constraint {
+	return constraint.Not{Term: atom(constraint.Enum{Values: r.Values})}
+}
+
+func parseEnumRule(expr hcl.Expression, varName string) (Rule, Path, error) {
 	call, ok := expr.(*hclsyntax.FunctionCallExpr)
 	if !ok || call.Name != "contains" {
 		return nil, nil, nil // Not a 'contains' function call.
@@ -44,11 +54,22 @@ func parseEnumRule(expr hcl.Expression, varName string) (Rule, []string, error)
 		return nil, nil, fmt.Errorf("first argument to 'contains' for enum validation must be a literal list")
 	}
 
+	values, err := literalValues(listExpr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rule := &EnumRule{Values: values}
+	return rule, path, nil
+}
+
+// literalValues evaluates the elements of a literal list of primitives.
+func literalValues(listExpr *hclsyntax.TupleConsExpr) ([]interface{}, error) {
 	var values []interface{}
 	for _, itemExpr := range listExpr.Exprs {
 		val, diags := itemExpr.Value(nil)
 		if diags.HasErrors() {
-			return nil, nil, fmt.Errorf("failed to evaluate enum value: %s", diags.Error())
+			return nil, fmt.Errorf("failed to evaluate enum value: %s", diags.Error())
 		}
 		switch val.Type() {
 		case cty.String:
@@ -59,10 +80,34 @@ func parseEnumRule(expr hcl.Expression, varName string) (Rule, []string, error)
 		case cty.Bool:
 			values = append(values, val.True())
 		default:
-			return nil, nil, fmt.Errorf("unsupported type in enum validation: %s", val.Type().FriendlyName())
+			return nil, fmt.Errorf("unsupported type in enum validation: %s", val.Type().FriendlyName())
 		}
 	}
+	return values, nil
+}
 
-	rule := &EnumRule{Values: values}
-	return rule, path, nil
+// parseNotEqualRule handles var.x != "value", excluding a single literal.
+func parseNotEqualRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	binary, ok := unwrapParen(expr).(*hclsyntax.BinaryOpExpr)
+	if !ok || binary.Op != hclsyntax.OpNotEqual {
+		return nil, nil, nil
+	}
+
+	subject, valueExpr := binary.LHS, binary.RHS
+	if !isReference(subject, varName) {
+		subject, valueExpr = binary.RHS, binary.LHS
+	}
+	if !isReference(subject, varName) || isNullLiteral(valueExpr) {
+		return nil, nil, nil
+	}
+	value, err := extractLiteralValue(unwrapParen(valueExpr))
+	if err != nil {
+		return nil, nil, nil // Not compared with a literal.
+	}
+
+	path, err := pathHandler.ExtractPathFromExpression(unwrapParen(subject), varName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &NotEnumRule{Values: []interface{}{value}}, path, nil
 }
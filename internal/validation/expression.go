@@ -0,0 +1,66 @@
+package validation
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// unwrapParen recursively removes any enclosing ParenthesesExpr
+func unwrapParen(expr hcl.Expression) hcl.Expression {
+	for {
+		if p, ok := expr.(*hclsyntax.ParenthesesExpr); ok {
+			expr = p.Expression
+			continue
+		}
+		return expr
+	}
+}
+
+// traversal returns the traversal of an expression that is a plain reference,
+// such as var.x.name or local.pattern.
+func traversal(expr hcl.Expression) (hcl.Traversal, bool) {
+	ref, ok := unwrapParen(expr).(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return nil, false
+	}
+	return ref.Traversal, true
+}
+
+// isReference reports whether expr is a plain reference to the variable being
+// validated or to part of it: var.<name>, a loop variable of that name, self
+// or each.
+func isReference(expr hcl.Expression, varName string) bool {
+	trav, ok := traversal(expr)
+	if !ok {
+		return false
+	}
+	switch trav.RootName() {
+	case varName, "self", "each":
+		return true
+	case "var":
+		if len(trav) < 2 {
+			return false
+		}
+		attr, ok := trav[1].(hcl.TraverseAttr)
+		return ok && attr.Name == varName
+	}
+	return false
+}
+
+// calledFunction returns the function call an expression makes, looking
+// through parentheses and a surrounding can(...), and whether the call was
+// wrapped in can.
+func calledFunction(expr hcl.Expression) (*hclsyntax.FunctionCallExpr, bool) {
+	call, ok := unwrapParen(expr).(*hclsyntax.FunctionCallExpr)
+	if !ok {
+		return nil, false
+	}
+	if call.Name != "can" || len(call.Args) != 1 {
+		return call, false
+	}
+	inner, ok := unwrapParen(call.Args[0]).(*hclsyntax.FunctionCallExpr)
+	if !ok {
+		return nil, false
+	}
+	return inner, true
+}
@@ -0,0 +1,158 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func init() {
+	// Must run before the regex parser so that well-known patterns become formats.
+	RegisterRuleParserWithPriority(parseFormatRule, 11)
+}
+
+// Patterns for CIDR notation, which JSON Schema has no format for.
+const (
+	ipv4CIDRPattern = `^((25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])/(3[0-2]|[12]?[0-9])$`
+	ipv6CIDRPattern = `^[0-9A-Fa-f:]*:[0-9A-Fa-f:.]*/(12[0-8]|1[01][0-9]|[1-9]?[0-9])$`
+)
+
+// cidrPattern accepts both IPv4 and IPv6 CIDR blocks, as cidrhost does.
+var cidrPattern = "(" + ipv4CIDRPattern + ")|(" + ipv6CIDRPattern + ")"
+
+// knownFormatRegexes maps regexes commonly used to validate IP addresses to
+// the JSON Schema format they stand for. Most are looser than the format, so
+// the regex is kept as a pattern next to it.
+var knownFormatRegexes = map[string]string{
+	`^(?:[0-9]{1,3}\.){3}[0-9]{1,3}$`:      "ipv4",
+	`^([0-9]{1,3}\.){3}[0-9]{1,3}$`:        "ipv4",
+	`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`: "ipv4",
+	`^((25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)$`:       "ipv4",
+	`^(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)$`: "ipv4",
+	`^((25[0-5]|(2[0-4]|1\d|[1-9]|)\d)\.?\b){4}$`:                                                 "ipv4",
+	`^(([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4})$`:                                                  "ipv6",
+}
+
+// FormatRule sets the JSON Schema format of a string, and the pattern of the
+// regex the format was recognised from, if any.
+type FormatRule struct {
+	Format  string
+	Pattern string
+	RE2     string // the pattern as written, if Pattern is a translation of it
+}
+
+// Constraint lowers the format validation rule into the constraint IR.
+func (r *FormatRule) Constraint() constraint.Constraint {
+	format := atom(constraint.Format{Format: r.Format})
+	if r.Pattern == "" {
+		return format
+	}
+	return constraint.And{Terms: []constraint.Constraint{
+		format,
+		atom(constraint.Pattern{Pattern: r.Pattern, RE2: r.RE2}),
+	}}
+}
+
+// PatternRule adds a pattern to a string, keeping any pattern it already has.
+type PatternRule struct {
+	Pattern string
+	RE2     string // the pattern as written, if Pattern is a translation of it
+}
+
+// Constraint lowers the pattern validation rule into the constraint IR.
+func (r *PatternRule) Constraint() constraint.Constraint {
+	return atom(constraint.Pattern{Pattern: r.Pattern, RE2: r.RE2})
+}
+
+// parseFormatRule recognises the function calls used to validate network
+// addresses and timestamps:
+//
+//	can(cidrhost(var.x, 0)), can(cidrnetmask(var.x))     -> CIDR pattern
+//	can(regex(<well-known IP regex>, var.x))             -> format ipv4/ipv6 and pattern
+//	can(timeadd(var.x, "0s")), can(formatdate(f, var.x)) -> format date-time
+func parseFormatRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	call, inCan := calledFunction(expr)
+	if !inCan {
+		return nil, nil, nil
+	}
+
+	var rule Rule
+	var subject hcl.Expression
+	switch {
+	case call.Name == "cidrhost" && len(call.Args) == 2:
+		rule, subject = &PatternRule{Pattern: cidrPattern}, call.Args[0]
+	case call.Name == "cidrnetmask" && len(call.Args) == 1:
+		rule, subject = &PatternRule{Pattern: ipv4CIDRPattern}, call.Args[0]
+	case call.Name == "timeadd" && len(call.Args) == 2:
+		rule, subject = &FormatRule{Format: "date-time"}, call.Args[0]
+	case call.Name == "formatdate" && len(call.Args) == 2:
+		rule, subject = &FormatRule{Format: "date-time"}, call.Args[1]
+	case call.Name == "regex" && len(call.Args) == 2:
+		pattern, ok := stringLiteral(call.Args[0])
+		if !ok {
+			if ref, isRef := unwrapParen(call.Args[0]).(*hclsyntax.ScopeTraversalExpr); isRef {
+				// e.g. a local value computed from others, which is not folded in.
+				return nil, nil, &UntranslatableError{Reason: fmt.Sprintf(
+					"the regex pattern is taken from %s, whose value is not known", referenceName(ref.Traversal))}
+			}
+			return nil, nil, nil
+		}
+		format, known := knownFormatRegexes[pattern]
+		if !known {
+			return nil, nil, nil
+		}
+		translated, err := TranslateRegex(pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		rule = &FormatRule{Format: format, Pattern: translated, RE2: re2Source(pattern, translated)}
+		subject = call.Args[1]
+	default:
+		return nil, nil, nil
+	}
+
+	subject = unwrapParen(subject)
+	if !isReference(subject, varName) {
+		return nil, nil, nil
+	}
+	path, err := pathHandler.ExtractPathFromExpression(subject, varName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rule, path, nil
+}
+
+// referenceName renders a traversal such as local.ipv4_regex.
+func referenceName(traversal hcl.Traversal) string {
+	name := traversal.RootName()
+	for _, step := range traversal[1:] {
+		if attr, ok := step.(hcl.TraverseAttr); ok {
+			name += "." + attr.Name
+		}
+	}
+	return name
+}
+
+// stringLiteral returns the value of a literal string expression.
+func stringLiteral(expr hcl.Expression) (string, bool) {
+	switch e := unwrapParen(expr).(type) {
+	case *hclsyntax.TemplateExpr:
+		if !e.IsStringLiteral() {
+			return "", false
+		}
+		val, diags := e.Value(nil)
+		if diags.HasErrors() {
+			return "", false
+		}
+		return val.AsString(), true
+	case *hclsyntax.LiteralValueExpr:
+		if !e.Val.Type().Equals(cty.String) {
+			return "", false
+		}
+		return e.Val.AsString(), true
+	}
+	return "", false
+}
@@ -0,0 +1,33 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormatRuleKeepsIPRegex(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`can(regex("^\\d{1,3}\\.\\d{1,3}\\.\\d{1,3}\\.\\d{1,3}$", var.ip))`), "test.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	rule, path, err := parseFormatRule(expr, "ip")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+	assert.Equal(t, &FormatRule{
+		Format:  "ipv4",
+		Pattern: `^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`,
+	}, rule)
+}
+
+func TestParseFormatRuleRegexFromLocal(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte(`can(regex(local.ipv4_regex, var.ip))`), "test.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	_, _, err := parseFormatRule(expr, "ip")
+	var untranslatable *UntranslatableError
+	require.ErrorAs(t, err, &untranslatable)
+	assert.Contains(t, untranslatable.Reason, "local.ipv4_regex")
+}
@@ -0,0 +1,216 @@
+package validation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func init() {
+	// Must run before the range parser, which rejects function calls in comparisons.
+	RegisterRuleParserWithPriority(parseIntegerRule, 15)
+}
+
+// IntegerRule requires a number to be whole. Applied to a string, it requires
+// the string to hold a base-10 integer instead.
+type IntegerRule struct{}
+
+// Constraint lowers the integer validation rule into the constraint IR.
+func (r *IntegerRule) Constraint() constraint.Constraint {
+	return atom(constraint.Integer{})
+}
+
+// MultipleOfRule requires a number to be a multiple of Value, or with Negate
+// set, not to be one. Integer additionally requires a whole number, as a
+// remainder such as var.x % 2 == 1 does: 2.5 is not a multiple of 2, but it is
+// not odd either.
+type MultipleOfRule struct {
+	Value   float64
+	Negate  bool
+	Integer bool
+}
+
+// Constraint lowers the multiple-of validation rule into the constraint IR.
+func (r *MultipleOfRule) Constraint() constraint.Constraint {
+	multiple := atom(constraint.MultipleOf{Value: r.Value})
+	if r.Negate {
+		multiple = constraint.Not{Term: multiple}
+	}
+	if !r.Integer {
+		return multiple
+	}
+	return constraint.And{Terms: []constraint.Constraint{atom(constraint.Integer{}), multiple}}
+}
+
+// parseIntegerRule recognises the idioms used to require whole numbers and
+// multiples: floor(var.x) == var.x, can(parseint(var.x, 10)),
+// var.x % 1 == 0 and var.x % 8 == 0. can(parseint(var.x, 16)) and other
+// bases require the digits of the base instead.
+func parseIntegerRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	expr = unwrapParen(expr)
+
+	switch e := expr.(type) {
+	case *hclsyntax.FunctionCallExpr:
+		call, inCan := calledFunction(e)
+		if !inCan || call.Name != "parseint" || len(call.Args) != 2 {
+			return nil, nil, nil
+		}
+		subject := unwrapToString(call.Args[0])
+		if !isReference(subject, varName) {
+			return nil, nil, nil
+		}
+		base, err := extractNumericValue(unwrapParen(call.Args[1]))
+		if err != nil {
+			return nil, nil, &UntranslatableError{Reason: "base of parseint is not a number literal"}
+		}
+		if base != float64(int(base)) || base < 2 || base > 36 {
+			return nil, nil, &UntranslatableError{Reason: fmt.Sprintf("parseint does not accept base %v", base)}
+		}
+		path, err := pathHandler.ExtractPathFromExpression(subject, varName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if base != 10 {
+			return &PatternRule{Pattern: integerPatternForBase(int(base))}, path, nil
+		}
+		return &IntegerRule{}, path, nil
+
+	case *hclsyntax.BinaryOpExpr:
+		if e.Op != hclsyntax.OpEqual && e.Op != hclsyntax.OpNotEqual {
+			return nil, nil, nil
+		}
+		if subject := wholeNumberSubject(e.LHS, e.RHS, varName); subject != nil && e.Op == hclsyntax.OpEqual {
+			path, err := pathHandler.ExtractPathFromExpression(subject, varName)
+			if err != nil {
+				return nil, nil, err
+			}
+			return &IntegerRule{}, path, nil
+		}
+		return parseModuloRule(e, varName)
+	}
+
+	return nil, nil, nil
+}
+
+// integerPatternForBase returns a pattern matching the strings parseint
+// accepts in base: an optional sign and the digits of the base, in either case
+// for bases above 10.
+func integerPatternForBase(base int) string {
+	const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+	class := "0-" + digits[base-1:base]
+	if base > 10 {
+		last := digits[base-1 : base]
+		class = "0-9a-" + last + "A-" + strings.ToUpper(last)
+	}
+	return "^[+-]?[" + class + "]+$"
+}
+
+// wholeNumberSubject returns the variable reference of a comparison such as
+// floor(var.x) == var.x, in either order, or nil if the comparison is not one.
+func wholeNumberSubject(lhs, rhs hcl.Expression, varName string) hcl.Expression {
+	for _, pair := range [][2]hcl.Expression{{lhs, rhs}, {rhs, lhs}} {
+		call, ok := unwrapParen(pair[0]).(*hclsyntax.FunctionCallExpr)
+		if !ok || len(call.Args) == 0 {
+			continue
+		}
+		switch call.Name {
+		case "floor", "ceil", "parseint":
+		default:
+			continue
+		}
+		subject := unwrapParen(pair[1])
+		if isReference(subject, varName) && sameTraversal(unwrapToString(call.Args[0]), subject) {
+			return subject
+		}
+	}
+	return nil
+}
+
+// parseModuloRule handles var.x % n == r and var.x % n != r comparisons.
+func parseModuloRule(expr *hclsyntax.BinaryOpExpr, varName string) (Rule, Path, error) {
+	modulo, remainderExpr := expr.LHS, expr.RHS
+	if _, ok := unwrapParen(modulo).(*hclsyntax.BinaryOpExpr); !ok {
+		modulo, remainderExpr = expr.RHS, expr.LHS
+	}
+	mod, ok := unwrapParen(modulo).(*hclsyntax.BinaryOpExpr)
+	if !ok || mod.Op != hclsyntax.OpModulo || !isReference(mod.LHS, varName) {
+		return nil, nil, nil
+	}
+
+	divisor, err := extractNumericValue(unwrapParen(mod.RHS))
+	if err != nil {
+		return nil, nil, &UntranslatableError{Reason: "divisor of modulo validation is not a number literal"}
+	}
+	remainder, err := extractNumericValue(unwrapParen(remainderExpr))
+	if err != nil {
+		return nil, nil, &UntranslatableError{Reason: "remainder of modulo validation is not a number literal"}
+	}
+	if divisor == 0 {
+		return nil, nil, fmt.Errorf("divisor of modulo validation must not be zero")
+	}
+
+	path, err := pathHandler.ExtractPathFromExpression(mod.LHS, varName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mustMatch := expr.Op == hclsyntax.OpEqual
+	switch {
+	case remainder == 0 && divisor == 1 && mustMatch:
+		return &IntegerRule{}, path, nil
+	case remainder == 0:
+		// A number that leaves any remainder, whole or not, is not a multiple.
+		return &MultipleOfRule{Value: divisor, Negate: !mustMatch}, path, nil
+	case divisor == 2 && remainder == 1 && mustMatch:
+		// An odd number is a whole number that is not even.
+		return &MultipleOfRule{Value: 2, Negate: true, Integer: true}, path, nil
+	}
+	return nil, nil, &UntranslatableError{Reason: fmt.Sprintf("JSON Schema cannot express a remainder of %v modulo %v", remainder, divisor)}
+}
+
+// unwrapToString strips a tostring() conversion, as in parseint(tostring(var.x), 10).
+func unwrapToString(expr hcl.Expression) hcl.Expression {
+	expr = unwrapParen(expr)
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "tostring" && len(call.Args) == 1 {
+		return unwrapParen(call.Args[0])
+	}
+	return expr
+}
+
+// sameTraversal reports whether two expressions are the same variable reference.
+func sameTraversal(a, b hcl.Expression) bool {
+	ta, ok := a.(*hclsyntax.ScopeTraversalExpr)
+	if !ok {
+		return false
+	}
+	tb, ok := b.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || len(ta.Traversal) != len(tb.Traversal) {
+		return false
+	}
+	for i := range ta.Traversal {
+		switch sa := ta.Traversal[i].(type) {
+		case hcl.TraverseRoot:
+			sb, ok := tb.Traversal[i].(hcl.TraverseRoot)
+			if !ok || sa.Name != sb.Name {
+				return false
+			}
+		case hcl.TraverseAttr:
+			sb, ok := tb.Traversal[i].(hcl.TraverseAttr)
+			if !ok || sa.Name != sb.Name {
+				return false
+			}
+		case hcl.TraverseIndex:
+			sb, ok := tb.Traversal[i].(hcl.TraverseIndex)
+			if !ok || !sa.Key.Type().Equals(sb.Key.Type()) || sa.Key.Equals(sb.Key) != cty.True {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
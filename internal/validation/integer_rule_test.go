@@ -0,0 +1,72 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIntegerRule(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      Rule
+	}{
+		{"floor(var.count) == var.count", &IntegerRule{}},
+		{"var.count == ceil(var.count)", &IntegerRule{}},
+		{"can(parseint(var.count, 10))", &IntegerRule{}},
+		{"can(parseint(var.count, 16))", &PatternRule{Pattern: "^[+-]?[0-9a-fA-F]+$"}},
+		{"can(parseint(var.count, 8))", &PatternRule{Pattern: "^[+-]?[0-7]+$"}},
+		{"var.count % 1 == 0", &IntegerRule{}},
+		{"var.count % 8 == 0", &MultipleOfRule{Value: 8}},
+		{"var.count % 2 != 0", &MultipleOfRule{Value: 2, Negate: true}},
+		{"var.count % 8 != 0", &MultipleOfRule{Value: 8, Negate: true}},
+		{"var.count % 2 == 1", &MultipleOfRule{Value: 2, Negate: true, Integer: true}},
+		{"floor(var.other) == var.count", nil},
+		{"var.count > 1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			rule, _, err := parseIntegerRule(expr, "count")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule)
+		})
+	}
+}
+
+func TestParseIntegerRuleUntranslatable(t *testing.T) {
+	for _, condition := range []string{
+		"var.count % 3 == 1",
+		"var.count % local.step == 0",
+		"var.count % 8 == local.offset",
+		"can(parseint(var.count, local.base))",
+	} {
+		t.Run(condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			_, _, err := parseIntegerRule(expr, "count")
+			var untranslatable *UntranslatableError
+			assert.ErrorAs(t, err, &untranslatable)
+		})
+	}
+}
+
+func TestNegatedMultipleOfAllowsFractions(t *testing.T) {
+	schema := &jsonschema.Schema{Type: "number"}
+	require.NoError(t, jsonschema.Constrain(schema, (&MultipleOfRule{Value: 8, Negate: true}).Constraint()))
+	assert.Equal(t, "number", schema.Type)
+	require.NotNil(t, schema.Not)
+	assert.Equal(t, 8.0, *schema.Not.MultipleOf)
+
+	odd := &jsonschema.Schema{Type: "number"}
+	require.NoError(t, jsonschema.Constrain(odd, (&MultipleOfRule{Value: 2, Negate: true, Integer: true}).Constraint()))
+	assert.Equal(t, "integer", odd.Type)
+}
@@ -3,7 +3,7 @@ package validation
 import (
 	"fmt"
 
-	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
@@ -19,226 +19,148 @@ type LengthRule struct {
 	Value    int
 }
 
-// getBaseType extracts the primary non-null type from a schema's Type field.
-func getBaseType(t interface{}) string {
-	if typeStr, ok := t.(string); ok {
-		return typeStr
-	}
-	if typeSlice, ok := t.([]interface{}); ok {
-		for _, v := range typeSlice {
-			if typeStr, ok := v.(string); ok && typeStr != "null" {
-				return typeStr
-			}
-		}
-	}
-	return ""
-}
-
-// Apply applies the length validation rule to a JSON schema.
-func (r *LengthRule) Apply(schema *jsonschema.Schema) error {
-	val := r.Value
-	baseType := getBaseType(schema.Type)
-
-	applyLength := func(min, max *int) {
-		switch baseType {
-		case "string":
-			schema.MinLength = min
-			schema.MaxLength = max
-		case "array":
-			schema.MinItems = min
-			schema.MaxItems = max
-		case "object":
-			schema.MinProperties = min
-			schema.MaxProperties = max
-		}
-	}
-
-	switch r.Operator {
-	case hclsyntax.OpGreaterThan:
-		minVal := val + 1
-		applyLength(&minVal, nil)
-	case hclsyntax.OpGreaterThanOrEqual:
-		applyLength(&val, nil)
-	case hclsyntax.OpLessThan:
-		maxVal := val - 1
-		applyLength(nil, &maxVal)
-	case hclsyntax.OpLessThanOrEqual:
-		applyLength(nil, &val)
-	case hclsyntax.OpEqual:
-		applyLength(&val, &val)
-	default:
-		return fmt.Errorf("unsupported operator for length validation: %v", r.Operator)
-	}
-	return nil
-}
-
-// lengthRuleVisitor implements the hclsyntax.Walker interface to traverse
-// an expression tree and build a LengthRule.
-type lengthRuleVisitor struct {
-	varName string
-	path    []string
-	rules   []*LengthRule
-	err     error
-}
-
-func (v *lengthRuleVisitor) Enter(node hclsyntax.Node) hcl.Diagnostics {
-	// Handle different node types during tree traversal
-	if n, ok := node.(*hclsyntax.BinaryOpExpr); ok {
-		return v.visitBinaryOpExpr(n)
-	}
-	return nil
-}
-
-func (v *lengthRuleVisitor) Exit(node hclsyntax.Node) hcl.Diagnostics {
-	// No special processing needed on exit for length rules
-	return nil
-}
-
-func (v *lengthRuleVisitor) visitBinaryOpExpr(expr *hclsyntax.BinaryOpExpr) hcl.Diagnostics {
-	if expr.Op == hclsyntax.OpLogicalAnd {
-		// Continue traversal for compound expressions
-		return nil
-	}
-
-	call, ok := expr.LHS.(*hclsyntax.FunctionCallExpr)
-	if !ok || call.Name != "length" {
-		return nil // Not a length() call comparison.
-	}
-
-	path, err := pathHandler.ExtractPathFromExpression(call.Args[0], v.varName)
+// Constraint lowers the length validation rule into the constraint IR.
+func (r *LengthRule) Constraint() constraint.Constraint {
+	compound, err := mergeLengthRules([]*LengthRule{r})
 	if err != nil {
-		v.err = err
 		return nil
 	}
-	if v.path == nil {
-		v.path = path
-	}
+	return compound.Constraint()
+}
 
-	if len(call.Args) != 1 {
-		v.err = fmt.Errorf("'length' function expects exactly one argument")
-		return nil
+// lengthComparison matches a comparison between length(x) and a bound, in
+// either order. The operator is normalised to read length(x) <op> bound, and
+// keys(x) and values(x) are unwrapped since they have the length of x.
+func lengthComparison(expr hcl.Expression) (subject hcl.Expression, op *hclsyntax.Operation, bound hcl.Expression, ok bool) {
+	binary, isBinary := unwrapParen(expr).(*hclsyntax.BinaryOpExpr)
+	if !isBinary {
+		return nil, nil, nil, false
 	}
-
-	lit, ok := expr.RHS.(*hclsyntax.LiteralValueExpr)
+	op, bound = binary.Op, binary.RHS
+	subject, ok = lengthArgument(binary.LHS)
 	if !ok {
-		v.err = fmt.Errorf("rhs of length validation must be a literal value")
-		return nil
+		// Normalise 0 < length(x) to length(x) > 0.
+		op, bound = flipComparison(binary.Op), binary.LHS
+		subject, ok = lengthArgument(binary.RHS)
 	}
-
-	if lit.Val.Type() != cty.Number {
-		v.err = fmt.Errorf("rhs of length validation must be a number")
-		return nil
+	if !ok || op == nil {
+		return nil, nil, nil, false
 	}
-
-	val, _ := lit.Val.AsBigFloat().Int64()
-
-	rule := &LengthRule{
-		Operator: expr.Op,
-		Value:    int(val),
+	if call, isCall := subject.(*hclsyntax.FunctionCallExpr); isCall && (call.Name == "keys" || call.Name == "values") && len(call.Args) == 1 {
+		subject = unwrapParen(call.Args[0])
 	}
-	v.rules = append(v.rules, rule)
-
-	return nil
+	return subject, op, bound, true
 }
 
-func parseLengthRule(expr hcl.Expression, varName string) (Rule, []string, error) {
-	if !containsLengthCall(expr) {
-		return nil, nil, nil // No length() calls found
-	}
-
-	node, ok := expr.(hclsyntax.Node)
-	if !ok {
-		return nil, nil, fmt.Errorf("expression is not a syntax node")
-	}
-
-	visitor := &lengthRuleVisitor{
-		varName: varName,
-	}
+// parseLengthRule handles conditions made of length comparisons joined with
+// &&, all on the same value, such as 3 <= length(var.x) && length(var.x) <= 10.
+// Conditions that mix in other checks are left to the other parsers.
+func parseLengthRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	var rules []*LengthRule
+	var path Path
+	for i, part := range conjuncts(expr) {
+		subject, op, bound, ok := lengthComparison(part)
+		if !ok {
+			return nil, nil, nil // Not a length() call comparison.
+		}
 
-	diags := hclsyntax.Walk(node, visitor)
-	if diags.HasErrors() {
-		return nil, nil, diags
-	}
-	if visitor.err != nil {
-		return nil, nil, visitor.err
-	}
+		partPath, err := pathHandler.ExtractPathFromExpression(subject, varName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i > 0 && !path.Equal(partPath) {
+			return nil, nil, &UntranslatableError{Reason: "the condition bounds the lengths of different values"}
+		}
+		path = partPath
 
-	if len(visitor.rules) == 0 {
-		return nil, nil, nil // No length rule found
+		lit, ok := unwrapParen(bound).(*hclsyntax.LiteralValueExpr)
+		if !ok {
+			// Constants are folded beforehand, so this refers to another value.
+			return nil, nil, &UntranslatableError{Reason: "the length is compared with a value that is not a constant"}
+		}
+		if lit.Val.Type() != cty.Number {
+			return nil, nil, fmt.Errorf("length must be compared with a number")
+		}
+		val, _ := lit.Val.AsBigFloat().Int64()
+		rules = append(rules, &LengthRule{Operator: op, Value: int(val)})
 	}
 
-	path := visitor.path
-
-	if len(visitor.rules) == 1 {
-		return visitor.rules[0], path, nil
+	if len(rules) == 1 {
+		return rules[0], path, nil
 	}
 
-	return mergeCompoundLengthRules(visitor.rules[0], visitor.rules[1]), path, nil
-}
-
-func containsLengthCall(expr hcl.Expression) bool {
-	switch e := expr.(type) {
-	case *hclsyntax.BinaryOpExpr:
-		return containsLengthCall(e.LHS) || containsLengthCall(e.RHS)
-	case *hclsyntax.FunctionCallExpr:
-		return e.Name == "length"
+	compound, err := mergeLengthRules(rules)
+	if err != nil {
+		return nil, nil, err
 	}
-	return false
+	return compound, path, nil
 }
 
-func mergeCompoundLengthRules(left, right *LengthRule) Rule {
+// mergeLengthRules combines length comparisons into the tightest bounds they
+// imply. length(x) != 0 raises the minimum to 1; other excluded lengths are
+// kept separately.
+func mergeLengthRules(rules []*LengthRule) (*CompoundLengthRule, error) {
 	compound := &CompoundLengthRule{}
 
-	// Determine which rule sets min vs max values
-	for _, rule := range []*LengthRule{left, right} {
+	for _, rule := range rules {
 		switch rule.Operator {
 		case hclsyntax.OpGreaterThan:
-			minVal := rule.Value + 1
-			compound.MinValue = &minVal
+			compound.raiseMin(rule.Value + 1)
 		case hclsyntax.OpGreaterThanOrEqual:
-			compound.MinValue = &rule.Value
+			compound.raiseMin(rule.Value)
 		case hclsyntax.OpLessThan:
-			maxVal := rule.Value - 1
-			compound.MaxValue = &maxVal
+			compound.lowerMax(rule.Value - 1)
 		case hclsyntax.OpLessThanOrEqual:
-			compound.MaxValue = &rule.Value
+			compound.lowerMax(rule.Value)
 		case hclsyntax.OpEqual:
-			compound.MinValue = &rule.Value
-			compound.MaxValue = &rule.Value
+			compound.raiseMin(rule.Value)
+			compound.lowerMax(rule.Value)
+		case hclsyntax.OpNotEqual:
+			if rule.Value == 0 {
+				compound.raiseMin(1)
+			} else {
+				compound.Excluded = append(compound.Excluded, rule.Value)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported operator for length validation: %v", rule.Operator)
 		}
 	}
 
-	return compound
+	return compound, nil
 }
 
-// CompoundLengthRule represents a combined length validation rule with both min and max constraints.
+// CompoundLengthRule represents a combined length validation rule with both
+// min and max constraints, and lengths the value must not have.
 type CompoundLengthRule struct {
 	MinValue *int
 	MaxValue *int
+	Excluded []int
 }
 
-// Apply applies the compound length validation rule to a JSON schema.
-func (r *CompoundLengthRule) Apply(schema *jsonschema.Schema) error {
-	baseType := getBaseType(schema.Type)
-	if r.MinValue != nil {
-		switch baseType {
-		case "string":
-			schema.MinLength = r.MinValue
-		case "array":
-			schema.MinItems = r.MinValue
-		case "object":
-			schema.MinProperties = r.MinValue
-		}
+func (r *CompoundLengthRule) raiseMin(value int) {
+	if r.MinValue == nil || value > *r.MinValue {
+		r.MinValue = &value
 	}
-	if r.MaxValue != nil {
-		switch baseType {
-		case "string":
-			schema.MaxLength = r.MaxValue
-		case "array":
-			schema.MaxItems = r.MaxValue
-		case "object":
-			schema.MaxProperties = r.MaxValue
-		}
+}
+
+func (r *CompoundLengthRule) lowerMax(value int) {
+	if r.MaxValue == nil || value < *r.MaxValue {
+		r.MaxValue = &value
+	}
+}
+
+// Constraint lowers the compound length validation rule into the constraint IR.
+func (r *CompoundLengthRule) Constraint() constraint.Constraint {
+	terms := []constraint.Constraint{atom(constraint.Length{Min: r.MinValue, Max: r.MaxValue})}
+	for _, excluded := range r.Excluded {
+		value := excluded
+		terms = append(terms, constraint.Not{Term: atom(constraint.Length{Min: &value, Max: &value})})
+	}
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	if r.MinValue == nil && r.MaxValue == nil {
+		terms = terms[1:]
 	}
-	return nil
+	return constraint.And{Terms: terms}
 }
@@ -3,6 +3,8 @@ package validation
 import (
 	"testing"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
@@ -10,37 +12,6 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestLengthRuleVisitorImplementsWalker(t *testing.T) {
-	var _ hclsyntax.Walker = (*lengthRuleVisitor)(nil)
-}
-
-func TestLengthRuleVisitorHandlesNodes(t *testing.T) {
-	visitor := &lengthRuleVisitor{}
-
-	// Test with a BinaryOpExpr
-	binOpNode := &hclsyntax.BinaryOpExpr{}
-	diags := visitor.Enter(binOpNode)
-	if diags.HasErrors() {
-		t.Errorf("Enter() with BinaryOpExpr returned unexpected error: %v", diags)
-	}
-
-	// Test with a FunctionCallExpr
-	funcCallNode := &hclsyntax.FunctionCallExpr{
-		Name: "length",
-	}
-	diags = visitor.Enter(funcCallNode)
-	if diags.HasErrors() {
-		t.Errorf("Enter() with FunctionCallExpr returned unexpected error: %v", diags)
-	}
-
-	// Test with a different node to ensure it's gracefully ignored
-	otherNode := &hclsyntax.LiteralValueExpr{}
-	diags = visitor.Enter(otherNode)
-	if diags.HasErrors() {
-		t.Errorf("Enter() with LiteralValueExpr returned unexpected error: %v", diags)
-	}
-}
-
 func TestParseLengthRuleWithVisitor(t *testing.T) {
 	parser := hclparse.NewParser()
 	file, diags := parser.ParseHCL([]byte(`
@@ -154,3 +125,58 @@ variable "my_list" {
 	assert.Equal(t, 5, *compoundRule.MaxValue)
 	assert.Empty(t, path)
 }
+
+func TestParseLengthRuleBounds(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	tests := []struct {
+		condition string
+		want      Rule
+		wantPath  Path
+	}{
+		{"0 < length(var.x)", &LengthRule{Operator: hclsyntax.OpGreaterThan, Value: 0}, nil},
+		{"length(var.x) != 0", &LengthRule{Operator: hclsyntax.OpNotEqual, Value: 0}, nil},
+		{
+			"3 <= length(var.x) && length(var.x) <= 10 && length(var.x) < 8",
+			&CompoundLengthRule{MinValue: intPtr(3), MaxValue: intPtr(7)},
+			nil,
+		},
+		{
+			"length(var.x) > 0 && length(var.x) != 4",
+			&CompoundLengthRule{MinValue: intPtr(1), Excluded: []int{4}},
+			nil,
+		},
+		{"length(keys(var.x.tags)) <= 5", &LengthRule{Operator: hclsyntax.OpLessThanOrEqual, Value: 5}, Path{constraint.Attr("tags")}},
+		{"length(var.x) > 0 && can(regex(\"^a\", var.x))", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors())
+
+			rule, path, err := parseLengthRule(expr, "x")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule)
+			assert.ElementsMatch(t, tt.wantPath, path)
+		})
+	}
+}
+
+func TestParseLengthRuleDifferentValues(t *testing.T) {
+	expr, diags := hclsyntax.ParseExpression([]byte("length(var.x.a) > 0 && length(var.x.b) > 0"), "test.tf", hcl.InitialPos)
+	require.False(t, diags.HasErrors())
+
+	_, _, err := parseLengthRule(expr, "x")
+	_, untranslatable := untranslatableReason(err)
+	assert.True(t, untranslatable)
+}
+
+func TestLengthRuleKeepsTighterBounds(t *testing.T) {
+	schema := &jsonschema.Schema{Type: "string"}
+	require.NoError(t, jsonschema.Constrain(schema, (&LengthRule{Operator: hclsyntax.OpGreaterThan, Value: 2}).Constraint()))
+	require.NoError(t, jsonschema.Constrain(schema, (&LengthRule{Operator: hclsyntax.OpLessThan, Value: 10}).Constraint()))
+	require.NoError(t, jsonschema.Constrain(schema, (&LengthRule{Operator: hclsyntax.OpGreaterThanOrEqual, Value: 1}).Constraint()))
+
+	assert.Equal(t, 3, *schema.MinLength)
+	assert.Equal(t, 9, *schema.MaxLength)
+}
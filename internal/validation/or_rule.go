@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func init() {
+	// Runs last, so that disjunctions other parsers understand as a whole,
+	// such as equalities forming an enum, are left to them.
+	RegisterRuleParserWithPriority(parseOrRule, -10)
+}
+
+// OrRule requires at least one of its alternatives to hold. The paths of the
+// alternatives are relative to the schema the rule applies to.
+type OrRule struct {
+	Alternatives []ScopedRule
+}
+
+// Constraint lowers the or validation rule into the constraint IR, or returns
+// nil if one of its alternatives cannot be lowered.
+func (r *OrRule) Constraint() constraint.Constraint {
+	or := constraint.Or{}
+	for _, alternative := range r.Alternatives {
+		c := lower(alternative)
+		if c == nil {
+			return nil
+		}
+		or.Terms = append(or.Terms, c)
+	}
+	return or
+}
+
+// parseOrRule handles conditions joined with ||, each of which another parser
+// recognises, such as can(regex("^[a-z]+$", var.x)) || var.x == "". The rule
+// is anchored at the nearest common ancestor of the paths of the alternatives.
+func parseOrRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	parts := disjuncts(expr)
+	if len(parts) < 2 {
+		return nil, nil, nil // Not a disjunction.
+	}
+
+	var alternatives []ScopedRule
+	var paths []Path
+	for _, part := range parts {
+		rule, path, matched, err := parseConditionOperand(part, varName)
+		if _, untranslatable := untranslatableReason(err); untranslatable {
+			return nil, nil, err
+		}
+		if err != nil || !matched {
+			// Parsers may reject what they do not understand, such as a
+			// comparison with null, with an error.
+			return nil, nil, nil
+		}
+		if rule == nil {
+			return nil, nil, nil // A literal true alternative always holds.
+		}
+		alternatives = append(alternatives, ScopedRule{Rule: rule, Path: path})
+		paths = append(paths, path)
+	}
+
+	common := constraint.CommonPrefix(paths...)
+	for i := range alternatives {
+		alternatives[i].Path = alternatives[i].Path[len(common):]
+	}
+	return &OrRule{Alternatives: alternatives}, common, nil
+}
+
+// disjuncts splits an expression on its top-level || operators.
+func disjuncts(expr hcl.Expression) []hcl.Expression {
+	binary, ok := unwrapParen(expr).(*hclsyntax.BinaryOpExpr)
+	if !ok || binary.Op != hclsyntax.OpLogicalOr {
+		return []hcl.Expression{expr}
+	}
+	return append(disjuncts(binary.LHS), disjuncts(binary.RHS)...)
+}
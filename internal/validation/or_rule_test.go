@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOrRule(t *testing.T) {
+	thirtySix := 36
+	tests := []struct {
+		condition string
+		want      constraint.Constraint
+		wantPath  Path
+	}{
+		{
+			`can(regex("^[a-z]+$", var.name)) || var.name == ""`,
+			constraint.Or{Terms: []constraint.Constraint{
+				atom(constraint.Pattern{Pattern: "^[a-z]+$"}),
+				atom(constraint.Enum{Values: []interface{}{""}}),
+			}},
+			Path{},
+		},
+		{
+			`var.name.id == "" || length(var.name.id) == 36`,
+			constraint.Or{Terms: []constraint.Constraint{
+				atom(constraint.Enum{Values: []interface{}{""}}),
+				atom(constraint.Length{Min: &thirtySix, Max: &thirtySix}),
+			}},
+			Path{constraint.Attr("id")},
+		},
+		{`var.name == null || length(var.name) > 2`, nil, nil},
+		{`length(var.name) > 2`, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			rule, path, err := parseOrRule(expr, "name")
+			require.NoError(t, err)
+			if tt.want == nil {
+				assert.Nil(t, rule)
+				return
+			}
+			require.NotNil(t, rule)
+			assert.Equal(t, tt.want, rule.Constraint())
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
@@ -1,24 +1,14 @@
 package validation
 
 import (
-	"fmt"
+	"math/big"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 )
 
-// PathExpression represents a traversal path within a Terraform variable.
-type PathExpression struct {
-	Segments []PathSegment
-}
-
-// PathSegment is a single part of a PathExpression.
-type PathSegment struct {
-	Name     string
-	IndexKey *hcl.TraverseIndex
-}
-
 // PathExpressionHandler handles extraction of property paths from complex HCL expressions
 type PathExpressionHandler struct{}
 
@@ -28,7 +18,7 @@ func NewPathExpressionHandler() *PathExpressionHandler {
 }
 
 // ExtractPathFromExpression extracts a property path from an expression, handling complex traversals
-func (p *PathExpressionHandler) ExtractPathFromExpression(expr hcl.Expression, varName string) ([]string, error) {
+func (p *PathExpressionHandler) ExtractPathFromExpression(expr hcl.Expression, varName string) (Path, error) {
 	var vars []hcl.Traversal
 	switch e := expr.(type) {
 	case *hclsyntax.FunctionCallExpr, *hclsyntax.BinaryOpExpr, *hclsyntax.ScopeTraversalExpr:
@@ -75,36 +65,20 @@ func (p *PathExpressionHandler) ExtractPathFromExpression(expr hcl.Expression, v
 	return nil, nil
 }
 
-// extractComplexPath extracts a path from traversers, handling both attributes and indices
-func (p *PathExpressionHandler) extractComplexPath(traversers []hcl.Traverser) []string {
-	var path []string
+// extractComplexPath converts traversers into a path. Numeric indexes address
+// a single element and string indexes a single map entry, so var.tags["env"]
+// keeps the key it refers to.
+func (p *PathExpressionHandler) extractComplexPath(traversers []hcl.Traverser) Path {
+	var path Path
 
 	for _, traverser := range traversers {
 		switch t := traverser.(type) {
 		case hcl.TraverseAttr:
 			// Regular attribute access: .property
-			path = append(path, t.Name)
+			path = append(path, constraint.Attr(t.Name))
 		case hcl.TraverseIndex:
 			// Array/map access: [index] or ["key"]
-			// For tuple validation, we need to capture the specific index
-
-			// Get the actual index value if it's a number
-			indexValue := ""
-			if t.Key.Type().Equals(cty.Number) {
-				if bigFloat := t.Key.AsBigFloat(); bigFloat != nil {
-					if intVal, accuracy := bigFloat.Int64(); accuracy == 0 {
-						indexValue = fmt.Sprintf("[%d]", intVal)
-					}
-				}
-			}
-
-			if indexValue == "" {
-				// Fallback for non-numeric indices (like string keys)
-				indexValue = "[*]"
-			}
-
-			// Add the index as a separate path segment
-			path = append(path, indexValue)
+			path = append(path, indexSegment(t.Key))
 		default:
 			// For any other traversal types, we'll skip them
 			// This handles cases like TraverseSplat (*) which we don't need for validation
@@ -115,25 +89,19 @@ func (p *PathExpressionHandler) extractComplexPath(traversers []hcl.Traverser) [
 	return path
 }
 
-// IsIndexedPath checks if a path contains array indexing
-func (p *PathExpressionHandler) IsIndexedPath(path []string) bool {
-	for _, segment := range path {
-		if len(segment) > 3 && segment[len(segment)-3:] == "[*]" {
-			return true
-		}
+// indexSegment returns the segment addressed by an index key: an element for
+// whole numbers, a map entry for strings, and every element otherwise.
+func indexSegment(key cty.Value) constraint.Segment {
+	if !key.IsKnown() || key.IsNull() {
+		return constraint.Wildcard()
 	}
-	return false
-}
-
-// GetBasePath removes indexing markers from a path
-func (p *PathExpressionHandler) GetBasePath(path []string) []string {
-	var basePath []string
-	for _, segment := range path {
-		if len(segment) > 3 && segment[len(segment)-3:] == "[*]" {
-			basePath = append(basePath, segment[:len(segment)-3])
-		} else {
-			basePath = append(basePath, segment)
+	switch key.Type() {
+	case cty.Number:
+		if index, accuracy := key.AsBigFloat().Int64(); accuracy == big.Exact && index >= 0 {
+			return constraint.Index(int(index))
 		}
+	case cty.String:
+		return constraint.Key(key.AsString())
 	}
-	return basePath
+	return constraint.Wildcard()
 }
@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"log"
 
-	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
@@ -23,27 +23,27 @@ type RangeRule struct {
 	Enum             []interface{} `json:"enum,omitempty"`
 }
 
-// Apply applies the range validation rule to a JSON schema.
-func (r *RangeRule) Apply(schema *jsonschema.Schema) error {
-	if r.Minimum != nil {
-		schema.Minimum = r.Minimum
-	}
-	if r.Maximum != nil {
-		schema.Maximum = r.Maximum
-	}
-	if r.ExclusiveMinimum != nil {
-		schema.ExclusiveMinimum = r.ExclusiveMinimum
-	}
-	if r.ExclusiveMaximum != nil {
-		schema.ExclusiveMaximum = r.ExclusiveMaximum
+// Constraint lowers the range validation rule into the constraint IR.
+func (r *RangeRule) Constraint() constraint.Constraint {
+	var terms []constraint.Constraint
+	if r.Minimum != nil || r.Maximum != nil || r.ExclusiveMinimum != nil || r.ExclusiveMaximum != nil {
+		terms = append(terms, atom(constraint.Range{
+			Minimum:          r.Minimum,
+			Maximum:          r.Maximum,
+			ExclusiveMinimum: r.ExclusiveMinimum,
+			ExclusiveMaximum: r.ExclusiveMaximum,
+		}))
 	}
 	if len(r.Enum) > 0 {
-		schema.Enum = r.Enum
+		terms = append(terms, atom(constraint.Enum{Values: r.Enum}))
+	}
+	if len(terms) == 1 {
+		return terms[0]
 	}
-	return nil
+	return constraint.And{Terms: terms}
 }
 
-func parseRangeRule(expr hcl.Expression, varName string) (Rule, []string, error) {
+func parseRangeRule(expr hcl.Expression, varName string) (Rule, Path, error) {
 	expr = unwrapParen(expr)
 	log.Printf("[range] called var=%s, expr=%T", varName, expr)
 	binaryExpr, ok := expr.(*hclsyntax.BinaryOpExpr)
@@ -52,230 +52,80 @@ func parseRangeRule(expr hcl.Expression, varName string) (Rule, []string, error)
 	}
 	log.Printf("[range] op=%v", binaryExpr.Op)
 
-	// Check if this is a range comparison
-	if !isRangeOperationForVar(binaryExpr, varName) {
-		log.Printf("[range] isRangeOperation=false for var=%s", varName)
-		return nil, nil, nil // Not a range operation.
-	}
+	var rule *RangeRule
+	var path Path
 
-	// Extract the path from any variable reference in the expression
-	path, err := extractPathFromRangeExpr(expr, varName)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	rule := &RangeRule{}
-	op := binaryExpr.Op
-
-	switch op {
+	switch binaryExpr.Op {
 	case hclsyntax.OpLogicalAnd:
-		// Handle compound expressions like: var.value >= 1 && var.value <= 10
-		leftRule, err := parseSingleComparison(binaryExpr.LHS, varName)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse left side: %w", err)
+		// Handle compound expressions like: var.value >= 1 && var.value <= 10.
+		// Conjunctions mixing in other checks, such as floor(var.value) ==
+		// var.value, are left to the and parser.
+		rule = &RangeRule{}
+		for i, operand := range conjuncts(expr) {
+			single, operandPath, err := parseSingleComparison(operand, varName)
+			if err != nil || single == nil || (i > 0 && !operandPath.Equal(path)) {
+				return nil, nil, nil
+			}
+			path = operandPath
+			rule = mergeRangeRules(rule, single)
 		}
-		rightRule, err := parseSingleComparison(binaryExpr.RHS, varName)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse right side: %w", err)
-		}
-
-		// Merge the rules
-		rule = mergeRangeRules(leftRule, rightRule)
 
 	case hclsyntax.OpLogicalOr:
-		// If either side of the OR contains a function call, this isn't a simple enum.
-		// Abort and let another parser (e.g., regex) handle it.
-		if containsFunctionCall(binaryExpr.LHS) || containsFunctionCall(binaryExpr.RHS) {
+		// Handle OR expressions like: var.value == 1 || var.value == 2.
+		// Anything else is left to the or parser.
+		enumValues, enumPath, ok := parseOrExpression(expr, varName)
+		if !ok {
 			return nil, nil, nil
 		}
+		rule, path = &RangeRule{Enum: enumValues}, enumPath
 
-		// Handle OR expressions like: var.value == 1 || var.value == 2
-		enumValues, err := parseOrExpression(binaryExpr, varName)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse OR expression: %w", err)
-		}
-		rule.Enum = enumValues
-
-	default:
-		// Handle single comparison
-		singleRule, err := parseSingleComparison(expr, varName)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to parse single comparison: %w", err)
-		}
-		rule = singleRule
-	}
-
-	log.Printf("[range] result rule=%#v path=%v", rule, path)
-	return rule, path, nil
-}
-
-func isRangeOperation(expr *hclsyntax.BinaryOpExpr) bool {
-	switch expr.Op {
-	case hclsyntax.OpLogicalAnd, hclsyntax.OpLogicalOr:
-		// For logical operations, check if it contains direct variable comparisons (not function calls)
-		return hasDirectVariableComparison(expr)
-	case hclsyntax.OpGreaterThan, hclsyntax.OpGreaterThanOrEqual,
-		hclsyntax.OpLessThan, hclsyntax.OpLessThanOrEqual,
-		hclsyntax.OpEqual:
-		// For comparison operations, check if it has direct variable references
-		return hasDirectVariableReference(expr)
-	}
-	return false
-}
-
-func isRangeOperationForVar(expr *hclsyntax.BinaryOpExpr, varName string) bool {
-	switch expr.Op {
-	case hclsyntax.OpLogicalAnd, hclsyntax.OpLogicalOr:
-		// For logical operations, check if it contains direct variable comparisons (not function calls)
-		return hasDirectVariableComparisonForVar(expr, varName)
 	case hclsyntax.OpGreaterThan, hclsyntax.OpGreaterThanOrEqual,
 		hclsyntax.OpLessThan, hclsyntax.OpLessThanOrEqual,
 		hclsyntax.OpEqual:
-		// For comparison operations, check if it has direct variable references
-		return hasDirectVariableReferenceForVar(expr, varName)
-	}
-	return false
-}
-
-func hasDirectVariableComparison(expr *hclsyntax.BinaryOpExpr) bool {
-	// Check if this is a compound expression with direct variable comparisons
-	// (not function calls like length())
-	return hasDirectVariableComparisonRecursive(expr.LHS) || hasDirectVariableComparisonRecursive(expr.RHS)
-}
-
-func hasDirectVariableComparisonForVar(expr *hclsyntax.BinaryOpExpr, varName string) bool {
-	// Check if this is a compound expression with direct variable comparisons
-	// (not function calls like length())
-	return hasDirectVariableComparisonRecursiveForVar(expr.LHS, varName) || hasDirectVariableComparisonRecursiveForVar(expr.RHS, varName)
-}
-
-func hasDirectVariableComparisonRecursive(expr hcl.Expression) bool {
-	if paren, ok := expr.(*hclsyntax.ParenthesesExpr); ok {
-		return hasDirectVariableComparisonRecursive(paren.Expression)
-	}
-	if binaryExpr, ok := expr.(*hclsyntax.BinaryOpExpr); ok {
-		switch binaryExpr.Op {
-		case hclsyntax.OpGreaterThan, hclsyntax.OpGreaterThanOrEqual,
-			hclsyntax.OpLessThan, hclsyntax.OpLessThanOrEqual,
-			hclsyntax.OpEqual:
-			// Check if this is a direct variable comparison (not a function call)
-			return (isVariableReference(binaryExpr.LHS) && !isFunctionCall(binaryExpr.LHS)) ||
-				(isVariableReference(binaryExpr.RHS) && !isFunctionCall(binaryExpr.RHS))
-		case hclsyntax.OpLogicalAnd, hclsyntax.OpLogicalOr:
-			// Recursively check nested logical operations
-			return hasDirectVariableComparisonRecursive(binaryExpr.LHS) ||
-				hasDirectVariableComparisonRecursive(binaryExpr.RHS)
+		single, singlePath, err := parseSingleComparison(expr, varName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse single comparison: %w", err)
 		}
-	}
-	return false
-}
-
-func hasDirectVariableComparisonRecursiveForVar(expr hcl.Expression, varName string) bool {
-	if paren, ok := expr.(*hclsyntax.ParenthesesExpr); ok {
-		return hasDirectVariableComparisonRecursiveForVar(paren.Expression, varName)
-	}
-	if binaryExpr, ok := expr.(*hclsyntax.BinaryOpExpr); ok {
-		switch binaryExpr.Op {
-		case hclsyntax.OpGreaterThan, hclsyntax.OpGreaterThanOrEqual,
-			hclsyntax.OpLessThan, hclsyntax.OpLessThanOrEqual,
-			hclsyntax.OpEqual:
-			// Check if this is a direct variable comparison (not a function call)
-			return (isVariableReferenceForVar(binaryExpr.LHS, varName) && !isFunctionCall(binaryExpr.LHS)) ||
-				(isVariableReferenceForVar(binaryExpr.RHS, varName) && !isFunctionCall(binaryExpr.RHS))
-		case hclsyntax.OpLogicalAnd, hclsyntax.OpLogicalOr:
-			// Recursively check nested logical operations
-			return hasDirectVariableComparisonRecursiveForVar(binaryExpr.LHS, varName) ||
-				hasDirectVariableComparisonRecursiveForVar(binaryExpr.RHS, varName)
+		if single == nil {
+			log.Printf("[range] no direct reference to var=%s", varName)
+			return nil, nil, nil // Not a range operation.
 		}
-	}
-	return false
-}
-
-func hasDirectVariableReference(expr *hclsyntax.BinaryOpExpr) bool {
-	// Check if either side has a direct variable reference (not wrapped in function calls)
-	return (isVariableReference(expr.LHS) && !isFunctionCall(expr.LHS)) ||
-		(isVariableReference(expr.RHS) && !isFunctionCall(expr.RHS))
-}
-
-func hasDirectVariableReferenceForVar(expr *hclsyntax.BinaryOpExpr, varName string) bool {
-	// Check if either side has a direct variable reference (not wrapped in function calls)
-	return (isVariableReferenceForVar(expr.LHS, varName) && !isFunctionCall(expr.LHS)) ||
-		(isVariableReferenceForVar(expr.RHS, varName) && !isFunctionCall(expr.RHS))
-}
-
-func isFunctionCall(expr hcl.Expression) bool {
-	_, ok := expr.(*hclsyntax.FunctionCallExpr)
-	return ok
-}
-
-func isVariableReference(expr hcl.Expression) bool {
-	// First unwrap any parentheses
-	if paren, ok := expr.(*hclsyntax.ParenthesesExpr); ok {
-		return isVariableReference(paren.Expression)
-	}
-
-	if traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr); ok {
-		rootName := traversal.Traversal.RootName()
-		return rootName == "var" || rootName == "self"
-	}
-	return false
-}
+		rule, path = single, singlePath
 
-func isVariableReferenceForVar(expr hcl.Expression, varName string) bool {
-	// First unwrap any parentheses
-	if paren, ok := expr.(*hclsyntax.ParenthesesExpr); ok {
-		return isVariableReferenceForVar(paren.Expression, varName)
-	}
-
-	if traversal, ok := expr.(*hclsyntax.ScopeTraversalExpr); ok {
-		rootName := traversal.Traversal.RootName()
-		// Accept both var.* references and direct loop variable references
-		return rootName == "var" || rootName == "self" || rootName == varName
-	}
-	return false
-}
-
-func extractPathFromRangeExpr(expr hcl.Expression, varName string) ([]string, error) {
-	// Find the first variable reference in the expression
-	switch e := expr.(type) {
-	case *hclsyntax.BinaryOpExpr:
-		if isVariableReferenceForVar(e.LHS, varName) {
-			return pathHandler.ExtractPathFromExpression(e.LHS, varName)
-		}
-		if isVariableReferenceForVar(e.RHS, varName) {
-			return pathHandler.ExtractPathFromExpression(e.RHS, varName)
-		}
-		// Try left side first, then right side for compound expressions
-		if path, err := extractPathFromRangeExpr(e.LHS, varName); err == nil && path != nil {
-			return path, nil
-		}
-		return extractPathFromRangeExpr(e.RHS, varName)
-	case *hclsyntax.ScopeTraversalExpr:
-		return pathHandler.ExtractPathFromExpression(e, varName)
+	default:
+		return nil, nil, nil
 	}
 
-	return nil, nil // No variable reference found
+	log.Printf("[range] result rule=%#v path=%v", rule, path)
+	return rule, path, nil
 }
 
-func parseSingleComparison(expr hcl.Expression, varName string) (*RangeRule, error) {
+// parseSingleComparison parses a comparison of the variable against a
+// literal, returning nil if neither side refers to the variable directly.
+func parseSingleComparison(expr hcl.Expression, varName string) (*RangeRule, Path, error) {
 	expr = unwrapParen(expr)
 	comparison, ok := expr.(*hclsyntax.BinaryOpExpr)
 	if !ok {
-		return nil, fmt.Errorf("not a comparison expression")
+		return nil, nil, nil
 	}
 
 	// Determine which side is the variable and which is the value
-	var valueExpr hcl.Expression
+	var subject, valueExpr hcl.Expression
 	var isReversed bool
 
-	if isVariableReferenceForVar(comparison.LHS, varName) {
-		valueExpr = comparison.RHS
+	if isReference(comparison.LHS, varName) {
+		subject, valueExpr = comparison.LHS, comparison.RHS
 		isReversed = false
-	} else if isVariableReferenceForVar(comparison.RHS, varName) {
-		valueExpr = comparison.LHS
+	} else if isReference(comparison.RHS, varName) {
+		subject, valueExpr = comparison.RHS, comparison.LHS
 		isReversed = true
 	} else {
-		return nil, fmt.Errorf("no variable reference found in comparison")
+		return nil, nil, nil
+	}
+
+	path, err := pathHandler.ExtractPathFromExpression(unwrapParen(subject), varName)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Extract value (try numeric first, then any literal for enum support)
@@ -286,7 +136,7 @@ func parseSingleComparison(expr hcl.Expression, varName string) (*RangeRule, err
 	} else if litVal, litErr := extractLiteralValue(valueExpr); litErr == nil {
 		value = litVal
 	} else {
-		return nil, fmt.Errorf("failed to extract value from expression")
+		return nil, nil, fmt.Errorf("failed to extract value from expression")
 	}
 
 	rule := &RangeRule{}
@@ -311,25 +161,25 @@ func parseSingleComparison(expr hcl.Expression, varName string) (*RangeRule, err
 		if numVal, ok := value.(float64); ok {
 			rule.ExclusiveMinimum = &numVal
 		} else {
-			return nil, fmt.Errorf("comparison operators require numeric values")
+			return nil, nil, fmt.Errorf("comparison operators require numeric values")
 		}
 	case hclsyntax.OpGreaterThanOrEqual:
 		if numVal, ok := value.(float64); ok {
 			rule.Minimum = &numVal
 		} else {
-			return nil, fmt.Errorf("comparison operators require numeric values")
+			return nil, nil, fmt.Errorf("comparison operators require numeric values")
 		}
 	case hclsyntax.OpLessThan:
 		if numVal, ok := value.(float64); ok {
 			rule.ExclusiveMaximum = &numVal
 		} else {
-			return nil, fmt.Errorf("comparison operators require numeric values")
+			return nil, nil, fmt.Errorf("comparison operators require numeric values")
 		}
 	case hclsyntax.OpLessThanOrEqual:
 		if numVal, ok := value.(float64); ok {
 			rule.Maximum = &numVal
 		} else {
-			return nil, fmt.Errorf("comparison operators require numeric values")
+			return nil, nil, fmt.Errorf("comparison operators require numeric values")
 		}
 	case hclsyntax.OpEqual:
 		// For numeric types, treat equality as a range with the same min and max
@@ -341,56 +191,48 @@ func parseSingleComparison(expr hcl.Expression, varName string) (*RangeRule, err
 			rule.Enum = []interface{}{value}
 		}
 	default:
-		return nil, fmt.Errorf("unsupported operator: %v", op)
+		return nil, nil, fmt.Errorf("unsupported operator: %v", op)
 	}
 
-	return rule, nil
+	return rule, path, nil
 }
 
-func parseOrExpression(expr *hclsyntax.BinaryOpExpr, varName string) ([]interface{}, error) {
+// parseOrExpression collects the values of a disjunction of equalities
+// between the same part of the variable and a literal, such as
+// var.value == 1 || var.value == 2.
+func parseOrExpression(expr hcl.Expression, varName string) ([]interface{}, Path, bool) {
 	var values []interface{}
+	var path Path
 
-	// Recursively collect all values from OR expressions
-	leftValues, err := collectOrValues(expr.LHS, varName)
-	if err != nil {
-		return nil, err
-	}
-	values = append(values, leftValues...)
-
-	rightValues, err := collectOrValues(expr.RHS, varName)
-	if err != nil {
-		return nil, err
-	}
-	values = append(values, rightValues...)
-
-	return values, nil
-}
-
-func collectOrValues(expr hcl.Expression, varName string) ([]interface{}, error) {
-	if binaryExpr, ok := expr.(*hclsyntax.BinaryOpExpr); ok && binaryExpr.Op == hclsyntax.OpLogicalOr {
-		// Recursively handle nested OR expressions
-		return parseOrExpression(binaryExpr, varName)
-	}
+	for i, operand := range disjuncts(expr) {
+		equality, ok := unwrapParen(operand).(*hclsyntax.BinaryOpExpr)
+		if !ok || equality.Op != hclsyntax.OpEqual {
+			return nil, nil, false
+		}
 
-	if binaryExpr, ok := expr.(*hclsyntax.BinaryOpExpr); ok && binaryExpr.Op == hclsyntax.OpEqual {
-		// Handle equality comparison
-		var valueExpr hcl.Expression
-		if isVariableReferenceForVar(binaryExpr.LHS, varName) {
-			valueExpr = binaryExpr.RHS
-		} else if isVariableReferenceForVar(binaryExpr.RHS, varName) {
-			valueExpr = binaryExpr.LHS
+		var subject, valueExpr hcl.Expression
+		if isReference(equality.LHS, varName) {
+			subject, valueExpr = equality.LHS, equality.RHS
+		} else if isReference(equality.RHS, varName) {
+			subject, valueExpr = equality.RHS, equality.LHS
 		} else {
-			return nil, fmt.Errorf("no variable reference found in equality comparison")
+			return nil, nil, false
+		}
+
+		operandPath, err := pathHandler.ExtractPathFromExpression(unwrapParen(subject), varName)
+		if err != nil || (i > 0 && !operandPath.Equal(path)) {
+			return nil, nil, false
 		}
+		path = operandPath
 
 		value, err := extractLiteralValue(valueExpr)
 		if err != nil {
-			return nil, err
+			return nil, nil, false
 		}
-		return []interface{}{value}, nil
+		values = append(values, value)
 	}
 
-	return nil, fmt.Errorf("expected equality or OR expression")
+	return values, path, true
 }
 
 func mergeRangeRules(left, right *RangeRule) *RangeRule {
@@ -469,24 +311,3 @@ func extractLiteralValue(expr hcl.Expression) (interface{}, error) {
 
 	return nil, fmt.Errorf("unsupported literal type: %v", val.Type())
 }
-
-func containsFunctionCall(expr hcl.Expression) bool {
-	if _, ok := expr.(*hclsyntax.FunctionCallExpr); ok {
-		return true
-	}
-	if binary, ok := expr.(*hclsyntax.BinaryOpExpr); ok {
-		return containsFunctionCall(binary.LHS) || containsFunctionCall(binary.RHS)
-	}
-	return false
-}
-
-// unwrapParen recursively removes any enclosing ParenthesesExpr
-func unwrapParen(expr hcl.Expression) hcl.Expression {
-	for {
-		if p, ok := expr.(*hclsyntax.ParenthesesExpr); ok {
-			expr = p.Expression
-			continue
-		}
-		return expr
-	}
-}
@@ -3,7 +3,7 @@ package validation
 import (
 	"fmt"
 
-	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
@@ -13,119 +13,23 @@ func init() {
 	RegisterRuleParserWithPriority(parseRegexRule, 10)
 }
 
-// findRegexCall recursively searches for a regex function call within complex expressions
-func findRegexCall(expr hcl.Expression) *hclsyntax.FunctionCallExpr {
-	switch e := expr.(type) {
-	case *hclsyntax.FunctionCallExpr:
-		if e.Name == "regex" {
-			return e
-		}
-		// Check inside can() functions
-		if e.Name == "can" && len(e.Args) == 1 {
-			return findRegexCall(e.Args[0])
-		}
-	case *hclsyntax.BinaryOpExpr:
-		// Search both sides of binary operations (like ||, &&)
-		if left := findRegexCall(e.LHS); left != nil {
-			return left
-		}
-		if right := findRegexCall(e.RHS); right != nil {
-			return right
-		}
-	}
-	return nil
-}
-
 // RegexRule represents a regex validation rule.
 type RegexRule struct {
 	Pattern string
+	RE2     string // the pattern as written, if Pattern is a translation of it
 }
 
-// Apply applies the regex validation rule to a JSON schema.
-func (r *RegexRule) Apply(schema *jsonschema.Schema) error {
-	schema.Pattern = r.Pattern
-	return nil
-}
-
-// regexRuleVisitor implements the hclsyntax.Walker interface to traverse
-// an expression tree and build a RegexRule.
-type regexRuleVisitor struct {
-	varName string
-	rule    *RegexRule
-	path    []string
-	err     error
-}
-
-func (v *regexRuleVisitor) Enter(node hclsyntax.Node) hcl.Diagnostics {
-	// Handle different node types during tree traversal
-	if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
-		return v.visitFunctionCallExpr(call)
-	}
-	return nil
-}
-
-func (v *regexRuleVisitor) Exit(node hclsyntax.Node) hcl.Diagnostics {
-	// No special processing needed on exit for regex rules
-	return nil
-}
-
-func (v *regexRuleVisitor) visitFunctionCallExpr(expr *hclsyntax.FunctionCallExpr) hcl.Diagnostics {
-	if expr.Name != "can" {
-		return nil
-	}
-
-	if len(expr.Args) != 1 {
-		v.err = fmt.Errorf("'can' function for regex should have one argument")
-		return nil
-	}
-
-	regexCall, ok := expr.Args[0].(*hclsyntax.FunctionCallExpr)
-	if !ok || regexCall.Name != "regex" {
-		return nil // Not a regex call inside can()
-	}
-
-	if len(regexCall.Args) != 2 {
-		v.err = fmt.Errorf("expected 'regex' function to have two arguments")
-		return nil
-	}
-
-	path, err := pathHandler.ExtractPathFromExpression(regexCall.Args[1], v.varName)
-	if err != nil {
-		v.err = err
-		return nil
-	}
-	if path == nil {
-		v.path = []string{}
-	} else {
-		v.path = path
-	}
-
-	var pattern string
-	if templateExpr, ok := regexCall.Args[0].(*hclsyntax.TemplateExpr); ok {
-		if len(templateExpr.Parts) == 1 {
-			if lit, ok := templateExpr.Parts[0].(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
-				pattern = lit.Val.AsString()
-			}
-		}
-	} else if lit, ok := regexCall.Args[0].(*hclsyntax.LiteralValueExpr); ok && lit.Val.Type() == cty.String {
-		pattern = lit.Val.AsString()
-	}
-
-	if pattern == "" {
-		v.err = fmt.Errorf("regex pattern must be a string literal")
-		return nil
-	}
-
-	v.rule = &RegexRule{
-		Pattern: pattern,
-	}
-	return nil
+// Constraint lowers the regex validation rule into the constraint IR.
+func (r *RegexRule) Constraint() constraint.Constraint {
+	return atom(constraint.Pattern{Pattern: r.Pattern, RE2: r.RE2})
 }
 
-func parseRegexRule(expr hcl.Expression, varName string) (Rule, []string, error) {
-	// Use the helper to find regex calls in complex expressions
-	regexCall := findRegexCall(expr)
-	if regexCall == nil {
+func parseRegexRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	// A regex call joined with other checks by && or || is not a pattern the
+	// whole value must match, and is left to the parsers that understand the
+	// other checks.
+	regexCall, _ := calledFunction(expr)
+	if regexCall == nil || regexCall.Name != "regex" {
 		return nil, nil, nil
 	}
 
@@ -138,7 +42,7 @@ func parseRegexRule(expr hcl.Expression, varName string) (Rule, []string, error)
 		return nil, nil, err
 	}
 	if path == nil {
-		path = []string{}
+		path = Path{}
 	}
 
 	var pattern string
@@ -153,11 +57,21 @@ func parseRegexRule(expr hcl.Expression, varName string) (Rule, []string, error)
 	}
 
 	if pattern == "" {
+		if _, isLiteral := stringLiteral(regexCall.Args[0]); !isLiteral {
+			// e.g. a pattern kept in a local value, which is not evaluated.
+			return nil, nil, &UntranslatableError{Reason: "the regex pattern is not a string literal"}
+		}
 		return nil, nil, fmt.Errorf("regex pattern must be a string literal")
 	}
 
+	translated, err := TranslateRegex(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	rule := &RegexRule{
-		Pattern: pattern,
+		Pattern: translated,
+		RE2:     re2Source(pattern, translated),
 	}
 
 	return rule, path, nil
@@ -10,30 +10,6 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestRegexRuleVisitorImplementsWalker(t *testing.T) {
-	var _ hclsyntax.Walker = (*regexRuleVisitor)(nil)
-}
-
-func TestRegexRuleVisitorHandlesNodes(t *testing.T) {
-	visitor := &regexRuleVisitor{}
-
-	// Test with a FunctionCallExpr
-	funcCallNode := &hclsyntax.FunctionCallExpr{
-		Name: "can",
-	}
-	diags := visitor.Enter(funcCallNode)
-	if diags.HasErrors() {
-		t.Errorf("Enter() with FunctionCallExpr returned unexpected error: %v", diags)
-	}
-
-	// Test with a different node to ensure it's gracefully ignored
-	otherNode := &hclsyntax.LiteralValueExpr{}
-	diags = visitor.Enter(otherNode)
-	if diags.HasErrors() {
-		t.Errorf("Enter() with LiteralValueExpr returned unexpected error: %v", diags)
-	}
-}
-
 func TestParseRegexRuleWithVisitor(t *testing.T) {
 	parser := hclparse.NewParser()
 	file, diags := parser.ParseHCL([]byte(`
@@ -87,5 +63,26 @@ variable "my_string" {
 	require.True(t, ok)
 
 	assert.Equal(t, "^[a-zA-Z0-9]*$", regexRule.Pattern)
-	assert.Equal(t, []string{}, path)
+	assert.Equal(t, Path{}, path)
+}
+
+func TestParseRegexRuleKeepsRE2Source(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      *RegexRule
+	}{
+		{`can(regex("^[a-z]+$", var.name))`, &RegexRule{Pattern: "^[a-z]+$"}},
+		{`can(regex("^\\x{263A}\\z", var.name))`, &RegexRule{Pattern: `^\u263A$`, RE2: `^\x{263A}\z`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(tt.condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			rule, _, err := parseRegexRule(expr, "name")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rule)
+		})
+	}
 }
@@ -0,0 +1,453 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RE2's \s matches ASCII whitespace only, written for use inside a character
+// class, and re2NonSpace its complement.
+const (
+	re2Space    = `\t\n\f\r `
+	re2NonSpace = `\x00-\x08\x0B\x0E-\x1F!-\u{10FFFF}`
+)
+
+// repetition matches a repetition count such as {2}, {2,} or {2,5}.
+var repetition = regexp.MustCompile(`^\{[0-9]+(,[0-9]*)?\}`)
+
+// posixClasses holds the ASCII ranges of the RE2 [[:name:]] classes, written
+// for use inside an ECMA-262 character class.
+var posixClasses = map[string]string{
+	"alnum":  `0-9A-Za-z`,
+	"alpha":  `A-Za-z`,
+	"ascii":  `\x00-\x7F`,
+	"blank":  `\t `,
+	"cntrl":  `\x00-\x1F\x7F`,
+	"digit":  `0-9`,
+	"graph":  `!-~`,
+	"lower":  `a-z`,
+	"print":  ` -~`,
+	"punct":  `!-/:-@[-` + "`" + `{-~`,
+	"space":  `\t\n\v\f\r `,
+	"upper":  `A-Z`,
+	"word":   `0-9A-Za-z_`,
+	"xdigit": `0-9A-Fa-f`,
+}
+
+// TranslateRegex rewrites a Terraform (RE2) regular expression into the
+// ECMA-262 dialect used by JSON Schema's pattern keyword. Constructs with the
+// same meaning in both dialects are copied verbatim; the others are rewritten:
+//
+//   - a leading (?i) flag expands letters to both cases, (?s) widens '.'
+//   - '.' becomes [^\n], as ECMA-262's also excludes \r, U+2028 and U+2029
+//   - \A and \z become ^ and $, \s becomes RE2's ASCII whitespace class
+//   - escaped punctuation is unescaped unless it has a special meaning, as
+//     Unicode-mode ECMA-262 rejects escapes such as \- outside a class
+//   - [[:alpha:]] and the other POSIX classes become explicit ranges
+//   - \pL and \p{Greek} become \p{L} and \p{Script=Greek}
+//   - \Q...\E quotes become escaped literals, \x{263A} becomes \u263A
+//   - (?P<name>...) becomes (?<name>...); (?<name>...) is copied
+//   - a '-' after a class escape such as [\d-z] is escaped, as it is a
+//     literal in RE2 and an error in Unicode-mode ECMA-262
+//
+// A pattern that is not valid RE2 or that uses a construct with no ECMA-262
+// equivalent, such as the multi-line flag, yields an *UntranslatableError.
+func TranslateRegex(pattern string) (string, error) {
+	if _, err := syntax.Parse(pattern, syntax.Perl); err != nil {
+		return "", &UntranslatableError{Reason: fmt.Sprintf("invalid RE2 pattern: %v", err)}
+	}
+
+	t := &regexTranslator{src: []rune(pattern)}
+	t.translate()
+	if len(t.issues) > 0 {
+		return "", &UntranslatableError{Reason: fmt.Sprintf("the pattern uses RE2 syntax with no ECMA-262 equivalent: %s", strings.Join(t.issues, ", "))}
+	}
+	return t.out.String(), nil
+}
+
+// re2Source returns the RE2 pattern to keep beside its ECMA-262 translation,
+// or an empty string if the translation left it unchanged.
+func re2Source(pattern, translated string) string {
+	if pattern == translated {
+		return ""
+	}
+	return pattern
+}
+
+type regexTranslator struct {
+	src             []rune
+	pos             int
+	out             strings.Builder
+	caseInsensitive bool
+	dotAll          bool
+	issues          []string
+}
+
+func (t *regexTranslator) issue(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	for _, existing := range t.issues {
+		if existing == msg {
+			return
+		}
+	}
+	t.issues = append(t.issues, msg)
+}
+
+func (t *regexTranslator) peek(offset int) rune {
+	if t.pos+offset < len(t.src) {
+		return t.src[t.pos+offset]
+	}
+	return 0
+}
+
+func (t *regexTranslator) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(t.src[t.pos:]), prefix)
+}
+
+func (t *regexTranslator) translate() {
+	t.leadingFlags()
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case c == '\\':
+			t.escape(false)
+		case c == '[':
+			t.class()
+		case c == '(' && t.hasPrefix("(?P<"):
+			// Named groups drop the P; the name is copied as is.
+			end := strings.IndexRune(string(t.src[t.pos:]), '>')
+			t.out.WriteString("(?<" + string(t.src[t.pos+4:t.pos+end+1]))
+			t.pos += end + 1
+		case c == '(' && t.hasPrefix("(?<"):
+			// A named group, valid RE2 since Go 1.22.
+			t.out.WriteString("(?<")
+			t.pos += 3
+		case c == '(' && t.peek(1) == '?' && t.peek(2) != ':':
+			t.issue("inline flags after the start of the pattern")
+			t.pos++
+		case c == '.' && t.dotAll:
+			t.out.WriteString(`[\s\S]`)
+			t.pos++
+		case c == '.':
+			t.out.WriteString(`[^\n]`)
+			t.pos++
+		case t.caseInsensitive && hasOtherCase(c):
+			t.out.WriteString("[" + string(c) + string(otherCase(c)) + "]")
+			t.pos++
+		case c == '{':
+			// Copy repetition counts, which must not be case-expanded. RE2
+			// reads any other brace as a literal, which Unicode mode rejects.
+			repeat := repetition.FindString(string(t.src[t.pos:]))
+			if repeat == "" {
+				t.out.WriteString(`\{`)
+				t.pos++
+				break
+			}
+			t.out.WriteString(repeat)
+			t.pos += len(repeat)
+		case c == '}' || c == ']':
+			t.out.WriteString(`\` + string(c))
+			t.pos++
+		default:
+			t.out.WriteRune(c)
+			t.pos++
+		}
+	}
+}
+
+// leadingFlags consumes a (?flags) group at the start of the pattern, which
+// applies to the whole expression.
+func (t *regexTranslator) leadingFlags() {
+	if !t.hasPrefix("(?") {
+		return
+	}
+	end := strings.IndexRune(string(t.src), ')')
+	if end < 0 {
+		return
+	}
+	flags := string(t.src[2:end])
+	if flags == "" || strings.Trim(flags, "imsU-") != "" {
+		return // A group such as (?:...) or (?P<name>...).
+	}
+	for _, f := range flags {
+		switch f {
+		case 'i':
+			t.caseInsensitive = true
+		case 's':
+			t.dotAll = true
+		case 'm':
+			t.issue("the multi-line flag (?m)")
+		case 'U':
+			t.issue("the ungreedy flag (?U)")
+		case '-':
+			t.issue("cleared flags")
+		}
+	}
+	t.pos = end + 1
+}
+
+// escape translates the escape sequence at the current position.
+func (t *regexTranslator) escape(inClass bool) {
+	next := t.peek(1)
+	switch next {
+	case 'A':
+		t.out.WriteByte('^')
+		t.pos += 2
+	case 'z':
+		t.out.WriteByte('$')
+		t.pos += 2
+	case 'C':
+		t.issue(`the single-byte escape \C`)
+		t.pos += 2
+	case 'Q':
+		t.pos += 2
+		end := strings.Index(string(t.src[t.pos:]), `\E`)
+		quoted := string(t.src[t.pos:])
+		if end >= 0 {
+			quoted = string(t.src[t.pos : t.pos+end])
+			t.pos += end + 2
+		} else {
+			t.pos = len(t.src)
+		}
+		for _, r := range quoted {
+			if t.caseInsensitive && hasOtherCase(r) && !inClass {
+				t.out.WriteString("[" + string(r) + string(otherCase(r)) + "]")
+				continue
+			}
+			t.out.WriteString(quoteRune(r, inClass))
+		}
+	case 'p', 'P':
+		t.unicodeClass(next)
+	case 'x':
+		t.hexEscape()
+	case 's':
+		// RE2's \s is ASCII-only; ECMA-262's also matches Unicode spaces.
+		if inClass {
+			t.out.WriteString(re2Space)
+		} else {
+			t.out.WriteString("[" + re2Space + "]")
+		}
+		t.pos += 2
+	case 'S':
+		if inClass {
+			t.out.WriteString(re2NonSpace)
+		} else {
+			t.out.WriteString("[^" + re2Space + "]")
+		}
+		t.pos += 2
+	case 'a':
+		t.out.WriteString(`\x07`)
+		t.pos += 2
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		t.octalEscape()
+	case 'd', 'D', 'w', 'W', 'b', 'B', 'f', 'n', 'r', 't', 'v':
+		t.out.WriteString(`\` + string(next))
+		t.pos += 2
+	default:
+		// An escaped punctuation character. Unicode-mode ECMA-262 rejects
+		// identity escapes of characters without a special meaning.
+		t.out.WriteString(quoteRune(next, inClass))
+		t.pos += 2
+	}
+}
+
+// octalEscape translates \0 and octal codes such as \101, which ECMA-262
+// does not support in Unicode mode.
+func (t *regexTranslator) octalEscape() {
+	t.pos++
+	end := t.pos
+	for end < len(t.src) && end < t.pos+3 && t.src[end] >= '0' && t.src[end] <= '7' {
+		end++
+	}
+	value, _ := strconv.ParseUint(string(t.src[t.pos:end]), 8, 32)
+	t.pos = end
+	t.writeCodePoint(value)
+}
+
+// unicodeClass translates \pL, \p{Name} and \p{^Name}.
+func (t *regexTranslator) unicodeClass(kind rune) {
+	t.pos += 2
+	var name string
+	if t.peek(0) == '{' {
+		end := strings.IndexRune(string(t.src[t.pos:]), '}')
+		name = string(t.src[t.pos+1 : t.pos+end])
+		t.pos += end + 1
+	} else {
+		name = string(t.peek(0))
+		t.pos++
+	}
+	if strings.HasPrefix(name, "^") {
+		name = name[1:]
+		if kind == 'p' {
+			kind = 'P'
+		} else {
+			kind = 'p'
+		}
+	}
+	if _, isScript := unicode.Scripts[name]; isScript && name != "Any" {
+		name = "Script=" + name
+	}
+	if name == "Any" {
+		// ECMA-262 has no \p{Any}; it is every code point.
+		if kind == 'p' {
+			t.out.WriteString(`[\s\S]`)
+		} else {
+			t.out.WriteString(`[^\s\S]`)
+		}
+		return
+	}
+	t.out.WriteString(`\` + string(kind) + "{" + name + "}")
+}
+
+// hexEscape translates \x41 and \x{41}.
+func (t *regexTranslator) hexEscape() {
+	t.pos += 2
+	var digits string
+	if t.peek(0) == '{' {
+		end := strings.IndexRune(string(t.src[t.pos:]), '}')
+		digits = string(t.src[t.pos+1 : t.pos+end])
+		t.pos += end + 1
+	} else {
+		digits = string(t.src[t.pos : t.pos+2])
+		t.pos += 2
+	}
+	value, _ := strconv.ParseUint(digits, 16, 32)
+	t.writeCodePoint(value)
+}
+
+// writeCodePoint writes a code point as a hexadecimal escape.
+func (t *regexTranslator) writeCodePoint(value uint64) {
+	switch {
+	case value <= 0xFF:
+		t.out.WriteString(fmt.Sprintf(`\x%02X`, value))
+	case value <= 0xFFFF:
+		t.out.WriteString(fmt.Sprintf(`\u%04X`, value))
+	default:
+		t.out.WriteString(fmt.Sprintf(`\u{%X}`, value))
+	}
+}
+
+// class translates a bracketed character class.
+func (t *regexTranslator) class() {
+	t.out.WriteByte('[')
+	t.pos++
+	if t.peek(0) == '^' {
+		t.out.WriteByte('^')
+		t.pos++
+	}
+	first := true
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case c == ']' && !first:
+			t.out.WriteByte(']')
+			t.pos++
+			return
+		case c == ']':
+			// A leading ] is a literal in RE2 but closes an empty class in ECMA-262.
+			t.out.WriteString(`\]`)
+			t.pos++
+		case c == '[' && t.peek(1) == ':':
+			t.posixClass()
+			t.literalDash()
+		case c == '\\':
+			classEscape := strings.ContainsRune("dDwWsSpP", t.peek(1))
+			t.escape(true)
+			if classEscape {
+				t.literalDash()
+			}
+		default:
+			lo := c
+			t.out.WriteRune(lo)
+			t.pos++
+			hi := lo
+			if t.peek(0) == '-' && t.peek(1) != ']' && t.peek(1) != 0 && t.peek(1) != '\\' && t.peek(1) != '[' {
+				hi = t.peek(1)
+				t.out.WriteRune('-')
+				t.out.WriteRune(hi)
+				t.pos += 2
+			}
+			if t.caseInsensitive {
+				t.otherCaseRange(lo, hi)
+			}
+		}
+		first = false
+	}
+}
+
+// literalDash escapes a '-' following a class escape or a POSIX class, which
+// RE2 reads as a literal but cannot start a range in ECMA-262.
+func (t *regexTranslator) literalDash() {
+	if t.peek(0) == '-' && t.peek(1) != ']' {
+		t.out.WriteString(`\-`)
+		t.pos++
+	}
+}
+
+// posixClass translates [:name:] inside a character class.
+func (t *regexTranslator) posixClass() {
+	end := strings.Index(string(t.src[t.pos:]), ":]")
+	name := string(t.src[t.pos+2 : t.pos+end])
+	t.pos += end + 2
+	if strings.HasPrefix(name, "^") {
+		t.issue("the negated POSIX class [:%s:]", name)
+		return
+	}
+	ranges, ok := posixClasses[name]
+	if !ok {
+		t.issue("the POSIX class [:%s:]", name)
+		return
+	}
+	if t.caseInsensitive && (name == "lower" || name == "upper") {
+		ranges = `A-Za-z`
+	}
+	t.out.WriteString(ranges)
+}
+
+// otherCaseRange adds the other case of the letters in lo-hi to a class.
+func (t *regexTranslator) otherCaseRange(lo, hi rune) {
+	switch {
+	case lo >= 'a' && hi <= 'z':
+		t.writeRange(unicode.ToUpper(lo), unicode.ToUpper(hi))
+	case lo >= 'A' && hi <= 'Z':
+		t.writeRange(unicode.ToLower(lo), unicode.ToLower(hi))
+	case lo == hi && hasOtherCase(lo):
+		t.out.WriteRune(otherCase(lo))
+	}
+}
+
+func (t *regexTranslator) writeRange(lo, hi rune) {
+	t.out.WriteRune(lo)
+	if hi != lo {
+		t.out.WriteRune('-')
+		t.out.WriteRune(hi)
+	}
+}
+
+func hasOtherCase(r rune) bool {
+	return unicode.IsLetter(r) && otherCase(r) != r
+}
+
+func otherCase(r rune) rune {
+	if unicode.IsUpper(r) {
+		return unicode.ToLower(r)
+	}
+	return unicode.ToUpper(r)
+}
+
+// quoteRune returns a rune as a literal, escaped only if it has a special
+// meaning in a pattern or, inClass, in a character class.
+func quoteRune(r rune, inClass bool) string {
+	special := `\^$.|?*+()[]{}`
+	if inClass {
+		special = `\]^-`
+	}
+	if strings.ContainsRune(special, r) {
+		return `\` + string(r)
+	}
+	return string(r)
+}
@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"encoding/json"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`^[a-z0-9-]{36}$`, `^[a-z0-9-]{36}$`},
+		{`^[0-9]{1,3}(\.[0-9]{1,3}){3}$`, `^[0-9]{1,3}(\.[0-9]{1,3}){3}$`},
+		{`\Aabc\z`, `^abc$`},
+		{`(?i)^ab[c-e]{2}$`, `^[aA][bB][c-eC-E]{2}$`},
+		{`(?s)^a.b$`, `^a[\s\S]b$`},
+		{`^[[:alpha:]_][[:alnum:]_]*$`, `^[A-Za-z_][0-9A-Za-z_]*$`},
+		{`^\pL+\p{Greek}\P{Lu}$`, `^\p{L}+\p{Script=Greek}\P{Lu}$`},
+		{`^\Qa.b\E$`, `^a\.b$`},
+		{`^\x{263A}\x41$`, `^\u263A\x41$`},
+		{`^(?P<year>\d{4})$`, `^(?<year>\d{4})$`},
+		{`[]a]`, `[\]a]`},
+		{`^[a-z]+\-[0-9]+$`, `^[a-z]+-[0-9]+$`},
+		{`^a\_b\/c\.d$`, `^a_b/c\.d$`},
+		{`^[\.\-\]\\\^_]$`, `^[.\-\]\\\^_]$`},
+		{`^\s+\S$`, `^[\t\n\f\r ]+[^\t\n\f\r ]$`},
+		{`^[\s,]$`, `^[\t\n\f\r ,]$`},
+		{`^\Q[a-b]\E$`, `^\[a-b\]$`},
+		{`^a{,2}}$`, `^a\{,2\}\}$`},
+		{`^\101\0$`, `^\x41\x00$`},
+		{`^a.b$`, `^a[^\n]b$`},
+		{`^[\d-z]$`, `^[\d\-z]$`},
+		{`^[[:digit:]-]$`, `^[0-9-]$`},
+		{`^(?<year>\d{4})$`, `^(?<year>\d{4})$`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := TranslateRegex(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTranslateRegexReportsIncompatibleSyntax(t *testing.T) {
+	for _, pattern := range []string{`(?m)^a$`, `^a(?i)b$`, `[[:^alpha:]]`, `a\C`, `(unclosed`} {
+		t.Run(pattern, func(t *testing.T) {
+			_, err := TranslateRegex(pattern)
+			var untranslatable *UntranslatableError
+			assert.ErrorAs(t, err, &untranslatable)
+		})
+	}
+}
+
+// TestTranslateRegexUnicodeMode compiles the translated patterns as
+// Unicode-mode JavaScript regular expressions, as ajv does, and checks that
+// they accept the same strings as the RE2 originals.
+func TestTranslateRegexUnicodeMode(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+
+	type testCase struct {
+		Pattern string   `json:"pattern"`
+		Inputs  []string `json:"inputs"`
+	}
+	tests := []struct {
+		re2    string
+		inputs []string
+	}{
+		{`^[a-z]+\-[0-9]+$`, []string{"ab-12", "ab12", "-1"}},
+		{`^a\_b\/c$`, []string{"a_b/c", "a_bc"}},
+		{`^[\w\.\-]+@[\w\-]+$`, []string{"a.b-c@d-e", "a b@c", "a@b.c"}},
+		{`^[\[\]\^\\]+$`, []string{"[]^\\", "a"}},
+		{`^\S+\s\S+$`, []string{"a b", "a\tb", "a\u00a0b", "a\u2003b", "ab"}},
+		{`^[^\s]+$`, []string{"ab", "a b", "a\u00a0b"}},
+		{`^[\S]+$`, []string{"ab", "a b", "a\u00a0b"}},
+		{`(?i)^ab[c-e]$`, []string{"ABD", "abf"}},
+		{`^[[:punct:]]+$`, []string{"[!~`", "a"}},
+		{`^\Q(a+b)\E{2}$`, []string{"(a+b)(a+b)", "(a+b)"}},
+		{`^a{,2}$`, []string{"a{,2}", "aa"}},
+		{`^\x{263A}\101\a$`, []string{"\u263aA\a", "A"}},
+		{`^a.b$`, []string{"axb", "a\rb", "a\u2028b", "a\u2029b", "a\nb"}},
+		{`^[\d-z]+$`, []string{"1-z", "y", "a"}},
+		{`^[\s-]+$`, []string{" -", "\t", "x"}},
+		{`^[\pL-]+$`, []string{"é-a", "1"}},
+		{`^(?<year>\d{4})-\d{2}$`, []string{"2024-01", "24-01"}},
+	}
+
+	var cases []testCase
+	for _, tt := range tests {
+		translated, err := TranslateRegex(tt.re2)
+		require.NoError(t, err, tt.re2)
+		cases = append(cases, testCase{Pattern: translated, Inputs: tt.inputs})
+	}
+	input, err := json.Marshal(cases)
+	require.NoError(t, err)
+
+	script := `
+const cases = JSON.parse(require("fs").readFileSync(0, "utf8"));
+console.log(JSON.stringify(cases.map(c => {
+  try {
+    const re = new RegExp(c.pattern, "u");
+    return c.inputs.map(s => re.test(s));
+  } catch (e) {
+    return e.message;
+  }
+})));`
+	cmd := exec.Command(node, "-e", script)
+	cmd.Stdin = strings.NewReader(string(input))
+	output, err := cmd.Output()
+	require.NoError(t, err)
+
+	var results []interface{}
+	require.NoError(t, json.Unmarshal(output, &results))
+	for i, tt := range tests {
+		matches, ok := results[i].([]interface{})
+		if !assert.True(t, ok, "%s: %s is not a valid Unicode-mode pattern: %v", tt.re2, cases[i].Pattern, results[i]) {
+			continue
+		}
+		re := regexp.MustCompile(tt.re2)
+		for j, s := range tt.inputs {
+			assert.Equal(t, re.MatchString(s), matches[j], "%s (%s) on %q", tt.re2, cases[i].Pattern, s)
+		}
+	}
+}
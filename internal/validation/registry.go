@@ -8,7 +8,7 @@ import (
 )
 
 // ParserFunc defines the signature for validation rule parsers.
-type ParserFunc func(expr hcl.Expression, varName string) (Rule, []string, error)
+type ParserFunc func(expr hcl.Expression, varName string) (Rule, Path, error)
 
 // prioritizedParser holds a parser function with its priority
 type prioritizedParser struct {
@@ -0,0 +1,137 @@
+package validation
+
+import (
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func init() {
+	// Must run before the enum parser, which reads any contains() call as an enum.
+	RegisterRuleParserWithPriority(parseRequiredRule, 5)
+}
+
+// RequiredRule marks properties of an object schema as required.
+type RequiredRule struct {
+	Properties []string
+}
+
+// Constraint lowers the required validation rule into the constraint IR.
+func (r *RequiredRule) Constraint() constraint.Constraint {
+	return atom(constraint.Required{Properties: r.Properties})
+}
+
+// parseRequiredRule recognises presence checks on object attributes and map
+// keys: contains(keys(var.m), "k"), var.obj.attr != null and
+// var.m["k"] != null, optionally joined with &&. All checks in a conjunction
+// must target the same object.
+func parseRequiredRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	var properties []string
+	var path Path
+	for i, operand := range conjuncts(expr) {
+		parent, property, ok := requiredProperty(operand, varName)
+		if !ok {
+			return nil, nil, nil
+		}
+		operandPath, err := pathHandler.ExtractPathFromExpression(parent, varName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i > 0 && !path.Equal(operandPath) {
+			return nil, nil, nil
+		}
+		path = operandPath
+		properties = append(properties, property)
+	}
+	return &RequiredRule{Properties: properties}, path, nil
+}
+
+// requiredProperty returns the object expression and the property name that a
+// presence check refers to.
+func requiredProperty(expr hcl.Expression, varName string) (hcl.Expression, string, bool) {
+	expr = unwrapParen(expr)
+
+	switch e := expr.(type) {
+	case *hclsyntax.FunctionCallExpr:
+		if e.Name != "contains" || len(e.Args) != 2 {
+			return nil, "", false
+		}
+		keysCall, ok := unwrapParen(e.Args[0]).(*hclsyntax.FunctionCallExpr)
+		if !ok || keysCall.Name != "keys" || len(keysCall.Args) != 1 {
+			return nil, "", false
+		}
+		key, ok := unwrapParen(e.Args[1]).(*hclsyntax.TemplateExpr)
+		if !ok || !key.IsStringLiteral() {
+			return nil, "", false
+		}
+		val, diags := key.Value(nil)
+		if diags.HasErrors() {
+			return nil, "", false
+		}
+		object := unwrapParen(keysCall.Args[0])
+		if _, ok := object.(*hclsyntax.ScopeTraversalExpr); !ok || !referencesVar(object, varName) {
+			return nil, "", false
+		}
+		return object, val.AsString(), true
+
+	case *hclsyntax.BinaryOpExpr:
+		if e.Op != hclsyntax.OpNotEqual {
+			return nil, "", false
+		}
+		operand := e.LHS
+		if isNullLiteral(e.LHS) {
+			operand = e.RHS
+		} else if !isNullLiteral(e.RHS) {
+			return nil, "", false
+		}
+		trav, ok := unwrapParen(operand).(*hclsyntax.ScopeTraversalExpr)
+		if !ok || !referencesVar(trav, varName) {
+			return nil, "", false
+		}
+		// The variable itself (var.x or a loop variable) is not a property.
+		minLen := 2
+		if trav.Traversal.RootName() == "var" {
+			minLen = 3
+		}
+		if len(trav.Traversal) < minLen {
+			return nil, "", false
+		}
+		var property string
+		switch last := trav.Traversal[len(trav.Traversal)-1].(type) {
+		case hcl.TraverseAttr:
+			property = last.Name
+		case hcl.TraverseIndex:
+			if !last.Key.Type().Equals(cty.String) {
+				return nil, "", false
+			}
+			property = last.Key.AsString()
+		default:
+			return nil, "", false
+		}
+		parent := &hclsyntax.ScopeTraversalExpr{
+			Traversal: trav.Traversal[:len(trav.Traversal)-1],
+			SrcRange:  trav.SrcRange,
+		}
+		return parent, property, true
+	}
+	return nil, "", false
+}
+
+// conjuncts splits an expression joined with && into its operands.
+func conjuncts(expr hcl.Expression) []hcl.Expression {
+	binary, ok := unwrapParen(expr).(*hclsyntax.BinaryOpExpr)
+	if !ok || binary.Op != hclsyntax.OpLogicalAnd {
+		return []hcl.Expression{expr}
+	}
+	return append(conjuncts(binary.LHS), conjuncts(binary.RHS)...)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,7 +1,30 @@
 package validation
 
+import (
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Path is the typed path from a variable to the value a rule applies to. It
+// is shared with the constraint IR, so lowering a rule keeps its path intact.
+type Path = constraint.Path
+
 // ScopedRule pairs a Rule with the path to the property it applies to.
 type ScopedRule struct {
 	Rule Rule
-	Path []string
+	Path Path
+	// Root marks rules that span several variables. They apply to the root
+	// schema and their paths start with a variable name.
+	Root bool
+	// Condition and ErrorMessage come from the validation block the rule was
+	// parsed from. They are not set on the parts of a ConditionalRule.
+	Condition    hcl.Expression
+	ErrorMessage string
+}
+
+// Constraint lowers the scoped rule into the constraint IR, with its path
+// prepended. It reports false if the rule cannot be lowered.
+func (s ScopedRule) Constraint() (constraint.Constraint, bool) {
+	c := lower(s)
+	return c, c != nil
 }
@@ -0,0 +1,161 @@
+package validation
+
+import (
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+func init() {
+	// Must run before the length parser, which would read length(regexall(...))
+	// as a length constraint on the string.
+	RegisterRuleParserWithPriority(parseStringCheckRule, 12)
+}
+
+// Patterns equivalent to the string normalisation checks. They only consider
+// ASCII letters, whereas Terraform's lower and upper change any Unicode
+// letter with a case mapping, so "Ä" passes noUppercasePattern although
+// Terraform rejects it. Unicode property escapes would close the gap, but not
+// every validator of the generated schema compiles patterns in Unicode mode.
+const (
+	noUppercasePattern = `^[^A-Z]*$`
+	noLowercasePattern = `^[^a-z]*$`
+	trimmedPattern     = `^(\S([\s\S]*\S)?)?$`
+	numericPattern     = `^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`
+)
+
+// normalisationPatterns maps the functions used in var.x == f(var.x) checks
+// to the pattern of the strings they leave unchanged.
+var normalisationPatterns = map[string]string{
+	"lower":     noUppercasePattern,
+	"upper":     noLowercasePattern,
+	"trimspace": trimmedPattern,
+}
+
+// NotPatternRule forbids strings matching a pattern.
+type NotPatternRule struct {
+	Pattern string
+	RE2     string // the pattern as written, if Pattern is a translation of it
+}
+
+// Constraint lowers the negated pattern validation rule into the constraint IR.
+func (r *NotPatternRule) Constraint() constraint.Constraint {
+	return constraint.Not{Term: atom(constraint.Pattern{Pattern: r.Pattern, RE2: r.RE2})}
+}
+
+// parseStringCheckRule recognises string checks written with functions other
+// than regex:
+//
+//	length(regexall(p, var.x)) > 0  -> pattern p (== 0 forbids it)
+//	var.x == lower(var.x)           -> no ASCII uppercase letters (likewise upper)
+//	var.x == trimspace(var.x)       -> no leading or trailing whitespace
+//	can(tonumber(var.x))            -> numeric string
+func parseStringCheckRule(expr hcl.Expression, varName string) (Rule, Path, error) {
+	expr = unwrapParen(expr)
+
+	if _, ok := expr.(*hclsyntax.FunctionCallExpr); ok {
+		call, inCan := calledFunction(expr)
+		if !inCan || call.Name != "tonumber" || len(call.Args) != 1 {
+			return nil, nil, nil
+		}
+		return stringCheck(&PatternRule{Pattern: numericPattern}, call.Args[0], varName)
+	}
+
+	binary, ok := expr.(*hclsyntax.BinaryOpExpr)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	if binary.Op == hclsyntax.OpEqual {
+		for _, pair := range [][2]hcl.Expression{{binary.LHS, binary.RHS}, {binary.RHS, binary.LHS}} {
+			call, ok := unwrapParen(pair[1]).(*hclsyntax.FunctionCallExpr)
+			if !ok || len(call.Args) != 1 {
+				continue
+			}
+			pattern, known := normalisationPatterns[call.Name]
+			subject := unwrapParen(pair[0])
+			if known && sameTraversal(subject, unwrapParen(call.Args[0])) {
+				return stringCheck(&PatternRule{Pattern: pattern}, subject, varName)
+			}
+		}
+	}
+
+	return parseRegexAllRule(binary, varName)
+}
+
+// parseRegexAllRule handles comparisons of length(regexall(p, var.x)) with a
+// number, which hold when the pattern matches (> 0) or never matches (== 0).
+func parseRegexAllRule(binary *hclsyntax.BinaryOpExpr, varName string) (Rule, Path, error) {
+	op, countExpr, limitExpr := binary.Op, binary.LHS, binary.RHS
+	if _, ok := lengthArgument(countExpr); !ok {
+		// Normalise 0 < length(...) to length(...) > 0.
+		op, countExpr, limitExpr = flipComparison(op), binary.RHS, binary.LHS
+	}
+	inner, ok := lengthArgument(countExpr)
+	if !ok || op == nil {
+		return nil, nil, nil
+	}
+	call, ok := inner.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != "regexall" || len(call.Args) != 2 {
+		return nil, nil, nil
+	}
+	limit, err := extractNumericValue(unwrapParen(limitExpr))
+	if err != nil {
+		return nil, nil, &UntranslatableError{Reason: "the regexall match count is not compared with a number literal"}
+	}
+	pattern, ok := stringLiteral(call.Args[0])
+	if !ok {
+		return nil, nil, &UntranslatableError{Reason: "the regex pattern is not a string literal"}
+	}
+	translated, err := TranslateRegex(pattern)
+	if err != nil {
+		return nil, nil, err
+	}
+	re2 := re2Source(pattern, translated)
+
+	matches := (op == hclsyntax.OpGreaterThan && limit == 0) ||
+		(op == hclsyntax.OpGreaterThanOrEqual && limit == 1) ||
+		(op == hclsyntax.OpNotEqual && limit == 0)
+	neverMatches := (op == hclsyntax.OpEqual && limit == 0) ||
+		(op == hclsyntax.OpLessThan && limit == 1) ||
+		(op == hclsyntax.OpLessThanOrEqual && limit == 0)
+	switch {
+	case matches:
+		return stringCheck(&PatternRule{Pattern: translated, RE2: re2}, call.Args[1], varName)
+	case neverMatches:
+		return stringCheck(&NotPatternRule{Pattern: translated, RE2: re2}, call.Args[1], varName)
+	}
+	return nil, nil, &UntranslatableError{Reason: "JSON Schema cannot count the matches of a pattern"}
+}
+
+// stringCheck scopes rule to the string subject refers to, if it refers to
+// the variable being validated.
+func stringCheck(rule Rule, subject hcl.Expression, varName string) (Rule, Path, error) {
+	subject = unwrapParen(subject)
+	if !isReference(subject, varName) {
+		return nil, nil, nil
+	}
+	path, err := pathHandler.ExtractPathFromExpression(subject, varName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rule, path, nil
+}
+
+// flipComparison returns the operator that gives the same result with its
+// operands swapped, or nil if op is not a comparison.
+func flipComparison(op *hclsyntax.Operation) *hclsyntax.Operation {
+	switch op {
+	case hclsyntax.OpGreaterThan:
+		return hclsyntax.OpLessThan
+	case hclsyntax.OpGreaterThanOrEqual:
+		return hclsyntax.OpLessThanOrEqual
+	case hclsyntax.OpLessThan:
+		return hclsyntax.OpGreaterThan
+	case hclsyntax.OpLessThanOrEqual:
+		return hclsyntax.OpGreaterThanOrEqual
+	case hclsyntax.OpEqual, hclsyntax.OpNotEqual:
+		return op
+	}
+	return nil
+}
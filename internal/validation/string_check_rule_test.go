@@ -0,0 +1,27 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStringCheckRuleUntranslatable(t *testing.T) {
+	for _, condition := range []string{
+		`length(regexall("[0-9]", var.name)) > local.minimum`,
+		`length(regexall("[0-9]", var.name)) > "many"`,
+		`length(regexall("[0-9]", var.name)) > 2`,
+	} {
+		t.Run(condition, func(t *testing.T) {
+			expr, diags := hclsyntax.ParseExpression([]byte(condition), "test.tf", hcl.InitialPos)
+			require.False(t, diags.HasErrors(), diags.Error())
+
+			_, _, err := parseStringCheckRule(expr, "name")
+			var untranslatable *UntranslatableError
+			assert.ErrorAs(t, err, &untranslatable)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package validation
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Untranslatable records a validation condition that could not be expressed
+// in JSON Schema. Terraform still enforces it, but a schema validator will not.
+type Untranslatable struct {
+	Variable     string
+	Condition    hcl.Expression
+	ErrorMessage string
+	Reason       string
+	Source       string // Original HCL of the condition, filled in by the converter
+}
+
+func (u Untranslatable) String() string {
+	condition := u.Source
+	if condition == "" && u.Condition != nil {
+		condition = u.Condition.Range().String()
+	}
+	return fmt.Sprintf("variable %q: condition %s was not translated: %s", u.Variable, condition, u.Reason)
+}
+
+// UntranslatableError is returned by rule parsers that recognise a condition
+// but know it cannot be represented in JSON Schema. Unlike other errors it does
+// not abort the conversion; the condition is reported as untranslatable instead.
+type UntranslatableError struct {
+	Reason string
+}
+
+func (e *UntranslatableError) Error() string {
+	return e.Reason
+}
@@ -1,8 +1,13 @@
 package validation
 
 import (
-	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // Global path expression handler for all validation rules
@@ -10,13 +15,41 @@ var pathHandler = NewPathExpressionHandler()
 
 // Rule is the interface that all validation rules must implement.
 type Rule interface {
-	// Apply applies the validation rule to a JSON schema.
-	Apply(schema *jsonschema.Schema) error
+	// Constraint lowers the rule into the constraint IR, from which every
+	// output format, JSON Schema included, is generated. The paths of the
+	// returned constraint are relative to the value the rule applies to. A
+	// nil constraint means the rule cannot be lowered.
+	Constraint() constraint.Constraint
+}
+
+// lower returns the constraint of a scoped rule with its path prepended, or
+// nil if the rule cannot be lowered.
+func lower(scoped ScopedRule) constraint.Constraint {
+	c := scoped.Rule.Constraint()
+	if c == nil {
+		return nil
+	}
+	return constraint.Prefix(c, scoped.Path)
+}
+
+// atom returns a constraint applying p to the value a rule applies to.
+func atom(p constraint.Predicate) constraint.Constraint {
+	return constraint.Atom{Predicate: p}
 }
 
+// unlowered is the reason given for a rule that cannot be lowered into the
+// constraint IR, such as one from an extension that returns no constraint.
+const unlowered = "the recognised rule cannot be lowered into a constraint"
+
 // ExtractValidationRules extracts the validation rules from a variable's blocks.
-func ExtractValidationRules(blocks hcl.Blocks, varName string) ([]ScopedRule, error) {
+// The names of all variables declared alongside it identify cross-variable
+// references, and references to the given constant local values are replaced
+// with their values. Conditions that cannot be translated, including those whose
+// rule cannot be lowered into the constraint IR, are returned separately
+// rather than failing the extraction.
+func ExtractValidationRules(blocks hcl.Blocks, varName string, declared []string, locals map[string]cty.Value) ([]ScopedRule, []Untranslatable, error) {
 	var scopedRules []ScopedRule
+	var untranslatable []Untranslatable
 
 	for _, block := range blocks {
 		if block.Type != "validation" {
@@ -38,21 +71,102 @@ func ExtractValidationRules(blocks hcl.Blocks, varName string) ([]ScopedRule, er
 			continue
 		}
 
+		skipped := Untranslatable{
+			Variable:     varName,
+			Condition:    condition.Expr,
+			ErrorMessage: extractErrorMessage(content.Attributes["error_message"]),
+		}
+
+		expr := foldConstants(condition.Expr, locals)
+
+		// Conditions referring to other variables (Terraform 1.9+) can only be
+		// expressed on the root schema.
+		if others := otherVariables(expr, varName, declared); len(others) > 0 {
+			rule, path, err := parseCrossVariableRule(expr, varName)
+			switch {
+			case err != nil:
+				reason, ok := untranslatableReason(err)
+				if !ok {
+					return nil, nil, err
+				}
+				skipped.Reason = reason
+				untranslatable = append(untranslatable, skipped)
+			case rule == nil:
+				skipped.Reason = fmt.Sprintf("references other variables (%s) in a form that cannot be expressed in JSON Schema", strings.Join(others, ", "))
+				untranslatable = append(untranslatable, skipped)
+			default:
+				scoped := ScopedRule{
+					Rule:         rule,
+					Path:         path,
+					Root:         true,
+					Condition:    condition.Expr,
+					ErrorMessage: skipped.ErrorMessage,
+				}
+				if _, ok := scoped.Constraint(); !ok {
+					skipped.Reason = unlowered
+					untranslatable = append(untranslatable, skipped)
+					break
+				}
+				scopedRules = append(scopedRules, scoped)
+			}
+			continue
+		}
+
 		// Try each registered parser in priority order
+		skipped.Reason = "no validation rule parser recognised the condition"
 		for _, parser := range GetParsers() {
-			rule, path, err := parser(condition.Expr, varName)
+			rule, path, err := parser(expr, varName)
 			if err != nil {
-				return nil, err
+				reason, ok := untranslatableReason(err)
+				if !ok {
+					return nil, nil, err
+				}
+				skipped.Reason = reason
+				break
 			}
 			if rule != nil {
-				scopedRules = append(scopedRules, ScopedRule{
-					Rule: rule,
-					Path: path,
-				})
+				scoped := ScopedRule{
+					Rule:         rule,
+					Path:         path,
+					Condition:    condition.Expr,
+					ErrorMessage: skipped.ErrorMessage,
+				}
+				if _, ok := scoped.Constraint(); !ok {
+					skipped.Reason = unlowered
+					break
+				}
+				scopedRules = append(scopedRules, scoped)
+				skipped.Reason = ""
 				break // Move to next validation block
 			}
 		}
+		if skipped.Reason != "" {
+			untranslatable = append(untranslatable, skipped)
+		}
 	}
 
-	return scopedRules, nil
+	return scopedRules, untranslatable, nil
+}
+
+// untranslatableReason reports whether err marks a condition as untranslatable
+// and, if so, why.
+func untranslatableReason(err error) (string, bool) {
+	var untranslatableErr *UntranslatableError
+	if !errors.As(err, &untranslatableErr) {
+		return "", false
+	}
+	return untranslatableErr.Reason, true
+}
+
+// extractErrorMessage returns the static text of an error_message attribute,
+// or an empty string if it is absent or interpolates values.
+func extractErrorMessage(attr *hcl.Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || val.IsNull() || !val.Type().Equals(cty.String) {
+		return ""
+	}
+	return val.AsString()
 }
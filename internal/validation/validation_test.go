@@ -0,0 +1,56 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unloweredRule stands for a rule from an extension that recognises a
+// condition but returns no constraint for it.
+type unloweredRule struct{}
+
+func (r *unloweredRule) Constraint() constraint.Constraint {
+	return nil
+}
+
+func TestExtractValidationRulesReportsUnloweredRule(t *testing.T) {
+	RegisterRuleParserWithPriority(func(expr hcl.Expression, varName string) (Rule, Path, error) {
+		if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "unlowered" {
+			return &unloweredRule{}, nil, nil
+		}
+		return nil, nil, nil
+	}, 100)
+
+	file, diags := hclparse.NewParser().ParseHCL([]byte(`
+variable "name" {
+  type = string
+  validation {
+    condition     = unlowered(var.name)
+    error_message = "The name is not valid."
+  }
+}
+`), "test.tf")
+	require.False(t, diags.HasErrors(), diags.Error())
+	content, diags := file.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+	})
+	require.False(t, diags.HasErrors(), diags.Error())
+	variable, diags := content.Blocks[0].Body.Content(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "type"}},
+		Blocks:     []hcl.BlockHeaderSchema{{Type: "validation"}},
+	})
+	require.False(t, diags.HasErrors(), diags.Error())
+
+	rules, untranslatable, err := ExtractValidationRules(variable.Blocks, "name", []string{"name"}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+	require.Len(t, untranslatable, 1)
+	assert.Equal(t, unlowered, untranslatable[0].Reason)
+	assert.Equal(t, "The name is not valid.", untranslatable[0].ErrorMessage)
+}
@@ -0,0 +1,236 @@
+package variables
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// toRE2 rewrites an ECMA-262 regular expression, as used by JSON Schema's
+// pattern keyword, into the RE2 dialect of Terraform's regex function.
+// Constructs with the same meaning in both dialects are copied verbatim; the
+// others are rewritten:
+//
+//   - \u263A and \u{1F600} become \x{263A} and \x{1F600}, joining surrogate
+//     pairs into one code point, and \cJ and \0 become \x{A} and \x00
+//   - (?<name>...) becomes (?P<name>...)
+//   - [\b] becomes [\x08], [^] becomes (?s:.) and [] a class matching nothing
+//   - identity escapes of letters such as \a, which RE2 gives a meaning, lose
+//     their backslash
+//
+// '.' is copied, although RE2's also matches \r, U+2028 and U+2029.
+// Lookarounds, backreferences and anything else RE2 does not accept yield an
+// error.
+func toRE2(pattern string) (string, error) {
+	t := &re2Translator{src: []rune(pattern)}
+	t.translate()
+	if len(t.issues) > 0 {
+		return "", fmt.Errorf("the pattern uses ECMA-262 syntax with no RE2 equivalent: %s", strings.Join(t.issues, ", "))
+	}
+	out := t.out.String()
+	if _, err := regexp.Compile(out); err != nil {
+		return "", fmt.Errorf("the pattern is not valid RE2: %w", err)
+	}
+	return out, nil
+}
+
+type re2Translator struct {
+	src     []rune
+	pos     int
+	out     strings.Builder
+	inClass bool
+	issues  []string
+}
+
+func (t *re2Translator) peek(offset int) rune {
+	if t.pos+offset < len(t.src) {
+		return t.src[t.pos+offset]
+	}
+	return 0
+}
+
+func (t *re2Translator) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(string(t.src[t.pos:]), prefix)
+}
+
+func (t *re2Translator) issue(issue string) {
+	for _, existing := range t.issues {
+		if existing == issue {
+			return
+		}
+	}
+	t.issues = append(t.issues, issue)
+}
+
+func (t *re2Translator) translate() {
+	for t.pos < len(t.src) {
+		c := t.src[t.pos]
+		switch {
+		case c == '\\':
+			t.escape()
+
+		case t.inClass:
+			if c == ']' {
+				t.inClass = false
+			} else if c == '[' {
+				// A literal in ECMA-262, but the start of [:alpha:] in RE2.
+				t.out.WriteString(`\`)
+			}
+			t.out.WriteRune(c)
+			t.pos++
+
+		case t.hasPrefix("[^]"):
+			t.out.WriteString(`(?s:.)`)
+			t.pos += 3
+
+		case t.hasPrefix("[]"):
+			t.out.WriteString(`[^\x00-\x{10FFFF}]`)
+			t.pos += 2
+
+		case c == '[':
+			t.inClass = true
+			t.out.WriteRune(c)
+			t.pos++
+			if t.peek(0) == '^' {
+				t.out.WriteRune('^')
+				t.pos++
+			}
+
+		case t.hasPrefix("(?=") || t.hasPrefix("(?!"):
+			t.issue("lookahead")
+			t.pos += 3
+
+		case t.hasPrefix("(?<=") || t.hasPrefix("(?<!"):
+			t.issue("lookbehind")
+			t.pos += 4
+
+		case t.hasPrefix("(?<"):
+			t.out.WriteString("(?P<")
+			t.pos += 3
+
+		default:
+			t.out.WriteRune(c)
+			t.pos++
+		}
+	}
+}
+
+// escape translates the escape sequence at the current position.
+func (t *re2Translator) escape() {
+	next := t.peek(1)
+	switch {
+	case next == 0:
+		t.issue("a trailing backslash")
+		t.pos++
+
+	case next == 'u':
+		r, n := t.unicodeEscape(t.pos)
+		if n == 0 {
+			// An identity escape outside Unicode mode.
+			t.out.WriteRune('u')
+			t.pos += 2
+			return
+		}
+		t.pos += n
+		if utf16.IsSurrogate(r) {
+			if low, m := t.unicodeEscape(t.pos); m > 0 {
+				if pair := utf16.DecodeRune(r, low); pair != unicode.ReplacementChar {
+					r = pair
+					t.pos += m
+				}
+			}
+		}
+		fmt.Fprintf(&t.out, `\x{%X}`, r)
+
+	case next == 'c' && isASCIILetter(t.peek(2)):
+		fmt.Fprintf(&t.out, `\x{%X}`, t.peek(2)%32)
+		t.pos += 3
+
+	case next == '0' && !isDigit(t.peek(2)):
+		t.out.WriteString(`\x00`)
+		t.pos += 2
+
+	case next >= '1' && next <= '9' && !t.inClass:
+		t.issue("backreferences")
+		t.pos += 2
+		for isDigit(t.peek(0)) {
+			t.pos++
+		}
+
+	case next == 'k' && t.peek(2) == '<':
+		t.issue("backreferences")
+		t.pos += 3
+		for t.pos < len(t.src) && t.src[t.pos] != '>' {
+			t.pos++
+		}
+		t.pos++
+
+	case next == 'b' && t.inClass:
+		t.out.WriteString(`\x08`)
+		t.pos += 2
+
+	case next == 'x' && isHexDigit(t.peek(2)) && isHexDigit(t.peek(3)):
+		t.out.WriteString(string(t.src[t.pos : t.pos+4]))
+		t.pos += 4
+
+	case strings.ContainsRune(`dDwWsSbBtnrfvpP`, next):
+		t.out.WriteRune('\\')
+		t.out.WriteRune(next)
+		t.pos += 2
+
+	case next < 0x80 && !isASCIILetter(next) && !isDigit(next):
+		// Escaped punctuation is a literal in both dialects.
+		t.out.WriteRune('\\')
+		t.out.WriteRune(next)
+		t.pos += 2
+
+	default:
+		// An identity escape, such as \a or \é, stands for the character.
+		t.out.WriteString(regexp.QuoteMeta(string(next)))
+		t.pos += 2
+	}
+}
+
+// unicodeEscape decodes a \uXXXX or \u{X...} escape at position i, returning
+// the code point and the length of the escape, or a length of 0 if there is
+// none.
+func (t *re2Translator) unicodeEscape(i int) (rune, int) {
+	rest := string(t.src[i:])
+	if !strings.HasPrefix(rest, `\u`) {
+		return 0, 0
+	}
+	if strings.HasPrefix(rest, `\u{`) {
+		end := strings.IndexRune(rest, '}')
+		if end < 0 {
+			return 0, 0
+		}
+		value, err := strconv.ParseUint(rest[3:end], 16, 32)
+		if err != nil {
+			return 0, 0
+		}
+		return rune(value), len([]rune(rest[:end+1]))
+	}
+	if len(rest) < 6 {
+		return 0, 0
+	}
+	value, err := strconv.ParseUint(rest[2:6], 16, 16)
+	if err != nil {
+		return 0, 0
+	}
+	return rune(value), 6
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isHexDigit(r rune) bool {
+	return isDigit(r) || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F'
+}
+
+func isASCIILetter(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+}
@@ -0,0 +1,48 @@
+package variables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToRE2(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{`^[a-z][a-z0-9-]*$`, `^[a-z][a-z0-9-]*$`},
+		{`^\u0041\d$`, `^\x{41}\d$`},
+		{`^\u{1F600}$`, `^\x{1F600}$`},
+		{`^\uD83D\uDE00$`, `^\x{1F600}$`},
+		{`^\cJ\0$`, `^\x{A}\x00$`},
+		{`^(?<year>\d{4})-\d{2}$`, `^(?P<year>\d{4})-\d{2}$`},
+		{`^[\b[]$`, `^[\x08\[]$`},
+		{`^[^]*$`, `^(?s:.)*$`},
+		{`^\a\/\.$`, `^a\/\.$`},
+		{`^\x41[\d-z]$`, `^\x41[\d-z]$`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := toRE2(tt.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestToRE2Untranslatable(t *testing.T) {
+	for _, pattern := range []string{
+		`^(?!x)[a-z]+$`,
+		`^(?<=a)b$`,
+		`^(a)\1$`,
+		`^(?<q>a)\k<q>$`,
+		`^a\`,
+	} {
+		t.Run(pattern, func(t *testing.T) {
+			_, err := toRE2(pattern)
+			assert.Error(t, err)
+		})
+	}
+}
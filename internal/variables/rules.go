@@ -0,0 +1,367 @@
+package variables
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Regexes of the string formats, which the converter turns back into formats.
+const (
+	ipv4Pattern = `^((25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)$`
+	ipv6Pattern = `^(([0-9a-fA-F]{1,4}:){7}[0-9a-fA-F]{1,4})$`
+)
+
+// rule is a validation block: its condition and the subject and predicate of
+// its error message.
+type rule struct {
+	condition string
+	subject   string
+	predicate string
+	// guarded is set when the condition is a null check, so that it is
+	// parenthesized inside another one.
+	guarded bool
+}
+
+func (r rule) message() string {
+	return r.subject + " must " + r.predicate + "."
+}
+
+// scope is a value that validation conditions refer to.
+type scope struct {
+	ref     string // HCL expression of the value
+	path    string // path of the value, with [*] for the elements of collections
+	subject string // subject of the error messages
+	loops   int    // number of enclosing for expressions
+	guard   string // condition under which the value is set, if it may not be
+}
+
+func (sc scope) attr(name string, optional bool) scope {
+	path := sc.path + "." + name
+	attr := scope{ref: sc.ref + "." + name, path: path, subject: subjectOf(path), loops: sc.loops}
+	if optional {
+		attr.guard = attr.ref + " != null"
+	}
+	return attr
+}
+
+// entry returns the scope of a map entry, which unless required is checked
+// only when the map has its key.
+func (sc scope) entry(key string, required bool) scope {
+	path := fmt.Sprintf("%s[%s]", sc.path, quote(key))
+	entry := scope{ref: fmt.Sprintf("%s[%s]", sc.ref, quote(key)), path: path, subject: subjectOf(path), loops: sc.loops}
+	if !strings.Contains(path, "[*]") {
+		entry.subject = fmt.Sprintf("The %s entry of %s", key, sc.path)
+	}
+	if !required {
+		entry.guard = fmt.Sprintf("contains(keys(%s), %s)", sc.ref, quote(key))
+	}
+	return entry
+}
+
+func (sc scope) index(i int) scope {
+	path := fmt.Sprintf("%s[%d]", sc.path, i)
+	return scope{ref: fmt.Sprintf("%s[%d]", sc.ref, i), path: path, subject: subjectOf(path), loops: sc.loops}
+}
+
+// loop returns the scope of the elements of a collection, named after kind,
+// and the for expression iterating over them with collection.
+func (sc scope) loop(kind, noun, collection string) (scope, string) {
+	name := kind
+	if sc.loops > 0 {
+		name += strconv.Itoa(sc.loops + 1)
+	}
+	elem := scope{ref: name, path: sc.path + "[*]", subject: "Each " + noun + " of " + sc.path, loops: sc.loops + 1}
+	return elem, fmt.Sprintf("for %s in %s", name, collection)
+}
+
+func subjectOf(path string) string {
+	if strings.Contains(path, "[*]") {
+		return "Each " + path
+	}
+	return "The " + path
+}
+
+// unit is what a length counts, in the singular and the plural.
+type unit struct {
+	one, many string
+}
+
+var (
+	characters = unit{"character", "characters"}
+	items      = unit{"item", "items"}
+	entries    = unit{"entry", "entries"}
+)
+
+func (u unit) count(n int) string {
+	if n == 1 {
+		return "1 " + u.one
+	}
+	return strconv.Itoa(n) + " " + u.many
+}
+
+// rules returns the validation blocks checking the keywords of a schema and
+// of its attributes and elements, with null checks around those of values
+// that may be null.
+func (g *generator) rules(schema *jsonschema.Schema, sc scope) []rule {
+	s, nullable := unwrap(schema)
+	g.skip(s, sc.path)
+	e := sc.ref
+	var rules []rule
+	add := func(condition, predicate string) {
+		rules = append(rules, rule{condition: condition, subject: sc.subject, predicate: predicate})
+	}
+
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i], _ = valueExpr(v)
+		}
+		add(fmt.Sprintf("contains([%s], %s)", strings.Join(values, ", "), e), "be one of "+joinList(values, "or"))
+	}
+
+	switch t := baseType(s); t {
+	case "string":
+		if condition, predicate, ok := lengthRule(e, s.MinLength, s.MaxLength, "be", characters, " long"); ok {
+			add(condition, predicate)
+		}
+		if s.Pattern != "" {
+			if pattern, err := toRE2(s.Pattern); err != nil {
+				g.skipped = append(g.skipped, Skipped{Path: sc.path, Keyword: "pattern", Reason: err.Error()})
+			} else {
+				add(fmt.Sprintf("can(regex(%s, %s))", quote(pattern), e), "match the pattern "+pattern)
+			}
+		}
+		switch s.Format {
+		case "":
+		case "date-time":
+			add(fmt.Sprintf(`can(timeadd(%s, "0s"))`, e), "be an RFC 3339 timestamp")
+		case "ipv4":
+			add(fmt.Sprintf("can(regex(%s, %s))", quote(ipv4Pattern), e), "be an IPv4 address")
+		case "ipv6":
+			add(fmt.Sprintf("can(regex(%s, %s))", quote(ipv6Pattern), e), "be an IPv6 address")
+		default:
+			g.skipped = append(g.skipped, Skipped{Path: sc.path, Keyword: "format"})
+		}
+
+	case "number", "integer":
+		if t == "integer" {
+			add(fmt.Sprintf("floor(%s) == %s", e, e), "be a whole number")
+		}
+		if condition, predicate, ok := rangeRule(e, s); ok {
+			add(condition, predicate)
+		}
+		if s.MultipleOf != nil {
+			add(fmt.Sprintf("%s %% %s == 0", e, render.Number(*s.MultipleOf)), "be a multiple of "+render.Number(*s.MultipleOf))
+		}
+
+	case "array":
+		if tuple := s.TupleItems(); tuple != nil {
+			for i, item := range tuple {
+				rules = append(rules, g.rules(item, sc.index(i))...)
+			}
+			break
+		}
+		if condition, predicate, ok := lengthRule(e, s.MinItems, s.MaxItems, "have", items, ""); ok {
+			add(condition, predicate)
+		}
+		elements := s.ElementSchema()
+		if elements != nil {
+			elem, loop := sc.loop("item", "item", e)
+			for _, r := range g.rules(elements, elem) {
+				r.condition, r.guarded = fmt.Sprintf("alltrue([%s : %s])", loop, r.condition), false
+				rules = append(rules, r)
+			}
+		}
+		if s.Contains != nil {
+			if r, ok := g.contains(s.Contains, elements, sc); ok {
+				rules = append(rules, r)
+			}
+		}
+
+	case "object":
+		values := s.MapValues()
+		if s.Required != nil && len(*s.Required) > 0 && (values != nil || len(s.Properties) == 0) {
+			checks := make([]string, len(*s.Required))
+			keys := make([]string, len(*s.Required))
+			for i, key := range *s.Required {
+				keys[i] = quote(key)
+				checks[i] = fmt.Sprintf("contains(keys(%s), %s)", e, keys[i])
+			}
+			predicate := "contain the key " + keys[0]
+			if len(keys) > 1 {
+				predicate = "contain the keys " + joinList(keys, "and")
+			}
+			add(strings.Join(checks, " && "), predicate)
+		}
+		if condition, predicate, ok := lengthRule(e, s.MinProperties, s.MaxProperties, "have", entries, ""); ok {
+			add(condition, predicate)
+		}
+		for _, name := range render.SortedKeys(s.Properties) {
+			prop := s.Properties[name]
+			child := sc.attr(name, !requires(s, name) && prop.Default == nil)
+			if values != nil {
+				child = sc.entry(name, requires(s, name))
+			}
+			rules = append(rules, g.rules(prop, child)...)
+		}
+		if s.PropertyNames != nil {
+			names := s.PropertyNames.Clone()
+			if names.Type == nil {
+				names.Type = "string"
+			}
+			key, loop := sc.loop("key", "key", "keys("+e+")")
+			for _, r := range g.rules(names, key) {
+				r.condition, r.guarded = fmt.Sprintf("alltrue([%s : %s])", loop, r.condition), false
+				rules = append(rules, r)
+			}
+		}
+		if values != nil {
+			value, loop := sc.loop("value", "value", "values("+e+")")
+			for _, r := range g.rules(values, value) {
+				r.condition, r.guarded = fmt.Sprintf("alltrue([%s : %s])", loop, r.condition), false
+				rules = append(rules, r)
+			}
+		}
+	}
+
+	var guards []string
+	if nullable && sc.guard != e+" != null" {
+		guards = append(guards, e+" != null")
+	}
+	if sc.guard != "" {
+		guards = append(guards, sc.guard)
+	}
+	for _, guard := range guards {
+		for i, r := range rules {
+			if r.guarded {
+				r.condition = "(" + r.condition + ")"
+			}
+			rules[i].condition = fmt.Sprintf("%s ? %s : true", guard, r.condition)
+			rules[i].guarded = true
+		}
+	}
+	return rules
+}
+
+// contains returns a check that at least one element of an array satisfies
+// the contains schema, which takes the type of the elements when it has
+// none.
+func (g *generator) contains(contains, elements *jsonschema.Schema, sc scope) (rule, bool) {
+	schema := contains.Clone()
+	if schema.Type == nil && len(schema.Enum) == 0 && elements != nil {
+		schema.Type = elements.Type
+	}
+	elem, loop := sc.loop("item", "item", sc.ref)
+	elem.subject = "At least one item of " + sc.path
+
+	var conditions, predicates []string
+	for _, r := range g.rules(schema, elem) {
+		if r.subject != elem.subject {
+			g.skipped = append(g.skipped, Skipped{Path: sc.path, Keyword: "contains"})
+			return rule{}, false
+		}
+		conditions = append(conditions, r.condition)
+		predicates = append(predicates, r.predicate)
+	}
+	if len(conditions) == 0 {
+		return rule{}, false
+	}
+	return rule{
+		condition: fmt.Sprintf("anytrue([%s : %s])", loop, strings.Join(conditions, " && ")),
+		subject:   elem.subject,
+		predicate: strings.Join(predicates, " and "),
+	}, true
+}
+
+// skip records the keywords of a schema that no validation block checks.
+func (g *generator) skip(s *jsonschema.Schema, path string) {
+	var keywords []string
+	if len(s.AllOf) > 0 {
+		keywords = append(keywords, "allOf")
+	}
+	if len(s.AnyOf) > 0 {
+		keywords = append(keywords, "anyOf")
+	}
+	if s.Not != nil {
+		keywords = append(keywords, "not")
+	}
+	if s.If != nil {
+		keywords = append(keywords, "if")
+	}
+	if len(s.Dependencies) > 0 {
+		keywords = append(keywords, "dependencies")
+	}
+	if len(s.DependentRequired) > 0 {
+		keywords = append(keywords, "dependentRequired")
+	}
+	if s.MinContains != nil {
+		keywords = append(keywords, "minContains")
+	}
+	for _, keyword := range keywords {
+		g.skipped = append(g.skipped, Skipped{Path: path, Keyword: keyword})
+	}
+}
+
+// lengthRule returns the check of a minimum and a maximum length, counted in
+// unit u. The predicate is "<verb> at least <n> <u><suffix>", and the like.
+func lengthRule(e string, min, max *int, verb string, u unit, suffix string) (string, string, bool) {
+	if min != nil && *min <= 0 {
+		min = nil
+	}
+	switch {
+	case min != nil && max != nil && *min == *max:
+		return fmt.Sprintf("length(%s) == %d", e, *min), fmt.Sprintf("%s exactly %s%s", verb, u.count(*min), suffix), true
+	case min != nil && max != nil:
+		return fmt.Sprintf("length(%s) >= %d && length(%s) <= %d", e, *min, e, *max),
+			fmt.Sprintf("%s between %d and %s%s", verb, *min, u.count(*max), suffix), true
+	case min != nil && *min == 1:
+		return fmt.Sprintf("length(%s) > 0", e), "not be empty", true
+	case min != nil:
+		return fmt.Sprintf("length(%s) >= %d", e, *min), fmt.Sprintf("%s at least %s%s", verb, u.count(*min), suffix), true
+	case max != nil:
+		return fmt.Sprintf("length(%s) <= %d", e, *max), fmt.Sprintf("%s at most %s%s", verb, u.count(*max), suffix), true
+	}
+	return "", "", false
+}
+
+// rangeRule returns the check of the bounds of a number.
+func rangeRule(e string, s *jsonschema.Schema) (string, string, bool) {
+	if s.Minimum != nil && s.Maximum != nil && s.ExclusiveMinimum == nil && s.ExclusiveMaximum == nil {
+		return fmt.Sprintf("%s >= %s && %s <= %s", e, render.Number(*s.Minimum), e, render.Number(*s.Maximum)),
+			fmt.Sprintf("be between %s and %s", render.Number(*s.Minimum), render.Number(*s.Maximum)), true
+	}
+	var conditions, bounds []string
+	bound := func(limit *float64, op, phrase string) {
+		if limit != nil {
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", e, op, render.Number(*limit)))
+			bounds = append(bounds, phrase+" "+render.Number(*limit))
+		}
+	}
+	bound(s.Minimum, ">=", "at least")
+	bound(s.ExclusiveMinimum, ">", "greater than")
+	bound(s.Maximum, "<=", "at most")
+	bound(s.ExclusiveMaximum, "<", "less than")
+	if len(conditions) == 0 {
+		return "", "", false
+	}
+	return strings.Join(conditions, " && "), "be " + strings.Join(bounds, " and "), true
+}
+
+// quote renders a string as an HCL string literal.
+func quote(s string) string {
+	return string(hclwrite.TokensForValue(cty.StringVal(s)).Bytes())
+}
+
+// joinList joins items as in "a, b or c".
+func joinList(items []string, conjunction string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " " + conjunction + " " + items[len(items)-1]
+}
@@ -0,0 +1,303 @@
+// Package variables generates Terraform variable blocks from a JSON Schema,
+// the reverse of the conversion done by the converter package: types become
+// type constraints and validation keywords become validation blocks.
+package variables
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/render"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// Skipped is a keyword that has no equivalent in a type constraint or a
+// validation block and was left out.
+type Skipped struct {
+	Path    string
+	Keyword string
+	Reason  string // why the keyword was left out, if it is not obvious
+}
+
+func (s Skipped) String() string {
+	if s.Reason != "" {
+		return fmt.Sprintf("%s: keyword %q was not translated: %s", s.Path, s.Keyword, s.Reason)
+	}
+	return fmt.Sprintf("%s: keyword %q was not translated", s.Path, s.Keyword)
+}
+
+// generator collects the keywords skipped while generating.
+type generator struct {
+	skipped []Skipped
+}
+
+// Generate returns a variables.tf declaring a variable for every property of
+// the root schema, sorted by name, together with the keywords that were not
+// translated. Properties the schema does not require get their default, or
+// a null one.
+func Generate(schema *jsonschema.Schema) ([]byte, []Skipped, error) {
+	if len(schema.Properties) == 0 {
+		return nil, nil, fmt.Errorf("the schema has no properties to declare as variables")
+	}
+	required := map[string]bool{}
+	if schema.Required != nil {
+		for _, name := range *schema.Required {
+			required[name] = true
+		}
+	}
+
+	g := &generator{}
+	g.skip(schema, "(root)")
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+	for i, name := range render.SortedKeys(schema.Properties) {
+		if !hclsyntax.ValidIdentifier(name) {
+			return nil, nil, fmt.Errorf("property %q is not a valid Terraform variable name", name)
+		}
+		if i > 0 {
+			body.AppendNewline()
+		}
+		block := body.AppendNewBlock("variable", []string{name})
+		if err := g.variable(block.Body(), name, schema.Properties[name], required[name]); err != nil {
+			return nil, nil, fmt.Errorf("variable %q: %w", name, err)
+		}
+	}
+	return hclwrite.Format(f.Bytes()), g.skipped, nil
+}
+
+// variable fills in the body of the variable block of a root property.
+func (g *generator) variable(body *hclwrite.Body, name string, prop *jsonschema.Schema, required bool) error {
+	s, nullable := unwrap(prop)
+	typ, err := g.typeExpr(s, name)
+	if err != nil {
+		return err
+	}
+	if err := setRaw(body, "type", typ); err != nil {
+		return err
+	}
+	if s.Description != "" {
+		body.SetAttributeValue("description", cty.StringVal(s.Description))
+	}
+	if !required {
+		value, err := valueExpr(s.Default)
+		if err != nil {
+			return err
+		}
+		if err := setRaw(body, "default", value); err != nil {
+			return err
+		}
+	}
+	if s.Sensitive != nil && *s.Sensitive {
+		body.SetAttributeValue("sensitive", cty.True)
+	}
+	if nullable {
+		body.SetAttributeValue("nullable", cty.True)
+	}
+
+	root := scope{ref: "var." + name, path: name, subject: "The " + name}
+	if !required && s.Default == nil {
+		root.guard = root.ref + " != null"
+	}
+	for _, r := range g.rules(prop, root) {
+		body.AppendNewline()
+		block := body.AppendNewBlock("validation", nil).Body()
+		if err := setRaw(block, "condition", r.condition); err != nil {
+			return err
+		}
+		block.SetAttributeValue("error_message", cty.StringVal(r.message()))
+	}
+	return nil
+}
+
+// typeExpr returns the type constraint of a schema. Arrays of unique items
+// are sets, and attributes an object does not require are optional, with
+// their default if they have one.
+func (g *generator) typeExpr(schema *jsonschema.Schema, path string) (string, error) {
+	s, _ := unwrap(schema)
+	switch baseType(s) {
+	case "string":
+		return "string", nil
+	case "number", "integer":
+		return "number", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if tuple := s.TupleItems(); tuple != nil {
+			elems := make([]string, len(tuple))
+			for i, item := range tuple {
+				elem, err := g.typeExpr(item, fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return "", err
+				}
+				elems[i] = elem
+			}
+			return "tuple([" + strings.Join(elems, ", ") + "])", nil
+		}
+		elem := "any"
+		if items := s.ElementSchema(); items != nil {
+			var err error
+			if elem, err = g.typeExpr(items, path+"[*]"); err != nil {
+				return "", err
+			}
+		}
+		if s.UniqueItems != nil && *s.UniqueItems {
+			return "set(" + elem + ")", nil
+		}
+		return "list(" + elem + ")", nil
+	case "object":
+		if values := s.MapValues(); values != nil {
+			elem, err := g.typeExpr(values, path+"[*]")
+			if err != nil {
+				return "", err
+			}
+			return "map(" + elem + ")", nil
+		}
+		if len(s.Properties) > 0 {
+			return g.objectTypeExpr(s, path)
+		}
+	}
+	return "any", nil
+}
+
+func (g *generator) objectTypeExpr(s *jsonschema.Schema, path string) (string, error) {
+	var b strings.Builder
+	b.WriteString("object({\n")
+	for _, name := range render.SortedKeys(s.Properties) {
+		if !hclsyntax.ValidIdentifier(name) {
+			return "", fmt.Errorf("attribute %q of %s is not a valid Terraform attribute name", name, path)
+		}
+		prop := s.Properties[name]
+		attr, err := g.typeExpr(prop, path+"."+name)
+		if err != nil {
+			return "", err
+		}
+		if !requires(s, name) {
+			if prop.Default != nil {
+				value, err := valueExpr(prop.Default)
+				if err != nil {
+					return "", err
+				}
+				attr = "optional(" + attr + ", " + value + ")"
+			} else {
+				attr = "optional(" + attr + ")"
+			}
+		}
+		fmt.Fprintf(&b, "%s = %s\n", name, attr)
+	}
+	b.WriteString("})")
+	return b.String(), nil
+}
+
+// unwrap returns the non-null branch of a nullable schema, keeping the
+// keywords set beside the anyOf, and whether the schema accepts null.
+func unwrap(schema *jsonschema.Schema) (*jsonschema.Schema, bool) {
+	nullable := schema.AcceptsNull() || schema.Nullable != nil && *schema.Nullable
+	if len(schema.AnyOf) != 2 || !nullable {
+		return schema, nullable
+	}
+	var branch *jsonschema.Schema
+	for i := range schema.AnyOf {
+		if t, _ := schema.AnyOf[i].Type.(string); t != "null" {
+			branch = &schema.AnyOf[i]
+		}
+	}
+	if branch == nil {
+		return schema, nullable
+	}
+	// The keywords of the wrapper apply on top of those of the branch.
+	keywords := map[string]interface{}{}
+	for _, part := range []interface{}{branch, schema} {
+		raw, err := json.Marshal(part)
+		if err != nil {
+			return schema, nullable
+		}
+		if err := json.Unmarshal(raw, &keywords); err != nil {
+			return schema, nullable
+		}
+		delete(keywords, "anyOf")
+	}
+	keywords["type"] = branch.Type
+	raw, err := json.Marshal(keywords)
+	if err != nil {
+		return schema, nullable
+	}
+	var merged jsonschema.Schema
+	if err := json.Unmarshal(raw, &merged); err != nil {
+		return schema, nullable
+	}
+	return &merged, nullable
+}
+
+// baseType returns the type of a schema, inferring it from the enum values
+// when the schema has no type.
+func baseType(s *jsonschema.Schema) string {
+	if t := s.BaseType(); t != "" || len(s.Enum) == 0 {
+		return t
+	}
+	inferred := ""
+	for _, v := range s.Enum {
+		t := ""
+		switch v.(type) {
+		case string:
+			t = "string"
+		case float64:
+			t = "number"
+		case bool:
+			t = "boolean"
+		}
+		if t == "" || inferred != "" && t != inferred {
+			return ""
+		}
+		inferred = t
+	}
+	return inferred
+}
+
+// requires reports whether an object schema lists an attribute as required.
+func requires(s *jsonschema.Schema, name string) bool {
+	if s.Required == nil {
+		return false
+	}
+	for _, required := range *s.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+// valueExpr renders a JSON value as an HCL expression.
+func valueExpr(v interface{}) (string, error) {
+	if v == nil {
+		return "null", nil
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	ty, err := ctyjson.ImpliedType(encoded)
+	if err != nil {
+		return "", err
+	}
+	value, err := ctyjson.Unmarshal(encoded, ty)
+	if err != nil {
+		return "", err
+	}
+	return string(hclwrite.TokensForValue(value).Bytes()), nil
+}
+
+// setRaw sets an attribute to the HCL expression src.
+func setRaw(body *hclwrite.Body, name, src string) error {
+	f, diags := hclwrite.ParseConfig([]byte("x = "+src+"\n"), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return fmt.Errorf("invalid %s expression %s: %s", name, src, diags.Error())
+	}
+	body.SetAttributeRaw(name, f.Body().GetAttribute("x").Expr().BuildTokens(nil))
+	return nil
+}
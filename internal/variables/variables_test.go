@@ -0,0 +1,245 @@
+package variables
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "type": "object",
+  "properties": {
+    "name": {"type": "string", "description": "Resource name", "minLength": 3, "maxLength": 32, "pattern": "^[a-z][a-z0-9-]*$"},
+    "environment": {"type": "string", "enum": ["dev", "prod"], "default": "dev"},
+    "replicas": {"type": "integer", "minimum": 1, "multipleOf": 2, "default": 2},
+    "zones": {"type": "array", "items": {"type": "string"}, "uniqueItems": true, "minItems": 1},
+    "endpoint": {"anyOf": [{"type": "null"}, {"type": "string", "format": "date-time"}]},
+    "token": {"type": "string", "sensitive": true},
+    "labels": {"type": "object", "additionalProperties": {"type": "string", "maxLength": 63}, "required": ["owner"], "propertyNames": {"pattern": "^[a-z_]+$"}},
+    "network": {
+      "type": "object",
+      "properties": {
+        "cidr": {"type": "string"},
+        "ports": {"type": "array", "items": {"type": "number", "exclusiveMinimum": 0, "maximum": 65535}},
+        "tier": {"type": "string", "default": "standard"},
+        "gateway": {"type": "string", "format": "ipv4"}
+      },
+      "required": ["cidr", "ports"]
+    },
+    "pair": {"type": "array", "items": [{"type": "string"}, {"type": "number", "minimum": 0}]},
+    "odd": {"type": "number", "not": {"multipleOf": 2}}
+  },
+  "required": ["name", "zones", "token", "labels", "network", "pair", "odd"]
+}
+`), &schema))
+
+	source, skipped, err := Generate(&schema)
+	require.NoError(t, err)
+	assert.Equal(t, `variable "endpoint" {
+  type     = string
+  default  = null
+  nullable = true
+
+  validation {
+    condition     = var.endpoint != null ? can(timeadd(var.endpoint, "0s")) : true
+    error_message = "The endpoint must be an RFC 3339 timestamp."
+  }
+}
+
+variable "environment" {
+  type    = string
+  default = "dev"
+
+  validation {
+    condition     = contains(["dev", "prod"], var.environment)
+    error_message = "The environment must be one of \"dev\" or \"prod\"."
+  }
+}
+
+variable "labels" {
+  type = map(string)
+
+  validation {
+    condition     = contains(keys(var.labels), "owner")
+    error_message = "The labels must contain the key \"owner\"."
+  }
+
+  validation {
+    condition     = alltrue([for key in keys(var.labels) : can(regex("^[a-z_]+$", key))])
+    error_message = "Each key of labels must match the pattern ^[a-z_]+$."
+  }
+
+  validation {
+    condition     = alltrue([for value in values(var.labels) : length(value) <= 63])
+    error_message = "Each value of labels must be at most 63 characters long."
+  }
+}
+
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = length(var.name) >= 3 && length(var.name) <= 32
+    error_message = "The name must be between 3 and 32 characters long."
+  }
+
+  validation {
+    condition     = can(regex("^[a-z][a-z0-9-]*$", var.name))
+    error_message = "The name must match the pattern ^[a-z][a-z0-9-]*$."
+  }
+}
+
+variable "network" {
+  type = object({
+    cidr    = string
+    gateway = optional(string)
+    ports   = list(number)
+    tier    = optional(string, "standard")
+  })
+
+  validation {
+    condition     = var.network.gateway != null ? can(regex("^((25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\\.){3}(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)$", var.network.gateway)) : true
+    error_message = "The network.gateway must be an IPv4 address."
+  }
+
+  validation {
+    condition     = alltrue([for item in var.network.ports : item > 0 && item <= 65535])
+    error_message = "Each item of network.ports must be greater than 0 and at most 65535."
+  }
+}
+
+variable "odd" {
+  type = number
+}
+
+variable "pair" {
+  type = tuple([string, number])
+
+  validation {
+    condition     = var.pair[1] >= 0
+    error_message = "The pair[1] must be at least 0."
+  }
+}
+
+variable "replicas" {
+  type    = number
+  default = 2
+
+  validation {
+    condition     = floor(var.replicas) == var.replicas
+    error_message = "The replicas must be a whole number."
+  }
+
+  validation {
+    condition     = var.replicas >= 1
+    error_message = "The replicas must be at least 1."
+  }
+
+  validation {
+    condition     = var.replicas % 2 == 0
+    error_message = "The replicas must be a multiple of 2."
+  }
+}
+
+variable "token" {
+  type      = string
+  sensitive = true
+}
+
+variable "zones" {
+  type = set(string)
+
+  validation {
+    condition     = length(var.zones) > 0
+    error_message = "The zones must not be empty."
+  }
+}
+`, string(source))
+	assert.Equal(t, []Skipped{{Path: "odd", Keyword: "not"}}, skipped)
+
+	c := converter.New()
+	_, err = c.ConvertString(string(source))
+	require.NoError(t, err)
+	// Only the null check of the optional timestamp is not translated back.
+	require.Len(t, c.Untranslatable(), 1)
+	assert.Equal(t, "endpoint", c.Untranslatable()[0].Variable)
+}
+
+func TestGenerateNullable(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "type": "object",
+  "properties": {
+    "settings": {
+      "type": "object",
+      "properties": {
+        "tls": {
+          "anyOf": [
+            {"type": "null"},
+            {"type": "object", "properties": {"cert": {"type": "string", "minLength": 1}}}
+          ]
+        }
+      },
+      "required": ["tls"]
+    }
+  },
+  "required": ["settings"]
+}`), &schema))
+
+	source, _, err := Generate(&schema)
+	require.NoError(t, err)
+	assert.Contains(t, string(source), `condition     = var.settings.tls != null ? (var.settings.tls.cert != null ? length(var.settings.tls.cert) > 0 : true) : true`)
+	assert.Contains(t, string(source), `error_message = "The settings.tls.cert must not be empty."`)
+
+	c := converter.New()
+	_, err = c.ConvertString(string(source))
+	require.NoError(t, err)
+	assert.Empty(t, c.Untranslatable())
+}
+
+func TestGeneratePatternsRoundTrip(t *testing.T) {
+	var schema jsonschema.Schema
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "type": "object",
+  "properties": {
+    "code": {"type": "string", "pattern": "^\\u0041\\d$"},
+    "name": {"type": "string", "pattern": "^(?!x)[a-z]+$"}
+  },
+  "required": ["code", "name"]
+}`), &schema))
+
+	source, skipped, err := Generate(&schema)
+	require.NoError(t, err)
+	assert.Contains(t, string(source), `condition     = can(regex("^\\x{41}\\d$", var.code))`)
+	assert.NotContains(t, string(source), "(?!x)")
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "name", skipped[0].Path)
+	assert.Equal(t, "pattern", skipped[0].Keyword)
+	assert.Contains(t, skipped[0].Reason, "lookahead")
+
+	c := converter.New()
+	got, err := c.ConvertString(string(source))
+	require.NoError(t, err)
+	assert.Empty(t, c.Untranslatable())
+	// The same character as \u0041, in the form the converter writes it.
+	assert.Equal(t, `^\x41\d$`, got.Properties["code"].Pattern)
+	assert.Empty(t, got.Properties["name"].Pattern)
+}
+
+func TestGenerateErrors(t *testing.T) {
+	_, _, err := Generate(&jsonschema.Schema{Type: "object"})
+	assert.Error(t, err)
+
+	_, _, err = Generate(&jsonschema.Schema{
+		Type:       "object",
+		Properties: map[string]*jsonschema.Schema{"not a name": {Type: "string"}},
+	})
+	assert.ErrorContains(t, err, `"not a name"`)
+}
@@ -0,0 +1,47 @@
+variable "instance_count" {
+  type = number
+  validation {
+    condition     = floor(var.instance_count) == var.instance_count
+    error_message = "Instance count must be a whole number."
+  }
+}
+
+variable "port" {
+  type = number
+  validation {
+    condition     = var.port % 1 == 0
+    error_message = "Port must be a whole number."
+  }
+}
+
+variable "disk_size" {
+  type = number
+  validation {
+    condition     = var.disk_size % 8 == 0
+    error_message = "Disk size must be a multiple of 8."
+  }
+}
+
+variable "shard_count" {
+  type = number
+  validation {
+    condition     = var.shard_count % 2 == 1
+    error_message = "Shard count must be odd."
+  }
+}
+
+variable "replica_counts" {
+  type = list(number)
+  validation {
+    condition     = alltrue([for c in var.replica_counts : c % 2 == 0])
+    error_message = "Replica counts must be even."
+  }
+}
+
+variable "timeout" {
+  type = string
+  validation {
+    condition     = can(parseint(var.timeout, 10))
+    error_message = "Timeout must be an integer."
+  }
+}
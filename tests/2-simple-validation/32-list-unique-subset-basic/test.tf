@@ -0,0 +1,23 @@
+variable "availability_zones" {
+  type = list(string)
+  validation {
+    condition     = length(distinct(var.availability_zones)) == length(var.availability_zones)
+    error_message = "Availability zones must be unique."
+  }
+}
+
+variable "features" {
+  type = list(string)
+  validation {
+    condition     = length(setsubtract(var.features, ["logging", "metrics", "tracing"])) == 0
+    error_message = "Features must be a subset of logging, metrics and tracing."
+  }
+}
+
+variable "ports" {
+  type = set(number)
+  validation {
+    condition     = length(setsubtract(var.ports, [80, 443])) == 0
+    error_message = "Only ports 80 and 443 are allowed."
+  }
+}
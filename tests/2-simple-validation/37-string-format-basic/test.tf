@@ -0,0 +1,51 @@
+locals {
+  ipv4_regex = "^((25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\\.){3}(25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)$"
+}
+
+variable "vpc_cidr" {
+  type = string
+  validation {
+    condition     = can(cidrhost(var.vpc_cidr, 0))
+    error_message = "The VPC CIDR must be a valid CIDR block."
+  }
+}
+
+variable "subnet_cidr" {
+  type = string
+  validation {
+    condition     = can(cidrnetmask(var.subnet_cidr))
+    error_message = "The subnet CIDR must be a valid IPv4 CIDR block."
+  }
+}
+
+variable "dns_server" {
+  type = string
+  validation {
+    condition     = can(regex("^(?:[0-9]{1,3}\\.){3}[0-9]{1,3}$", var.dns_server))
+    error_message = "The DNS server must be an IPv4 address."
+  }
+}
+
+variable "maintenance_start" {
+  type = string
+  validation {
+    condition     = can(timeadd(var.maintenance_start, "0s"))
+    error_message = "The maintenance start must be an RFC 3339 timestamp."
+  }
+}
+
+variable "expires_at" {
+  type = string
+  validation {
+    condition     = can(formatdate("YYYY", var.expires_at))
+    error_message = "The expiry must be an RFC 3339 timestamp."
+  }
+}
+
+variable "gateway" {
+  type = string
+  validation {
+    condition     = can(regex(local.ipv4_regex, var.gateway))
+    error_message = "The gateway must be an IPv4 address."
+  }
+}
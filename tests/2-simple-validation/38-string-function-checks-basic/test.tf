@@ -0,0 +1,31 @@
+variable "bucket_name" {
+  type = string
+  validation {
+    condition     = length(regexall("^[a-z0-9.-]+$", var.bucket_name)) > 0
+    error_message = "Bucket names may only contain lowercase letters, digits, dots and hyphens."
+  }
+  validation {
+    condition     = length(regexall("\\.\\.", var.bucket_name)) == 0
+    error_message = "Bucket names must not contain two adjacent dots."
+  }
+}
+
+variable "environment" {
+  type = string
+  validation {
+    condition     = var.environment == lower(var.environment)
+    error_message = "The environment must be lowercase."
+  }
+  validation {
+    condition     = var.environment == trimspace(var.environment)
+    error_message = "The environment must not have leading or trailing whitespace."
+  }
+}
+
+variable "replica_count" {
+  type = string
+  validation {
+    condition     = can(tonumber(var.replica_count))
+    error_message = "The replica count must be numeric."
+  }
+}
@@ -0,0 +1,49 @@
+variable "bucket_name" {
+  type        = string
+  description = "Bucket name, leaving room for a four character suffix"
+
+  validation {
+    condition     = length(var.bucket_name) <= 64 - 4
+    error_message = "The bucket name must leave room for the suffix."
+  }
+}
+
+variable "size_gb" {
+  type        = number
+  description = "Volume size in GiB, up to 16 TiB"
+
+  validation {
+    condition     = var.size_gb >= 1 && var.size_gb <= 16 * 1024
+    error_message = "The size must be between 1 GiB and 16 TiB."
+  }
+}
+
+variable "port" {
+  type        = number
+  description = "TCP port"
+
+  validation {
+    condition     = var.port > 0 && var.port < pow(2, 16)
+    error_message = "The port must fit in 16 bits."
+  }
+}
+
+variable "offset" {
+  type        = number
+  description = "Clock offset in minutes"
+
+  validation {
+    condition     = var.offset >= -(12 * 60) && var.offset <= 14 * 60
+    error_message = "The offset must be a valid UTC offset."
+  }
+}
+
+variable "environment" {
+  type        = string
+  description = "Deployment environment"
+
+  validation {
+    condition     = contains([lower("PROD"), lower("STAGING")], var.environment)
+    error_message = "The environment must be prod or staging."
+  }
+}
@@ -0,0 +1,19 @@
+variable "cluster" {
+  description = "Cluster settings whose constraints depend on other attributes."
+  type = object({
+    enabled  = bool
+    hosts    = list(string)
+    tier     = string
+    replicas = number
+  })
+
+  validation {
+    condition     = var.cluster.enabled ? length(var.cluster.hosts) > 0 : true
+    error_message = "An enabled cluster needs at least one host."
+  }
+
+  validation {
+    condition     = var.cluster.tier == "premium" ? var.cluster.replicas >= 3 : var.cluster.replicas >= 1
+    error_message = "Premium clusters need at least 3 replicas, others at least 1."
+  }
+}
@@ -0,0 +1,30 @@
+variable "tags" {
+  type = map(string)
+  validation {
+    condition     = alltrue([for k in keys(var.tags) : can(regex("^[a-z_]+$", k))])
+    error_message = "Tag keys must be lowercase letters and underscores."
+  }
+}
+
+variable "labels" {
+  type = map(string)
+  validation {
+    condition     = alltrue([for k, v in var.labels : length(k) <= 63])
+    error_message = "Label keys must be at most 63 characters."
+  }
+  validation {
+    condition     = alltrue([for k, v in var.labels : length(v) > 0])
+    error_message = "Label values must not be empty."
+  }
+}
+
+variable "services" {
+  type = object({
+    name  = string
+    ports = map(number)
+  })
+  validation {
+    condition     = alltrue([for name, port in var.services.ports : contains(["http", "https", "grpc"], name)])
+    error_message = "Service port names must be http, https or grpc."
+  }
+}
@@ -0,0 +1,43 @@
+variable "tags" {
+  type = map(string)
+  validation {
+    condition     = contains(keys(var.tags), "owner") && contains(keys(var.tags), "cost_center")
+    error_message = "Tags must include owner and cost_center."
+  }
+}
+
+variable "database" {
+  type = object({
+    engine   = string
+    username = optional(string)
+    password = optional(string)
+  })
+  validation {
+    condition     = var.database.password != null
+    error_message = "A database password must be set."
+  }
+}
+
+variable "listeners" {
+  type = list(object({
+    port        = number
+    certificate = optional(string)
+  }))
+  validation {
+    condition     = alltrue([for l in var.listeners : l.certificate != null])
+    error_message = "Every listener needs a certificate."
+  }
+}
+
+variable "settings" {
+  type = object({
+    tls = optional(object({
+      enabled = bool
+      cert    = optional(string)
+    }))
+  })
+  validation {
+    condition     = var.settings.tls != null ? var.settings.tls.cert != null : true
+    error_message = "A certificate is required when TLS is configured."
+  }
+}
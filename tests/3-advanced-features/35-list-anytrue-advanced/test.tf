@@ -0,0 +1,34 @@
+variable "subnets" {
+  type = list(object({
+    cidr   = string
+    public = bool
+  }))
+  validation {
+    condition     = anytrue([for s in var.subnets : s.public])
+    error_message = "At least one subnet must be public."
+  }
+}
+
+variable "ports" {
+  type = list(number)
+  validation {
+    condition     = anytrue([for p in var.ports : p == 443])
+    error_message = "Port 443 must be open."
+  }
+}
+
+variable "endpoints" {
+  type = map(string)
+  validation {
+    condition     = anytrue([for name, url in var.endpoints : can(regex("^https://", url))])
+    error_message = "At least one endpoint must use HTTPS."
+  }
+}
+
+variable "tags" {
+  type = map(string)
+  validation {
+    condition     = anytrue([for k in keys(var.tags) : k == "owner"])
+    error_message = "The tags must include an owner."
+  }
+}
@@ -0,0 +1,51 @@
+variable "name" {
+  type        = string
+  description = "Resource name"
+
+  validation {
+    condition     = 3 <= length(var.name) && length(var.name) <= 24 && length(var.name) != 4
+    error_message = "The name must be 3 to 24 characters long and not 4, which is reserved for region codes."
+  }
+}
+
+variable "availability_zones" {
+  type        = list(string)
+  description = "Availability zones to deploy into"
+
+  validation {
+    condition     = length(var.availability_zones) != 0
+    error_message = "At least one availability zone is required."
+  }
+}
+
+variable "labels" {
+  type        = map(string)
+  description = "Labels applied to every resource"
+
+  validation {
+    condition     = length(keys(var.labels)) <= 64
+    error_message = "At most 64 labels are allowed."
+  }
+}
+
+variable "cluster" {
+  type = object({
+    name = string
+    node_pools = list(object({
+      name  = string
+      count = number
+    }))
+    settings = map(string)
+  })
+  description = "Cluster configuration"
+
+  validation {
+    condition     = 0 < length(var.cluster.node_pools) && 10 >= length(var.cluster.node_pools)
+    error_message = "The cluster needs between 1 and 10 node pools."
+  }
+
+  validation {
+    condition     = length(values(var.cluster.settings)) > 0
+    error_message = "The cluster needs at least one setting."
+  }
+}
@@ -0,0 +1,37 @@
+variable "tags" {
+  type        = map(string)
+  description = "Tags applied to every resource"
+
+  validation {
+    condition     = length(var.tags["env"]) > 0
+    error_message = "The env tag must not be empty."
+  }
+
+  validation {
+    condition     = alltrue([for v in var.tags : length(v) <= 64])
+    error_message = "Tag values must be at most 64 characters long."
+  }
+
+  validation {
+    condition     = contains(["dev", "staging", "prod"], var.tags["stage"])
+    error_message = "The stage tag must be dev, staging or prod."
+  }
+}
+
+variable "service" {
+  type = object({
+    name  = string
+    ports = map(number)
+  })
+  description = "Service definition"
+
+  validation {
+    condition     = var.service.ports["http"] >= 1024 && var.service.ports["http"] <= 65535
+    error_message = "The http port must be unprivileged."
+  }
+
+  validation {
+    condition     = var.service["name"] != ""
+    error_message = "The service needs a name."
+  }
+}
@@ -0,0 +1,18 @@
+variable "settings" {
+  type = object({
+    tls = optional(object({
+      cert = optional(string)
+      port = optional(number)
+    }))
+  })
+
+  validation {
+    condition     = var.settings.tls != null ? (var.settings.tls.cert != null ? length(var.settings.tls.cert) > 0 : true) : true
+    error_message = "The certificate must not be empty."
+  }
+
+  validation {
+    condition     = var.settings.tls != null ? (var.settings.tls.port != null ? var.settings.tls.port >= 1 && var.settings.tls.port <= 65535 : true) : true
+    error_message = "The TLS port must be between 1 and 65535."
+  }
+}
@@ -0,0 +1,62 @@
+# Cross-variable validation (Terraform 1.9+): conditions that reference other
+# variables are expressed on the root schema.
+
+variable "tier" {
+  type        = string
+  description = "Service tier."
+  default     = "standard"
+}
+
+variable "replicas" {
+  type        = number
+  description = "Number of replicas."
+  default     = 1
+
+  validation {
+    condition     = var.tier == "premium" ? var.replicas >= 3 : true
+    error_message = "Premium tier requires at least 3 replicas."
+  }
+}
+
+variable "enable_tls" {
+  type        = bool
+  description = "Whether TLS is enabled."
+  default     = false
+}
+
+variable "cert_arn" {
+  type        = string
+  description = "Certificate ARN, required when TLS is enabled."
+  default     = null
+
+  validation {
+    condition     = var.enable_tls ? var.cert_arn != null : true
+    error_message = "cert_arn must be set when enable_tls is true."
+  }
+}
+
+variable "key_arn" {
+  type        = string
+  description = "Key ARN, required whenever a certificate is given."
+  default     = null
+
+  validation {
+    condition     = var.cert_arn != null ? var.key_arn != null : true
+    error_message = "key_arn must be set together with cert_arn."
+  }
+}
+
+variable "min_size" {
+  type    = number
+  default = 1
+}
+
+variable "max_size" {
+  type    = number
+  default = 3
+
+  validation {
+    condition     = var.max_size >= var.min_size
+    error_message = "max_size must not be smaller than min_size."
+  }
+}
@@ -0,0 +1,35 @@
+variable "rules" {
+  type = list(object({
+    name  = string
+    ports = list(number)
+  }))
+  validation {
+    condition     = alltrue(flatten([for r in var.rules : [for p in r.ports : p > 0 && p < 65536]]))
+    error_message = "Every port must be between 1 and 65535."
+  }
+}
+
+variable "subnets" {
+  type = list(object({
+    cidr    = string
+    enabled = bool
+  }))
+  validation {
+    condition     = alltrue([for s in var.subnets : s.cidr != "" if s.enabled])
+    error_message = "Enabled subnets need a CIDR block."
+  }
+}
+
+variable "zones" {
+  type = map(object({
+    primary = bool
+    records = list(object({
+      name = string
+      ttl  = number
+    }))
+  }))
+  validation {
+    condition     = alltrue(flatten([for z in var.zones : [for rec in z.records : rec.ttl >= 60] if z.primary]))
+    error_message = "Records in primary zones must have a TTL of at least 60 seconds."
+  }
+}
@@ -0,0 +1,31 @@
+variable "environment" {
+  type = string
+  validation {
+    condition     = can(regex("(?i)^(dev|prod)$", var.environment))
+    error_message = "The environment must be dev or prod, in any case."
+  }
+}
+
+variable "resource_name" {
+  type = string
+  validation {
+    condition     = can(regex("\\A[[:alpha:]][[:alnum:]_-]*\\z", var.resource_name))
+    error_message = "Resource names must start with a letter."
+  }
+}
+
+variable "display_name" {
+  type = string
+  validation {
+    condition     = can(regex("^[\\pL ]+$", var.display_name))
+    error_message = "Display names may only contain letters and spaces."
+  }
+}
+
+variable "banner" {
+  type = string
+  validation {
+    condition     = can(regex("(?m)^[A-Z]", var.banner))
+    error_message = "Every line of the banner must start with a capital letter."
+  }
+}
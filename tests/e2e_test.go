@@ -7,9 +7,16 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/alex-tw-lam/tfschema/internal/constraint"
 	"github.com/alex-tw-lam/tfschema/internal/converter"
+	"github.com/alex-tw-lam/tfschema/internal/crd"
+	"github.com/alex-tw-lam/tfschema/internal/example"
+	"github.com/alex-tw-lam/tfschema/internal/jsonschema"
+	"github.com/alex-tw-lam/tfschema/internal/rego"
+	"github.com/alex-tw-lam/tfschema/internal/variables"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
 func TestEndToEnd(t *testing.T) {
@@ -81,3 +88,138 @@ func runTest(_ *testing.T, _ string) error {
 	// ... existing code ...
 	return nil
 }
+
+// TestConstraintsAcceptFixtureInputs evaluates the lowered validations of each
+// fixture against its test.tfvar.json, which Terraform accepts.
+func TestConstraintsAcceptFixtureInputs(t *testing.T) {
+	testCases, err := discoverTestCases("./")
+	require.NoError(t, err, "Failed to discover test cases")
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			inputs, err := ioutil.ReadFile(filepath.Join(filepath.Dir(tc.TerraformFile), "test.tfvar.json"))
+			if os.IsNotExist(err) {
+				t.Skip("no test.tfvar.json")
+			}
+			require.NoError(t, err)
+			ty, err := ctyjson.ImpliedType(inputs)
+			require.NoError(t, err)
+			value, err := ctyjson.Unmarshal(inputs, ty)
+			require.NoError(t, err)
+
+			c := converter.New()
+			_, err = c.ConvertFile(tc.TerraformFile)
+			require.NoError(t, err)
+
+			for _, v := range c.Validations() {
+				ok, err := constraint.Evaluate(v.Constraint, value)
+				if err != nil {
+					t.Errorf("cannot evaluate %s: %v", v.Source, err)
+					continue
+				}
+				assert.True(t, ok, "inputs do not satisfy %s (%s)", v.Source, constraint.Describe(v.Constraint))
+			}
+		})
+	}
+}
+
+// TestExamplesSatisfyConstraints checks that the example inputs built from
+// each fixture's schema satisfy its lowered validations. Defaults are taken
+// as they are, so only the variables without one are checked.
+func TestExamplesSatisfyConstraints(t *testing.T) {
+	testCases, err := discoverTestCases("./")
+	require.NoError(t, err, "Failed to discover test cases")
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := converter.New()
+			_, err := c.ConvertFile(tc.TerraformFile)
+			require.NoError(t, err)
+
+			required := map[string]bool{}
+			for _, v := range c.Variables() {
+				required[v.Name] = v.Required
+			}
+			failed, err := example.Check(example.Generate(c.Variables()), c.Validations())
+			require.NoError(t, err)
+			for _, v := range failed {
+				assert.False(t, required[v.Variable], "example does not satisfy %s (%s)", v.Source, constraint.Describe(v.Constraint))
+			}
+		})
+	}
+}
+
+func TestVariablesRoundTrip(t *testing.T) {
+	testCases, err := discoverTestCases("./")
+	require.NoError(t, err, "Failed to discover test cases")
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			data, err := os.ReadFile(tc.SchemaFile)
+			require.NoError(t, err)
+			var schema jsonschema.Schema
+			require.NoError(t, json.Unmarshal(data, &schema))
+
+			source, _, err := variables.Generate(&schema)
+			require.NoError(t, err)
+
+			c := converter.New()
+			_, err = c.ConvertString(string(source))
+			require.NoError(t, err, "generated variables:\n%s", source)
+			assert.Empty(t, c.Untranslatable(), "generated variables:\n%s", source)
+
+			required := map[string]bool{}
+			for _, v := range c.Variables() {
+				required[v.Name] = v.Required
+			}
+			expected := map[string]bool{}
+			for name := range schema.Properties {
+				expected[name] = false
+			}
+			if schema.Required != nil {
+				for _, name := range *schema.Required {
+					expected[name] = true
+				}
+			}
+			assert.Equal(t, expected, required)
+		})
+	}
+}
+
+// TestCRDChecksFixtureValidations generates a CRD for each fixture and checks
+// that every validation is either a structural keyword or a CEL rule.
+func TestCRDChecksFixtureValidations(t *testing.T) {
+	testCases, err := discoverTestCases("./")
+	require.NoError(t, err, "Failed to discover test cases")
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := converter.New()
+			_, err := c.ConvertFile(tc.TerraformFile)
+			require.NoError(t, err)
+
+			manifest, unchecked, err := crd.Generate("", tc.Name, c.Variables(), c.Validations(), c.Untranslatable())
+			require.NoError(t, err)
+			assert.Empty(t, unchecked)
+			assert.NotContains(t, manifest, "anyOf")
+		})
+	}
+}
+
+func TestRegoChecksFixtureValidations(t *testing.T) {
+	testCases, err := discoverTestCases("./")
+	require.NoError(t, err, "Failed to discover test cases")
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c := converter.New()
+			_, err := c.ConvertFile(tc.TerraformFile)
+			require.NoError(t, err)
+
+			// Without the untranslatable conditions, every TODO is a
+			// translated validation the policy does not check.
+			policy := rego.Generate("terraform.test", c.Variables(), c.Validations(), nil)
+			assert.NotContains(t, policy, "# TODO")
+		})
+	}
+}